@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// AttendanceController 考勤控制器，需要权限5（统计分析）
+type AttendanceController struct {
+	attendanceService     service.AttendanceService     // 依赖的服务层，处理班次与考勤计算
+	controllerUserService service.ControllerUserService // 依赖的服务层，用于校验用户权限
+}
+
+// NewAttendanceController 创建并返回一个新的 AttendanceController 实例
+func NewAttendanceController(service service.AttendanceService, controllerUserService service.ControllerUserService) *AttendanceController {
+	return &AttendanceController{
+		attendanceService:     service,
+		controllerUserService: controllerUserService,
+	}
+}
+
+// checkPermission 校验当前用户是否具有统计分析权限，返回用户 ID
+func (controller *AttendanceController) checkPermission(ctx *gin.Context) uint {
+	uid, exists := ctx.Get("id")
+	requirePermission(uid, exists, controller.controllerUserService, permissionStatistics)
+	return uid.(uint)
+}
+
+// respond 返回处理结果
+func (controller *AttendanceController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAllShifts 查询所有班次
+// 路由：GET /attendance/shift
+func (controller *AttendanceController) FindAllShifts(ctx *gin.Context) {
+	log.Println("findAll shift")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.FindAllShifts())
+}
+
+// CreateShift 创建班次
+// 路由：POST /attendance/shift
+func (controller *AttendanceController) CreateShift(ctx *gin.Context) {
+	log.Println("create shift")
+	controller.checkPermission(ctx)
+
+	createShiftRequest := request.CreateShiftRequest{}
+	err := ctx.ShouldBindJSON(&createShiftRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(createShiftRequest))
+
+	controller.respond(ctx, controller.attendanceService.CreateShift(createShiftRequest))
+}
+
+// UpdateShift 更新班次
+// 路由：PATCH /attendance/shift/:shiftId
+func (controller *AttendanceController) UpdateShift(ctx *gin.Context) {
+	log.Println("update shift")
+	controller.checkPermission(ctx)
+
+	updateShiftRequest := request.UpdateShiftRequest{}
+	err := ctx.ShouldBindJSON(&updateShiftRequest)
+	utils.ErrorPanic(err)
+
+	updateShiftRequest.ID = cast.ToUint(ctx.Param("shiftId"))
+
+	log.Printf("%s", litter.Sdump(updateShiftRequest))
+
+	controller.attendanceService.UpdateShift(updateShiftRequest)
+	controller.respond(ctx, nil)
+}
+
+// DeleteShift 删除班次
+// 路由：DELETE /attendance/shift/:shiftId
+func (controller *AttendanceController) DeleteShift(ctx *gin.Context) {
+	log.Println("delete shift")
+	controller.checkPermission(ctx)
+
+	controller.attendanceService.DeleteShift(cast.ToUint(ctx.Param("shiftId")))
+	controller.respond(ctx, nil)
+}
+
+// FindAllAssignments 查询所有班次分配
+// 路由：GET /attendance/assignment
+func (controller *AttendanceController) FindAllAssignments(ctx *gin.Context) {
+	log.Println("findAll shift assignment")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.FindAllAssignments())
+}
+
+// CreateAssignment 将班次分配给人员或部门
+// 路由：POST /attendance/assignment
+func (controller *AttendanceController) CreateAssignment(ctx *gin.Context) {
+	log.Println("create shift assignment")
+	controller.checkPermission(ctx)
+
+	createShiftAssignmentRequest := request.CreateShiftAssignmentRequest{}
+	err := ctx.ShouldBindJSON(&createShiftAssignmentRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(createShiftAssignmentRequest))
+
+	controller.respond(ctx, controller.attendanceService.CreateAssignment(createShiftAssignmentRequest))
+}
+
+// DeleteAssignment 删除班次分配
+// 路由：DELETE /attendance/assignment/:assignmentId
+func (controller *AttendanceController) DeleteAssignment(ctx *gin.Context) {
+	log.Println("delete shift assignment")
+	controller.checkPermission(ctx)
+
+	controller.attendanceService.DeleteAssignment(cast.ToUint(ctx.Param("assignmentId")))
+	controller.respond(ctx, nil)
+}
+
+// FindReaders 查询考勤读卡器
+// 路由：GET /attendance/reader
+func (controller *AttendanceController) FindReaders(ctx *gin.Context) {
+	log.Println("find attendance readers")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.FindReaders())
+}
+
+// UpdateReaders 设置考勤读卡器
+// 路由：PUT /attendance/reader
+func (controller *AttendanceController) UpdateReaders(ctx *gin.Context) {
+	log.Println("update attendance readers")
+	controller.checkPermission(ctx)
+
+	updateAttendanceReadersRequest := request.UpdateAttendanceReadersRequest{}
+	err := ctx.ShouldBindJSON(&updateAttendanceReadersRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(updateAttendanceReadersRequest))
+
+	controller.attendanceService.UpdateReaders(updateAttendanceReadersRequest)
+	controller.respond(ctx, nil)
+}
+
+// FindRecords 查询指定日期的考勤结果
+// 路由：GET /attendance/record?date=2006-01-02&peopleId=1
+func (controller *AttendanceController) FindRecords(ctx *gin.Context) {
+	log.Println("find attendance records")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.FindRecords(ctx.Query("date"), cast.ToUint(ctx.Query("peopleId"))))
+}
+
+// Calculate 重新计算指定日期的考勤结果
+// 路由：POST /attendance/calculate
+func (controller *AttendanceController) Calculate(ctx *gin.Context) {
+	log.Println("calculate attendance")
+	controller.checkPermission(ctx)
+
+	calculateAttendanceRequest := request.CalculateAttendanceRequest{}
+	err := ctx.ShouldBindJSON(&calculateAttendanceRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(calculateAttendanceRequest))
+
+	controller.attendanceService.Calculate(calculateAttendanceRequest.Date)
+	controller.respond(ctx, nil)
+}
+
+// FindCorrections 查询指定月份的人工更正记录
+// 路由：GET /attendance/correction?month=2006-01&peopleId=1
+func (controller *AttendanceController) FindCorrections(ctx *gin.Context) {
+	log.Println("find attendance corrections")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.FindCorrections(ctx.Query("month"), cast.ToUint(ctx.Query("peopleId"))))
+}
+
+// CreateCorrection 人工更正考勤
+// 路由：POST /attendance/correction
+func (controller *AttendanceController) CreateCorrection(ctx *gin.Context) {
+	log.Println("create attendance correction")
+	uid := controller.checkPermission(ctx)
+
+	createAttendanceCorrectionRequest := request.CreateAttendanceCorrectionRequest{}
+	err := ctx.ShouldBindJSON(&createAttendanceCorrectionRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(createAttendanceCorrectionRequest))
+
+	controller.respond(ctx, controller.attendanceService.CreateCorrection(createAttendanceCorrectionRequest, uid))
+}
+
+// MonthlyReport 查询月度考勤汇总
+// 路由：GET /attendance/report?month=2006-01
+func (controller *AttendanceController) MonthlyReport(ctx *gin.Context) {
+	log.Println("attendance monthly report")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.attendanceService.MonthlyReport(ctx.Query("month")))
+}
+
+// ExportMonthlyReport 导出月度考勤汇总 CSV
+// 路由：GET /attendance/report/export?month=2006-01
+func (controller *AttendanceController) ExportMonthlyReport(ctx *gin.Context) {
+	log.Println("export attendance monthly report")
+	controller.checkPermission(ctx)
+
+	month := ctx.Query("month")
+	data := controller.attendanceService.ExportMonthlyReport(month)
+
+	filename := "attendance-" + month + ".csv"
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
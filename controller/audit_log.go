@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// AuditLogController 用于查询操作审计日志的控制器
+type AuditLogController struct {
+	auditLogService service.AuditLogService // 依赖的服务层，处理操作审计日志
+}
+
+// NewAuditLogController 创建并返回一个新的 AuditLogController 实例
+func NewAuditLogController(service service.AuditLogService) *AuditLogController {
+	return &AuditLogController{
+		auditLogService: service,
+	}
+}
+
+// FindAll 分页查询操作审计日志
+// 路由：GET /audit
+func (controller *AuditLogController) FindAll(ctx *gin.Context) {
+	log.Println("findAll audit log")
+
+	pg := utils.NewPagination(ctx)
+	auditLogResponse := controller.auditLogService.FindAll(pg)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    auditLogResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
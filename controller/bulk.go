@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// BulkController 人员和凭证批量操作控制器
+type BulkController struct {
+	bulkService service.BulkService // 依赖的服务层，处理批量操作
+}
+
+// NewBulkController 创建并返回一个新的 BulkController 实例
+func NewBulkController(service service.BulkService) *BulkController {
+	return &BulkController{
+		bulkService: service,
+	}
+}
+
+// People 人员批量操作
+// 路由：POST /bulk/people
+func (controller *BulkController) People(ctx *gin.Context) {
+	log.Println("bulk people")
+
+	bulkRequest := request.BulkRequest{}
+	err := ctx.ShouldBindJSON(&bulkRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(bulkRequest))
+
+	controller.respond(ctx, controller.bulkService.People(bulkRequest))
+}
+
+// Credentials 凭证批量操作
+// 路由：POST /bulk/credential
+func (controller *BulkController) Credentials(ctx *gin.Context) {
+	log.Println("bulk credential")
+
+	bulkRequest := request.BulkRequest{}
+	err := ctx.ShouldBindJSON(&bulkRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(bulkRequest))
+
+	controller.respond(ctx, controller.bulkService.Credentials(bulkRequest))
+}
+
+// respond 返回批量操作结果，有对象生效时统一同步一次
+func (controller *BulkController) respond(ctx *gin.Context, bulkResponse response.BulkResponse) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    bulkResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+
+	if bulkResponse.Succeeded > 0 {
+		DataSync()
+	}
+}
@@ -17,13 +17,15 @@ import (
 // CredentialController 用于管理凭证（Credential）的控制器
 type CredentialController struct {
 	credentialService service.CredentialService // 依赖 CredentialService 提供具体业务逻辑
+	bulkService       service.BulkService       // 停用的凭证修改门禁组后保存到停用期间的门禁组
 }
 
 // NewCredentialController 创建一个新的 CredentialController 实例
-// 参数：service 提供业务逻辑的 CredentialService
-func NewCredentialController(service service.CredentialService) *CredentialController {
+// 参数：service 提供业务逻辑的 CredentialService，bulkService 处理停用凭证的门禁组
+func NewCredentialController(service service.CredentialService, bulkService service.BulkService) *CredentialController {
 	return &CredentialController{
 		credentialService: service,
+		bulkService:       bulkService,
 	}
 }
 
@@ -213,6 +215,9 @@ func (controller *CredentialController) UpdateDoor(ctx *gin.Context) {
 	// 调用业务层的 UpdateDoor 方法更新门信息
 	doorResponse := controller.credentialService.UpdateDoor(updateCredentialDoorRequest)
 
+	// 停用的凭证不能有门禁组，写入的门禁组保存到停用期间的门禁组，启用时恢复
+	controller.bulkService.SuspendAccess(credentialId)
+
 	// 构造标准化的 HTTP 响应
 	webResponse := response.Response{
 		Code:    http.StatusOK,
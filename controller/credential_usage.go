@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// CredentialUsageController 凭证使用情况控制器
+type CredentialUsageController struct {
+	credentialUsageService service.CredentialUsageService // 依赖的服务层，汇总使用情况并停用长期未使用的凭证
+}
+
+// NewCredentialUsageController 创建并返回一个新的 CredentialUsageController 实例
+func NewCredentialUsageController(service service.CredentialUsageService) *CredentialUsageController {
+	return &CredentialUsageController{
+		credentialUsageService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *CredentialUsageController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAll 查询所有凭证的使用情况
+// 路由：GET /credential/usage
+func (controller *CredentialUsageController) FindAll(ctx *gin.Context) {
+	log.Println("findAll credential usage")
+
+	controller.respond(ctx, controller.credentialUsageService.FindAll())
+}
+
+// Stale 查询超过指定天数未使用的凭证，查询参数 days 默认 90
+// 路由：GET /credential/stale
+func (controller *CredentialUsageController) Stale(ctx *gin.Context) {
+	log.Println("find stale credential")
+
+	days := cast.ToUint(ctx.Query("days"))
+	controller.respond(ctx, controller.credentialUsageService.Stale(days))
+}
+
+// DisableStale 停用超过指定天数未使用的凭证
+// 路由：POST /credential/stale/disable
+func (controller *CredentialUsageController) DisableStale(ctx *gin.Context) {
+	log.Println("disable stale credential")
+
+	disableRequest := request.DisableStaleCredentialRequest{}
+	err := ctx.ShouldBindJSON(&disableRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(disableRequest))
+
+	bulkResponse := controller.credentialUsageService.DisableStale(disableRequest)
+	controller.respond(ctx, bulkResponse)
+
+	if bulkResponse.Succeeded > 0 {
+		DataSync()
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// CredentialValidityController 凭证和人员有效期控制器
+type CredentialValidityController struct {
+	credentialValidityService service.CredentialValidityService // 依赖的服务层，处理有效期
+}
+
+// NewCredentialValidityController 创建并返回一个新的 CredentialValidityController 实例
+func NewCredentialValidityController(service service.CredentialValidityService) *CredentialValidityController {
+	return &CredentialValidityController{
+		credentialValidityService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *CredentialValidityController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// UpdateCredential 设置凭证有效期
+// 路由：PATCH /credential/:credentialId/validity
+func (controller *CredentialValidityController) UpdateCredential(ctx *gin.Context) {
+	log.Println("update credential validity")
+
+	updateValidityRequest := request.UpdateValidityRequest{}
+	err := ctx.ShouldBindJSON(&updateValidityRequest)
+	utils.ErrorPanic(err)
+
+	updateValidityRequest.ID = cast.ToUint(ctx.Param("credentialId"))
+
+	log.Printf("%s", litter.Sdump(updateValidityRequest))
+
+	validityResponse := controller.credentialValidityService.SetCredentialValidity(updateValidityRequest)
+	controller.respond(ctx, validityResponse)
+
+	// 凭证被启用或停用时触发数据同步
+	if validityResponse.Changed {
+		DataSync()
+	}
+}
+
+// UpdatePeople 设置人员有效期
+// 路由：PATCH /people/:peopleId/validity
+func (controller *CredentialValidityController) UpdatePeople(ctx *gin.Context) {
+	log.Println("update people validity")
+
+	updateValidityRequest := request.UpdateValidityRequest{}
+	err := ctx.ShouldBindJSON(&updateValidityRequest)
+	utils.ErrorPanic(err)
+
+	updateValidityRequest.ID = cast.ToUint(ctx.Param("peopleId"))
+
+	log.Printf("%s", litter.Sdump(updateValidityRequest))
+
+	validityResponse := controller.credentialValidityService.SetPeopleValidity(updateValidityRequest)
+	controller.respond(ctx, validityResponse)
+
+	// 凭证被启用或停用时触发数据同步
+	if validityResponse.Changed {
+		DataSync()
+	}
+}
+
+// Expiring 查询即将到期的凭证，days 默认 30 天
+// 路由：GET /credential/expiring?days=N
+func (controller *CredentialValidityController) Expiring(ctx *gin.Context) {
+	log.Println("expiring credential")
+
+	days := cast.ToInt(ctx.Query("days"))
+	controller.respond(ctx, controller.credentialValidityService.Expiring(days))
+}
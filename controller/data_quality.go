@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+)
+
+// DataQualityController 数据质量报告控制器
+type DataQualityController struct {
+	dataQualityService service.DataQualityService // 依赖的服务层，汇总重复和失效的数据
+}
+
+// NewDataQualityController 创建并返回一个新的 DataQualityController 实例
+func NewDataQualityController(service service.DataQualityService) *DataQualityController {
+	return &DataQualityController{
+		dataQualityService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *DataQualityController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Report 生成数据质量报告：重复卡号、没有凭证的人员、没有门禁组的凭证和不存在的引用
+// 路由：GET /dataQuality
+func (controller *DataQualityController) Report(ctx *gin.Context) {
+	log.Println("data quality report")
+
+	controller.respond(ctx, controller.dataQualityService.Report())
+}
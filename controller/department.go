@@ -16,13 +16,15 @@ import (
 
 // DepartmentController 用于管理 Department 实体的控制器
 type DepartmentController struct {
-	departmentService service.DepartmentService // 依赖的服务层，用于处理 Department 数据的业务逻辑
+	departmentService     service.DepartmentService     // 依赖的服务层，用于处理 Department 数据的业务逻辑
+	departmentTreeService service.DepartmentTreeService // 部门层级和部门门禁组
 }
 
 // NewDepartmentController 创建并返回一个新的 DepartmentController 实例
-func NewDepartmentController(service service.DepartmentService) *DepartmentController {
+func NewDepartmentController(service service.DepartmentService, departmentTreeService service.DepartmentTreeService) *DepartmentController {
 	return &DepartmentController{
-		departmentService: service,
+		departmentService:     service,
+		departmentTreeService: departmentTreeService,
 	}
 }
 
@@ -54,21 +56,41 @@ func (controller *DepartmentController) Create(ctx *gin.Context) {
 	DataSync()
 }
 
-// Update 更新指定的 Department 实体
+// Update 修改部门名称或上级部门
+// 路由：PATCH /department/:departmentId
 func (controller *DepartmentController) Update(ctx *gin.Context) {
 	log.Println("update department")
 
+	updateDepartmentNodeRequest := request.UpdateDepartmentNodeRequest{}
+	err := ctx.ShouldBindJSON(&updateDepartmentNodeRequest)
+	utils.ErrorPanic(err)
+	updateDepartmentNodeRequest.ID = cast.ToUint(ctx.Param("departmentId"))
+
+	log.Printf("%s", litter.Sdump(updateDepartmentNodeRequest))
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.departmentTreeService.Update(updateDepartmentNodeRequest),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+
+	DataSync()
 }
 
 // Delete 删除指定的 Department 实体
+// 部门有人员或下级部门时需指定 reassignTo，人员和下级部门转移到该部门，否则拒绝删除
+// 路由：DELETE /department/:departmentId?reassignTo=
 func (controller *DepartmentController) Delete(ctx *gin.Context) {
 	log.Println("delete department")
 
 	// 从 URL 参数中获取 departmentId
 	departmentId := cast.ToUint(ctx.Param("departmentId"))
+	reassignTo := cast.ToUint(ctx.Query("reassignTo"))
 
 	// 调用服务层方法删除指定的 Department 实体
-	controller.departmentService.Delete(departmentId)
+	controller.departmentTreeService.Delete(departmentId, reassignTo)
 
 	// 构造响应并返回
 	webResponse := response.Response{
@@ -122,3 +144,79 @@ func (controller *DepartmentController) FindAll(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, webResponse)
 }
+
+// Tree 查询部门树
+// 路由：GET /department/tree
+func (controller *DepartmentController) Tree(ctx *gin.Context) {
+	log.Println("department tree")
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.departmentTreeService.Tree(),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// CreateChild 创建下级部门
+// 路由：POST /department/:departmentId/children
+func (controller *DepartmentController) CreateChild(ctx *gin.Context) {
+	log.Println("create sub-department")
+
+	createSubDepartmentRequest := request.CreateSubDepartmentRequest{}
+	err := ctx.ShouldBindJSON(&createSubDepartmentRequest)
+	utils.ErrorPanic(err)
+	createSubDepartmentRequest.ParentId = cast.ToUint(ctx.Param("departmentId"))
+
+	log.Printf("%s", litter.Sdump(createSubDepartmentRequest))
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.departmentTreeService.CreateChild(createSubDepartmentRequest),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+
+	DataSync()
+}
+
+// FindAccess 查询部门的门禁组，包括从上级部门继承的门禁组
+// 路由：GET /department/:departmentId/access
+func (controller *DepartmentController) FindAccess(ctx *gin.Context) {
+	log.Println("find department access")
+
+	departmentId := cast.ToUint(ctx.Param("departmentId"))
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.departmentTreeService.FindAccess(departmentId),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// SetAccess 设置部门的门禁组，部门及其下级部门的人员继承这些门禁组
+// 路由：PUT /department/:departmentId/access
+func (controller *DepartmentController) SetAccess(ctx *gin.Context) {
+	log.Println("set department access")
+
+	setDepartmentAccessRequest := request.SetDepartmentAccessRequest{}
+	err := ctx.ShouldBindJSON(&setDepartmentAccessRequest)
+	utils.ErrorPanic(err)
+	setDepartmentAccessRequest.ID = cast.ToUint(ctx.Param("departmentId"))
+
+	log.Printf("%s", litter.Sdump(setDepartmentAccessRequest))
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.departmentTreeService.SetAccess(setDepartmentAccessRequest),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+
+	DataSync()
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// EnrollmentController 刷卡登记凭证控制器
+type EnrollmentController struct {
+	enrollmentService service.EnrollmentService // 依赖的服务层，处理刷卡登记会话
+}
+
+// NewEnrollmentController 创建并返回一个新的 EnrollmentController 实例
+func NewEnrollmentController(service service.EnrollmentService) *EnrollmentController {
+	return &EnrollmentController{
+		enrollmentService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *EnrollmentController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Start 开始刷卡登记，刷卡后凭证自动创建并同步
+// 路由：POST /enrollment
+func (controller *EnrollmentController) Start(ctx *gin.Context) {
+	log.Println("start enrollment")
+
+	startRequest := request.StartEnrollmentRequest{}
+	err := ctx.ShouldBindJSON(&startRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(startRequest))
+
+	controller.respond(ctx, controller.enrollmentService.Start(startRequest))
+}
+
+// FindAll 查询最近的刷卡登记会话
+// 路由：GET /enrollment
+func (controller *EnrollmentController) FindAll(ctx *gin.Context) {
+	log.Println("findAll enrollment")
+
+	controller.respond(ctx, controller.enrollmentService.FindAll())
+}
+
+// FindById 查询刷卡登记会话
+// 路由：GET /enrollment/:sessionId
+func (controller *EnrollmentController) FindById(ctx *gin.Context) {
+	log.Println("findById enrollment")
+
+	controller.respond(ctx, controller.enrollmentService.FindById(cast.ToUint(ctx.Param("sessionId"))))
+}
+
+// Cancel 取消刷卡登记会话
+// 路由：DELETE /enrollment/:sessionId
+func (controller *EnrollmentController) Cancel(ctx *gin.Context) {
+	log.Println("cancel enrollment")
+
+	controller.enrollmentService.Cancel(cast.ToUint(ctx.Param("sessionId")))
+	controller.respond(ctx, nil)
+}
+
+// CreateFromEvent 将未注册卡事件中的卡号登记为人员的凭证，messageId 为事件消息 ID（msgid）
+// 路由：POST /event/:messageId/credential
+func (controller *EnrollmentController) CreateFromEvent(ctx *gin.Context) {
+	log.Println("create credential from event")
+
+	createRequest := request.CreateCredentialFromEventRequest{}
+	err := ctx.ShouldBindJSON(&createRequest)
+	utils.ErrorPanic(err)
+
+	createRequest.MsgId = cast.ToUint(ctx.Param("messageId"))
+
+	log.Printf("%s", litter.Sdump(createRequest))
+
+	controller.respond(ctx, controller.enrollmentService.CreateFromEvent(createRequest))
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// LostCardController 挂失卡控制器
+type LostCardController struct {
+	lostCardService service.LostCardService // 依赖的服务层，处理挂失、补发和黑名单
+}
+
+// NewLostCardController 创建并返回一个新的 LostCardController 实例
+func NewLostCardController(service service.LostCardService) *LostCardController {
+	return &LostCardController{
+		lostCardService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *LostCardController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAll 查询挂失卡黑名单
+// 路由：GET /credential/lost
+func (controller *LostCardController) FindAll(ctx *gin.Context) {
+	log.Println("findAll lost card")
+
+	controller.respond(ctx, controller.lostCardService.FindAll())
+}
+
+// Report 挂失凭证
+// 路由：POST /credential/:credentialId/lost
+func (controller *LostCardController) Report(ctx *gin.Context) {
+	log.Println("report lost card")
+
+	reportRequest := request.ReportLostCardRequest{}
+	err := ctx.ShouldBindJSON(&reportRequest)
+	utils.ErrorPanic(err)
+
+	reportRequest.UniqueId = cast.ToUint(ctx.Param("credentialId"))
+
+	log.Printf("%s", litter.Sdump(reportRequest))
+
+	lostCardResponse := controller.lostCardService.Report(reportRequest)
+	DataSync()
+	controller.respond(ctx, lostCardResponse)
+}
+
+// Replace 为挂失卡补发新卡
+// 路由：POST /credential/lost/:lostCardId/replace
+func (controller *LostCardController) Replace(ctx *gin.Context) {
+	log.Println("replace lost card")
+
+	replaceRequest := request.ReplaceLostCardRequest{}
+	err := ctx.ShouldBindJSON(&replaceRequest)
+	utils.ErrorPanic(err)
+
+	replaceRequest.ID = cast.ToUint(ctx.Param("lostCardId"))
+
+	log.Printf("%s", litter.Sdump(replaceRequest))
+
+	lostCardResponse := controller.lostCardService.Replace(replaceRequest)
+	DataSync()
+	controller.respond(ctx, lostCardResponse)
+}
+
+// Delete 将卡号移出黑名单
+// 路由：DELETE /credential/lost/:lostCardId
+func (controller *LostCardController) Delete(ctx *gin.Context) {
+	log.Println("delete lost card")
+
+	controller.lostCardService.Delete(cast.ToUint(ctx.Param("lostCardId")))
+	controller.respond(ctx, nil)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// MqttController 用于管理 MQTT 客户端配置的控制器
+type MqttController struct {
+	mqttService service.MqttService // 依赖的服务层，处理 MQTT 连接与发布
+}
+
+// NewMqttController 创建并返回一个新的 MqttController 实例
+func NewMqttController(service service.MqttService) *MqttController {
+	return &MqttController{
+		mqttService: service,
+	}
+}
+
+// FindConfig 查询 MQTT 配置
+// 路由：GET /mqtt
+func (controller *MqttController) FindConfig(ctx *gin.Context) {
+	log.Println("find mqtt config")
+
+	mqttConfigResponse := controller.mqttService.FindConfig()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    mqttConfigResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// UpdateConfig 更新 MQTT 配置，保存后按新配置重新连接
+// 路由：PATCH /mqtt
+func (controller *MqttController) UpdateConfig(ctx *gin.Context) {
+	log.Println("update mqtt config")
+
+	// 解析并绑定请求体到 UpdateMqttConfigRequest 结构体
+	updateMqttConfigRequest := request.UpdateMqttConfigRequest{}
+	err := ctx.ShouldBindJSON(&updateMqttConfigRequest)
+	utils.ErrorPanic(err)
+
+	// 日志中隐藏密码
+	logRequest := updateMqttConfigRequest
+	if logRequest.Password != nil {
+		password := redactSecret(*logRequest.Password)
+		logRequest.Password = &password
+	}
+	log.Printf("%s", litter.Sdump(logRequest))
+
+	mqttConfigResponse := controller.mqttService.UpdateConfig(updateMqttConfigRequest)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    mqttConfigResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Status 查询 MQTT 连接状态
+// 路由：GET /mqtt/status
+func (controller *MqttController) Status(ctx *gin.Context) {
+	log.Println("mqtt status")
+
+	mqttStatusResponse := controller.mqttService.Status()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    mqttStatusResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Test 发布一条测试消息
+// 路由：POST /mqtt/test
+func (controller *MqttController) Test(ctx *gin.Context) {
+	log.Println("test mqtt")
+
+	controller.mqttService.Test()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// OccupancyController 区域人数统计与紧急疏散点名控制器
+type OccupancyController struct {
+	occupancyService service.OccupancyService // 依赖的服务层，处理区域配置与区域内人员
+}
+
+// NewOccupancyController 创建并返回一个新的 OccupancyController 实例
+func NewOccupancyController(service service.OccupancyService) *OccupancyController {
+	return &OccupancyController{
+		occupancyService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *OccupancyController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAllAreas 查询所有区域
+// 路由：GET /area
+func (controller *OccupancyController) FindAllAreas(ctx *gin.Context) {
+	log.Println("findAll area")
+
+	controller.respond(ctx, controller.occupancyService.FindAllAreas())
+}
+
+// CreateArea 创建区域
+// 路由：POST /area
+func (controller *OccupancyController) CreateArea(ctx *gin.Context) {
+	log.Println("create area")
+
+	createAreaRequest := request.CreateAreaRequest{}
+	err := ctx.ShouldBindJSON(&createAreaRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(createAreaRequest))
+
+	controller.respond(ctx, controller.occupancyService.CreateArea(createAreaRequest))
+}
+
+// UpdateArea 更新区域
+// 路由：PATCH /area/:areaId
+func (controller *OccupancyController) UpdateArea(ctx *gin.Context) {
+	log.Println("update area")
+
+	updateAreaRequest := request.UpdateAreaRequest{}
+	err := ctx.ShouldBindJSON(&updateAreaRequest)
+	utils.ErrorPanic(err)
+
+	updateAreaRequest.ID = cast.ToUint(ctx.Param("areaId"))
+
+	log.Printf("%s", litter.Sdump(updateAreaRequest))
+
+	controller.occupancyService.UpdateArea(updateAreaRequest)
+	controller.respond(ctx, nil)
+}
+
+// DeleteArea 删除区域
+// 路由：DELETE /area/:areaId
+func (controller *OccupancyController) DeleteArea(ctx *gin.Context) {
+	log.Println("delete area")
+
+	controller.occupancyService.DeleteArea(cast.ToUint(ctx.Param("areaId")))
+	controller.respond(ctx, nil)
+}
+
+// Summary 查询各区域当前人数
+// 路由：GET /occupancy
+func (controller *OccupancyController) Summary(ctx *gin.Context) {
+	log.Println("occupancy summary")
+
+	controller.respond(ctx, controller.occupancyService.Summary())
+}
+
+// Muster 查询区域内人员名单，不指定 areaId 时返回所有区域
+// 路由：GET /occupancy/muster?areaId=1
+func (controller *OccupancyController) Muster(ctx *gin.Context) {
+	log.Println("occupancy muster")
+
+	controller.respond(ctx, controller.occupancyService.Muster(cast.ToUint(ctx.Query("areaId"))))
+}
+
+// ExportMuster 导出区域内人员名单 CSV
+// 路由：GET /occupancy/muster/export?areaId=1
+func (controller *OccupancyController) ExportMuster(ctx *gin.Context) {
+	log.Println("export occupancy muster")
+
+	data := controller.occupancyService.ExportMuster(cast.ToUint(ctx.Query("areaId")))
+
+	filename := "muster-" + time.Now().Format("20060102-150405") + ".csv"
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// Remove 将人员标记为已离开
+// 路由：DELETE /occupancy/:peopleId
+func (controller *OccupancyController) Remove(ctx *gin.Context) {
+	log.Println("remove occupant")
+
+	controller.occupancyService.Remove(cast.ToUint(ctx.Param("peopleId")))
+	controller.respond(ctx, nil)
+}
+
+// Reset 清空区域内人员，不指定 areaId 时清空所有区域
+// 路由：POST /occupancy/reset?areaId=1
+func (controller *OccupancyController) Reset(ctx *gin.Context) {
+	log.Println("reset occupancy")
+
+	controller.occupancyService.Reset(cast.ToUint(ctx.Query("areaId")))
+	controller.respond(ctx, nil)
+}
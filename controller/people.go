@@ -3,6 +3,8 @@ package controller
 import (
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sanity-io/litter"
@@ -16,22 +18,30 @@ import (
 
 // PeopleController 用于管理 People 实体的控制器
 type PeopleController struct {
-	peopleService service.PeopleService // 依赖的服务层，用于处理 People 数据的业务逻辑
+	peopleService       service.PeopleService       // 依赖的服务层，用于处理 People 数据的业务逻辑
+	peopleImportService service.PeopleImportService // 依赖的服务层，用于处理人员文件导入
 }
 
 // NewPeopleController 创建并返回一个新的 PeopleController 实例
-func NewPeopleController(service service.PeopleService) *PeopleController {
+func NewPeopleController(service service.PeopleService, peopleImportService service.PeopleImportService) *PeopleController {
 	return &PeopleController{
-		peopleService: service,
+		peopleService:       service,
+		peopleImportService: peopleImportService,
 	}
 }
 
+// Import 从上传的 CSV 或 XLSX 文件批量导入人员、卡号和门禁组
+// 路由：POST /people/import（multipart/form-data，字段 file、dry_run、sheet、mapping）
 func (controller *PeopleController) Import(ctx *gin.Context) {
 	log.Println("import people")
 
 	// 解析请求
-	importRequest := request.ImportPeopleRequest{}
-	if err := ctx.ShouldBindJSON(&importRequest); err != nil {
+	importRequest := request.ImportPeopleFileRequest{}
+	fileHeader, err := ctx.FormFile("file")
+	if err == nil {
+		err = ctx.ShouldBind(&importRequest)
+	}
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, response.Response{
 			Code:    http.StatusBadRequest,
 			Success: false,
@@ -40,13 +50,21 @@ func (controller *PeopleController) Import(ctx *gin.Context) {
 		return
 	}
 
-	// 调用服务层处理导入
-	result, err := controller.peopleService.Import(importRequest)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, response.Response{
-			Code:    http.StatusInternalServerError,
+	log.Printf("%s %s", fileHeader.Filename, litter.Sdump(importRequest))
+
+	file, err := fileHeader.Open()
+	utils.ErrorPanic(err)
+	defer file.Close()
+
+	// 调用服务层处理导入，存在行级错误时不写入任何数据
+	uid, _ := ctx.Get("id")
+	result := controller.peopleImportService.Import(importRequest, fileHeader.Filename, file, cast.ToUint(uid))
+	if len(result.Errors) > 0 && !result.DryRun {
+		ctx.JSON(http.StatusBadRequest, response.Response{
+			Code:    http.StatusBadRequest,
 			Success: false,
-			Message: err.Error(),
+			Message: "导入数据校验失败",
+			Data:    result,
 		})
 		return
 	}
@@ -58,6 +76,64 @@ func (controller *PeopleController) Import(ctx *gin.Context) {
 		Data:    result,
 	})
 
+	// 所有数据写入后统一同步一次
+	if !result.DryRun {
+		DataSync()
+	}
+}
+
+// Export 导出人员、卡号和门禁组，列布局与导入一致，修改后可直接重新导入
+// 路由：GET /people/export?format=xlsx（默认 csv）
+func (controller *PeopleController) Export(ctx *gin.Context) {
+	log.Println("export people")
+
+	format := ctx.DefaultQuery("format", "csv")
+	data := controller.peopleImportService.Export(format)
+
+	filename := "people-" + time.Now().Format("20060102") + ".csv"
+	contentType := "text/csv; charset=utf-8"
+	if strings.EqualFold(format, "xlsx") {
+		filename = "people-" + time.Now().Format("20060102") + ".xlsx"
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// FindAllImportBatches 分页查询人员导入批次
+// 路由：GET /people/import/batch
+func (controller *PeopleController) FindAllImportBatches(ctx *gin.Context) {
+	log.Println("findAll import batch")
+
+	pg := utils.NewPagination(ctx)
+	batchResponse := controller.peopleImportService.FindAllBatches(pg)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    batchResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// RollbackImportBatch 回滚人员导入批次
+// 路由：POST /people/import/batch/:batchId/rollback
+func (controller *PeopleController) RollbackImportBatch(ctx *gin.Context) {
+	log.Println("rollback import batch")
+
+	uid, _ := ctx.Get("id")
+	controller.peopleImportService.Rollback(cast.ToUint(ctx.Param("batchId")), cast.ToUint(uid))
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+
 	// 调用同步方法
 	DataSync()
 }
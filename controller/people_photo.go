@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// PeoplePhotoController 人员照片控制器
+type PeoplePhotoController struct {
+	peoplePhotoService service.PeoplePhotoService // 依赖的服务层，处理照片上传和缩略图
+}
+
+// NewPeoplePhotoController 创建并返回一个新的 PeoplePhotoController 实例
+func NewPeoplePhotoController(service service.PeoplePhotoService) *PeoplePhotoController {
+	return &PeoplePhotoController{
+		peoplePhotoService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *PeoplePhotoController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Upload 上传或替换人员照片，表单字段 file 为 JPEG、PNG 或 GIF 图片
+// 路由：POST /people/:peopleId/photo
+func (controller *PeoplePhotoController) Upload(ctx *gin.Context) {
+	log.Println("upload people photo")
+
+	peopleId := cast.ToUint(ctx.Param("peopleId"))
+	fileHeader, err := ctx.FormFile("file")
+	utils.ErrorPanic(err)
+
+	file, err := fileHeader.Open()
+	utils.ErrorPanic(err)
+	defer file.Close()
+
+	controller.respond(ctx, controller.peoplePhotoService.Upload(peopleId, file))
+}
+
+// UploadZip 批量上传照片，表单字段 file 为 ZIP 压缩包，文件名为人员编号
+// 路由：POST /people/photo/zip
+func (controller *PeoplePhotoController) UploadZip(ctx *gin.Context) {
+	log.Println("upload people photo zip")
+
+	fileHeader, err := ctx.FormFile("file")
+	utils.ErrorPanic(err)
+
+	log.Printf("%s %d", fileHeader.Filename, fileHeader.Size)
+
+	file, err := fileHeader.Open()
+	utils.ErrorPanic(err)
+	defer file.Close()
+
+	controller.respond(ctx, controller.peoplePhotoService.UploadZip(file, fileHeader.Size))
+}
+
+// Delete 删除人员照片
+// 路由：DELETE /people/:peopleId/photo
+func (controller *PeoplePhotoController) Delete(ctx *gin.Context) {
+	log.Println("delete people photo")
+
+	peopleId := cast.ToUint(ctx.Param("peopleId"))
+	controller.peoplePhotoService.Delete(peopleId)
+	controller.respond(ctx, nil)
+}
+
+// FindAll 查询人员照片地址，ids 为逗号分隔的人员 ID，为空时返回所有照片
+// 路由：GET /people/photo?ids=1,2,3
+func (controller *PeoplePhotoController) FindAll(ctx *gin.Context) {
+	log.Println("findAll people photo")
+
+	var peopleIds []uint
+	for _, id := range strings.Split(ctx.Query("ids"), ",") {
+		if peopleId := cast.ToUint(strings.TrimSpace(id)); peopleId > 0 {
+			peopleIds = append(peopleIds, peopleId)
+		}
+	}
+	controller.respond(ctx, controller.peoplePhotoService.FindByPeopleIds(peopleIds))
+}
+
+// Usage 查询照片存储空间使用情况
+// 路由：GET /people/photo/usage
+func (controller *PeoplePhotoController) Usage(ctx *gin.Context) {
+	log.Println("people photo usage")
+
+	controller.respond(ctx, controller.peoplePhotoService.Usage())
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/service"
+)
+
+// 权限字段取值函数，如统计分析对应 Permission5
+type permissionFunc func(user response.ControllerUserResponse) uint
+
+// permissionStatistics 权限5 统计分析
+func permissionStatistics(user response.ControllerUserResponse) uint {
+	return user.Permission5
+}
+
+// requirePermission 校验当前登录用户的功能权限
+// 只有 UserType = Ownsa 用户时权限字段才生效，出厂设置、经销服务商等账号不受限制
+func requirePermission(uid interface{}, exists bool, controllerUserService service.ControllerUserService, permission permissionFunc) {
+	if !exists {
+		panic("Not authorized")
+	}
+
+	user := controllerUserService.FindById(uid.(uint))
+	if user.UserType == model.UserTypeOwnsa && permission(user) != 1 {
+		panic("Permission denied")
+	}
+}
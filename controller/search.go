@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// SearchController 全文检索控制器
+type SearchController struct {
+	searchService service.SearchService // 依赖的服务层，检索人员、凭证和部门
+}
+
+// NewSearchController 创建并返回一个新的 SearchController 实例
+func NewSearchController(service service.SearchService) *SearchController {
+	return &SearchController{
+		searchService: service,
+	}
+}
+
+// Search 按姓名、人员编号、卡号和部门名称检索，结果按对象类型分组
+// 路由：GET /search?q=&types=&limit=
+func (controller *SearchController) Search(ctx *gin.Context) {
+	log.Println("search")
+
+	searchRequest := request.SearchRequest{}
+	err := ctx.ShouldBindQuery(&searchRequest)
+	utils.ErrorPanic(err)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    controller.searchService.Search(searchRequest),
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+)
+
+// StatisticsController 统计分析控制器，需要权限5（统计分析）
+// 所有查询接口支持 startTime、endTime 参数（"2006-01-02 15:04:05" 或 "2006-01-02"）
+type StatisticsController struct {
+	statisticsService     service.StatisticsService     // 依赖的服务层，处理事件统计
+	controllerUserService service.ControllerUserService // 依赖的服务层，用于校验用户权限
+}
+
+// NewStatisticsController 创建并返回一个新的 StatisticsController 实例
+func NewStatisticsController(service service.StatisticsService, controllerUserService service.ControllerUserService) *StatisticsController {
+	return &StatisticsController{
+		statisticsService:     service,
+		controllerUserService: controllerUserService,
+	}
+}
+
+// checkPermission 校验当前用户是否具有统计分析权限
+func (controller *StatisticsController) checkPermission(ctx *gin.Context) {
+	uid, exists := ctx.Get("id")
+	requirePermission(uid, exists, controller.controllerUserService, permissionStatistics)
+}
+
+// respond 返回查询结果
+func (controller *StatisticsController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Summary 查询允许、拒绝、报警事件数汇总
+// 路由：GET /statistics/summary
+func (controller *StatisticsController) Summary(ctx *gin.Context) {
+	log.Println("statistics summary")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.statisticsService.Summary(ctx.Query("startTime"), ctx.Query("endTime")))
+}
+
+// CountByDoor 按门汇总事件数
+// 路由：GET /statistics/door
+func (controller *StatisticsController) CountByDoor(ctx *gin.Context) {
+	log.Println("statistics by door")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.statisticsService.CountByDoor(ctx.Query("startTime"), ctx.Query("endTime")))
+}
+
+// BusiestDoors 查询通行次数最多的门
+// 路由：GET /statistics/door/top?limit=10
+func (controller *StatisticsController) BusiestDoors(ctx *gin.Context) {
+	log.Println("statistics busiest doors")
+	controller.checkPermission(ctx)
+
+	limit := cast.ToInt(ctx.Query("limit"))
+	controller.respond(ctx, controller.statisticsService.BusiestDoors(ctx.Query("startTime"), ctx.Query("endTime"), limit))
+}
+
+// CountByReader 按读卡器汇总事件数
+// 路由：GET /statistics/reader
+func (controller *StatisticsController) CountByReader(ctx *gin.Context) {
+	log.Println("statistics by reader")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.statisticsService.CountByReader(ctx.Query("startTime"), ctx.Query("endTime")))
+}
+
+// CountByDepartment 按部门汇总事件数
+// 路由：GET /statistics/department
+func (controller *StatisticsController) CountByDepartment(ctx *gin.Context) {
+	log.Println("statistics by department")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.statisticsService.CountByDepartment(ctx.Query("startTime"), ctx.Query("endTime")))
+}
+
+// Heatmap 查询按小时、星期汇总的事件热力图
+// 路由：GET /statistics/heatmap
+func (controller *StatisticsController) Heatmap(ctx *gin.Context) {
+	log.Println("statistics heatmap")
+	controller.checkPermission(ctx)
+
+	controller.respond(ctx, controller.statisticsService.Heatmap(ctx.Query("startTime"), ctx.Query("endTime")))
+}
+
+// TopDeniedCards 查询被拒绝次数最多的卡
+// 路由：GET /statistics/card/denied?limit=10
+func (controller *StatisticsController) TopDeniedCards(ctx *gin.Context) {
+	log.Println("statistics top denied cards")
+	controller.checkPermission(ctx)
+
+	limit := cast.ToInt(ctx.Query("limit"))
+	controller.respond(ctx, controller.statisticsService.TopDeniedCards(ctx.Query("startTime"), ctx.Query("endTime"), limit))
+}
+
+// Rebuild 清空统计数据并从第一条事件开始重新汇总
+// 路由：POST /statistics/rebuild
+func (controller *StatisticsController) Rebuild(ctx *gin.Context) {
+	log.Println("statistics rebuild")
+	controller.checkPermission(ctx)
+
+	controller.statisticsService.Rebuild()
+	controller.respond(ctx, nil)
+}
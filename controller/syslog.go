@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// SyslogController 用于管理 Syslog 转发配置的控制器
+type SyslogController struct {
+	syslogService service.SyslogService // 依赖的服务层，处理 Syslog 转发
+}
+
+// NewSyslogController 创建并返回一个新的 SyslogController 实例
+func NewSyslogController(service service.SyslogService) *SyslogController {
+	return &SyslogController{
+		syslogService: service,
+	}
+}
+
+// FindConfig 查询 Syslog 转发配置
+// 路由：GET /syslog
+func (controller *SyslogController) FindConfig(ctx *gin.Context) {
+	log.Println("find syslog config")
+
+	syslogConfigResponse := controller.syslogService.FindConfig()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    syslogConfigResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// UpdateConfig 更新 Syslog 转发配置，保存后按新配置重新连接
+// 路由：PATCH /syslog
+func (controller *SyslogController) UpdateConfig(ctx *gin.Context) {
+	log.Println("update syslog config")
+
+	// 解析并绑定请求体到 UpdateSyslogConfigRequest 结构体
+	updateSyslogConfigRequest := request.UpdateSyslogConfigRequest{}
+	err := ctx.ShouldBindJSON(&updateSyslogConfigRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(updateSyslogConfigRequest))
+
+	syslogConfigResponse := controller.syslogService.UpdateConfig(updateSyslogConfigRequest)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    syslogConfigResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Status 查询 Syslog 转发状态
+// 路由：GET /syslog/status
+func (controller *SyslogController) Status(ctx *gin.Context) {
+	log.Println("syslog status")
+
+	syslogStatusResponse := controller.syslogService.Status()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    syslogStatusResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Test 立即发送一条测试消息
+// 路由：POST /syslog/test
+func (controller *SyslogController) Test(ctx *gin.Context) {
+	log.Println("test syslog")
+
+	controller.syslogService.Test()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
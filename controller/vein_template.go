@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// VeinTemplateController 指静脉模板控制器
+type VeinTemplateController struct {
+	veinTemplateService service.VeinTemplateService // 依赖的服务层，处理模板的登记、删除和迁移
+}
+
+// NewVeinTemplateController 创建并返回一个新的 VeinTemplateController 实例
+func NewVeinTemplateController(service service.VeinTemplateService) *VeinTemplateController {
+	return &VeinTemplateController{
+		veinTemplateService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *VeinTemplateController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindByPeopleId 查询人员的模板
+// 路由：GET /people/:peopleId/vein
+func (controller *VeinTemplateController) FindByPeopleId(ctx *gin.Context) {
+	log.Println("findByPeopleId vein template")
+
+	controller.respond(ctx, controller.veinTemplateService.FindByPeopleId(cast.ToUint(ctx.Param("peopleId"))))
+}
+
+// Enroll 登记或替换人员指定手指的模板，请求内容不写入日志
+// 路由：PUT /people/:peopleId/vein/:fingerIndex
+func (controller *VeinTemplateController) Enroll(ctx *gin.Context) {
+	log.Println("enroll vein template")
+
+	enrollRequest := request.EnrollVeinTemplateRequest{}
+	err := ctx.ShouldBindJSON(&enrollRequest)
+	utils.ErrorPanic(err)
+
+	enrollRequest.PeopleId = cast.ToUint(ctx.Param("peopleId"))
+	enrollRequest.FingerIndex = cast.ToUint(ctx.Param("fingerIndex"))
+
+	templateResponse := controller.veinTemplateService.Enroll(enrollRequest)
+	DataSync()
+	controller.respond(ctx, templateResponse)
+}
+
+// Delete 删除人员指定手指的模板
+// 路由：DELETE /people/:peopleId/vein/:fingerIndex
+func (controller *VeinTemplateController) Delete(ctx *gin.Context) {
+	log.Println("delete vein template")
+
+	controller.veinTemplateService.Delete(cast.ToUint(ctx.Param("peopleId")), cast.ToUint(ctx.Param("fingerIndex")))
+	DataSync()
+	controller.respond(ctx, nil)
+}
+
+// DeleteByPeopleId 删除人员的所有模板
+// 路由：DELETE /people/:peopleId/vein
+func (controller *VeinTemplateController) DeleteByPeopleId(ctx *gin.Context) {
+	log.Println("delete people vein template")
+
+	controller.veinTemplateService.DeleteByPeopleId(cast.ToUint(ctx.Param("peopleId")))
+	DataSync()
+	controller.respond(ctx, nil)
+}
+
+// Usage 查询模板数量和容量
+// 路由：GET /vein/usage
+func (controller *VeinTemplateController) Usage(ctx *gin.Context) {
+	log.Println("vein template usage")
+
+	controller.respond(ctx, controller.veinTemplateService.Usage())
+}
+
+// Export 导出所有模板，导出文件使用口令加密
+// 路由：POST /vein/export
+func (controller *VeinTemplateController) Export(ctx *gin.Context) {
+	log.Println("export vein template")
+
+	exportRequest := request.ExportVeinTemplateRequest{}
+	err := ctx.ShouldBindJSON(&exportRequest)
+	utils.ErrorPanic(err)
+
+	data := controller.veinTemplateService.Export(exportRequest)
+
+	filename := "vein-" + time.Now().Format("20060102-150405") + ".json"
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Data(http.StatusOK, "application/json", data)
+}
+
+// Import 导入其他控制器导出的模板，表单字段 file 为导出文件，passphrase 为导出口令
+// 路由：POST /vein/import
+func (controller *VeinTemplateController) Import(ctx *gin.Context) {
+	log.Println("import vein template")
+
+	fileHeader, err := ctx.FormFile("file")
+	utils.ErrorPanic(err)
+
+	log.Printf("%s %d", fileHeader.Filename, fileHeader.Size)
+
+	file, err := fileHeader.Open()
+	utils.ErrorPanic(err)
+	defer file.Close()
+
+	importResponse := controller.veinTemplateService.Import(file, ctx.PostForm("passphrase"))
+	if importResponse.Succeeded > 0 {
+		DataSync()
+	}
+	controller.respond(ctx, importResponse)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// VisitorController 访客管理控制器
+type VisitorController struct {
+	visitorService service.VisitorService // 依赖的服务层，处理访客预约、签到和签离
+}
+
+// NewVisitorController 创建并返回一个新的 VisitorController 实例
+func NewVisitorController(service service.VisitorService) *VisitorController {
+	return &VisitorController{
+		visitorService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *VisitorController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Create 预约访客
+// 路由：POST /visitor
+func (controller *VisitorController) Create(ctx *gin.Context) {
+	log.Println("create visitor")
+
+	createVisitorRequest := request.CreateVisitorRequest{}
+	err := ctx.ShouldBindJSON(&createVisitorRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(createVisitorRequest))
+
+	controller.respond(ctx, controller.visitorService.Create(createVisitorRequest))
+
+	// 触发数据同步
+	DataSync()
+}
+
+// CheckIn 访客签到
+// 路由：POST /visitor/:visitorId/checkin
+func (controller *VisitorController) CheckIn(ctx *gin.Context) {
+	log.Println("check in visitor")
+
+	// 请求体可省略，表示使用预约时的卡号或密码
+	checkInVisitorRequest := request.CheckInVisitorRequest{}
+	if ctx.Request.ContentLength != 0 {
+		err := ctx.ShouldBindJSON(&checkInVisitorRequest)
+		utils.ErrorPanic(err)
+	}
+
+	checkInVisitorRequest.ID = cast.ToUint(ctx.Param("visitorId"))
+
+	log.Printf("%s", litter.Sdump(checkInVisitorRequest))
+
+	controller.respond(ctx, controller.visitorService.CheckIn(checkInVisitorRequest))
+
+	// 触发数据同步
+	DataSync()
+}
+
+// CheckOut 访客签离
+// 路由：POST /visitor/:visitorId/checkout
+func (controller *VisitorController) CheckOut(ctx *gin.Context) {
+	log.Println("check out visitor")
+
+	visitorId := cast.ToUint(ctx.Param("visitorId"))
+	controller.respond(ctx, controller.visitorService.CheckOut(visitorId))
+
+	// 触发数据同步
+	DataSync()
+}
+
+// Cancel 取消访客预约
+// 路由：DELETE /visitor/:visitorId
+func (controller *VisitorController) Cancel(ctx *gin.Context) {
+	log.Println("cancel visitor")
+
+	visitorId := cast.ToUint(ctx.Param("visitorId"))
+	controller.respond(ctx, controller.visitorService.Cancel(visitorId))
+
+	// 触发数据同步
+	DataSync()
+}
+
+// FindById 根据 ID 查询访客
+// 路由：GET /visitor/:visitorId
+func (controller *VisitorController) FindById(ctx *gin.Context) {
+	log.Println("findby visitorId")
+
+	visitorId := cast.ToUint(ctx.Param("visitorId"))
+	controller.respond(ctx, controller.visitorService.FindById(visitorId))
+}
+
+// FindAll 分页查询访客日志
+// 路由：GET /visitor?status=&keyword=&startTime=&endTime=
+func (controller *VisitorController) FindAll(ctx *gin.Context) {
+	log.Println("findAll visitor")
+
+	findVisitorRequest := request.FindVisitorRequest{}
+	err := ctx.ShouldBindQuery(&findVisitorRequest)
+	utils.ErrorPanic(err)
+
+	pg := utils.NewPagination(ctx)
+	controller.respond(ctx, controller.visitorService.FindAll(findVisitorRequest, pg))
+}
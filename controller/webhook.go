@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// WebhookController 用于管理出站 Webhook 订阅的控制器
+type WebhookController struct {
+	webhookService service.WebhookService // 依赖的服务层，处理 Webhook 订阅与投递
+}
+
+// NewWebhookController 创建并返回一个新的 WebhookController 实例
+func NewWebhookController(service service.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: service,
+	}
+}
+
+// Create 创建 Webhook 订阅
+// 路由：POST /webhook
+func (controller *WebhookController) Create(ctx *gin.Context) {
+	log.Println("create webhook")
+
+	// 解析并绑定请求体到 CreateWebhookRequest 结构体
+	createWebhookRequest := request.CreateWebhookRequest{}
+	err := ctx.ShouldBindJSON(&createWebhookRequest)
+	utils.ErrorPanic(err)
+
+	// 签名密钥不写入日志
+	logRequest := createWebhookRequest
+	logRequest.Secret = redactSecret(logRequest.Secret)
+	log.Printf("%s", litter.Sdump(logRequest))
+
+	// 调用服务层方法创建 Webhook 订阅
+	webhookResponse := controller.webhookService.Create(createWebhookRequest)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    webhookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Update 更新 Webhook 订阅
+// 路由：PATCH /webhook/:webhookId
+func (controller *WebhookController) Update(ctx *gin.Context) {
+	log.Println("update webhook")
+
+	// 解析并绑定请求体到 UpdateWebhookRequest 结构体
+	updateWebhookRequest := request.UpdateWebhookRequest{}
+	err := ctx.ShouldBindJSON(&updateWebhookRequest)
+	utils.ErrorPanic(err)
+
+	// 从 URL 参数中获取 webhookId
+	updateWebhookRequest.ID = cast.ToUint(ctx.Param("webhookId"))
+
+	// 签名密钥不写入日志
+	logRequest := updateWebhookRequest
+	if logRequest.Secret != nil {
+		secret := redactSecret(*logRequest.Secret)
+		logRequest.Secret = &secret
+	}
+	log.Printf("%s", litter.Sdump(logRequest))
+
+	// 调用服务层方法更新 Webhook 订阅
+	controller.webhookService.Update(updateWebhookRequest)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Delete 删除 Webhook 订阅
+// 路由：DELETE /webhook/:webhookId
+func (controller *WebhookController) Delete(ctx *gin.Context) {
+	log.Println("delete webhook")
+
+	webhookId := cast.ToUint(ctx.Param("webhookId"))
+	controller.webhookService.Delete(webhookId)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindById 根据 ID 查询 Webhook 订阅
+// 路由：GET /webhook/:webhookId
+func (controller *WebhookController) FindById(ctx *gin.Context) {
+	log.Println("findby webhookId")
+
+	webhookId := cast.ToUint(ctx.Param("webhookId"))
+	webhookResponse := controller.webhookService.FindById(webhookId)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    webhookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAll 查询所有 Webhook 订阅
+// 路由：GET /webhook
+func (controller *WebhookController) FindAll(ctx *gin.Context) {
+	log.Println("findAll webhook")
+
+	webhookResponse := controller.webhookService.FindAll()
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    webhookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Test 向 Webhook 发送一条测试消息并返回投递结果
+// 路由：POST /webhook/:webhookId/test
+func (controller *WebhookController) Test(ctx *gin.Context) {
+	log.Println("test webhook")
+
+	webhookId := cast.ToUint(ctx.Param("webhookId"))
+	deliveryResponse := controller.webhookService.Test(webhookId)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    deliveryResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindDeliveries 分页查询 Webhook 的投递记录
+// 路由：GET /webhook/:webhookId/delivery
+func (controller *WebhookController) FindDeliveries(ctx *gin.Context) {
+	log.Println("find webhook deliveries")
+
+	webhookId := cast.ToUint(ctx.Param("webhookId"))
+	pg := utils.NewPagination(ctx)
+	deliveryResponse := controller.webhookService.FindDeliveries(webhookId, pg)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    deliveryResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// Retry 立即重新投递指定的投递记录
+// 路由：POST /webhook/delivery/:deliveryId/retry
+func (controller *WebhookController) Retry(ctx *gin.Context) {
+	log.Println("retry webhook delivery")
+
+	deliveryId := cast.ToUint(ctx.Param("deliveryId"))
+	controller.webhookService.Retry(deliveryId)
+
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    nil,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// redactSecret 隐藏日志中的密钥，空字符串保持不变以便区分清除密钥的请求
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "******"
+}
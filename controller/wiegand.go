@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanity-io/litter"
+	"github.com/spf13/cast"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/service"
+	"hoyang/ownsa/utils"
+)
+
+// WiegandController 韦根规则管理与卡号解码控制器
+type WiegandController struct {
+	wiegandService service.WiegandService // 依赖的服务层，处理韦根规则与解码
+}
+
+// NewWiegandController 创建并返回一个新的 WiegandController 实例
+func NewWiegandController(service service.WiegandService) *WiegandController {
+	return &WiegandController{
+		wiegandService: service,
+	}
+}
+
+// respond 返回处理结果
+func (controller *WiegandController) respond(ctx *gin.Context, data interface{}) {
+	webResponse := response.Response{
+		Code:    http.StatusOK,
+		Success: true,
+		Data:    data,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// FindAll 查询控制器中的所有韦根规则
+// 路由：GET /wiegand
+func (controller *WiegandController) FindAll(ctx *gin.Context) {
+	log.Println("findAll wiegand rule")
+
+	controller.respond(ctx, controller.wiegandService.FindAll())
+}
+
+// FindPresets 查询预置韦根规则
+// 路由：GET /wiegand/preset
+func (controller *WiegandController) FindPresets(ctx *gin.Context) {
+	log.Println("findAll wiegand preset")
+
+	controller.respond(ctx, controller.wiegandService.FindPresets())
+}
+
+// FindById 查询韦根规则
+// 路由：GET /wiegand/:ruleId
+func (controller *WiegandController) FindById(ctx *gin.Context) {
+	log.Println("findById wiegand rule")
+
+	controller.respond(ctx, controller.wiegandService.FindById(cast.ToUint(ctx.Param("ruleId"))))
+}
+
+// Create 创建韦根规则
+// 路由：POST /wiegand
+func (controller *WiegandController) Create(ctx *gin.Context) {
+	log.Println("create wiegand rule")
+
+	ruleRequest := request.WiegandRuleRequest{}
+	err := ctx.ShouldBindJSON(&ruleRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(ruleRequest))
+
+	rule := controller.wiegandService.Create(ruleRequest)
+	ConfigSync()
+	controller.respond(ctx, rule)
+}
+
+// Update 修改韦根规则
+// 路由：PUT /wiegand/:ruleId
+func (controller *WiegandController) Update(ctx *gin.Context) {
+	log.Println("update wiegand rule")
+
+	ruleRequest := request.WiegandRuleRequest{}
+	err := ctx.ShouldBindJSON(&ruleRequest)
+	utils.ErrorPanic(err)
+
+	ruleRequest.ID = cast.ToUint(ctx.Param("ruleId"))
+
+	log.Printf("%s", litter.Sdump(ruleRequest))
+
+	rule := controller.wiegandService.Update(ruleRequest)
+	ConfigSync()
+	controller.respond(ctx, rule)
+}
+
+// Delete 删除韦根规则
+// 路由：DELETE /wiegand/:ruleId
+func (controller *WiegandController) Delete(ctx *gin.Context) {
+	log.Println("delete wiegand rule")
+
+	controller.wiegandService.Delete(cast.ToUint(ctx.Param("ruleId")))
+	ConfigSync()
+	controller.respond(ctx, nil)
+}
+
+// Decode 解码原始韦根数据
+// 路由：POST /wiegand/decode
+func (controller *WiegandController) Decode(ctx *gin.Context) {
+	log.Println("decode wiegand")
+
+	decodeRequest := request.DecodeWiegandRequest{}
+	err := ctx.ShouldBindJSON(&decodeRequest)
+	utils.ErrorPanic(err)
+
+	log.Printf("%s", litter.Sdump(decodeRequest))
+
+	controller.respond(ctx, controller.wiegandService.Decode(decodeRequest))
+}
+
+// DecodeEvents 解码事件中的韦根数据
+// 路由：GET /wiegand/event?ids=1,2,3
+func (controller *WiegandController) DecodeEvents(ctx *gin.Context) {
+	log.Println("decode event wiegand")
+
+	var msgIds []uint
+	for _, id := range strings.Split(ctx.Query("ids"), ",") {
+		if msgId := cast.ToUint(strings.TrimSpace(id)); msgId > 0 {
+			msgIds = append(msgIds, msgId)
+		}
+	}
+
+	controller.respond(ctx, controller.wiegandService.DecodeEvents(msgIds))
+}
@@ -0,0 +1,55 @@
+package request
+
+// 创建考勤班次的请求
+type CreateShiftRequest struct {
+	Name       string `validate:"required,max=50" json:"name"`                      // 名称
+	StartTime  string `validate:"required,datetime=15:04" json:"start_time"`        // 上班时间 HH:MM
+	EndTime    string `validate:"required,datetime=15:04" json:"end_time"`          // 下班时间 HH:MM，不晚于上班时间表示次日下班
+	LateGrace  uint   `validate:"max=240" json:"late_grace"`                        // 迟到宽限（分钟）
+	EarlyGrace uint   `validate:"max=240" json:"early_grace"`                       // 早退宽限（分钟）
+	WorkDays   []uint `validate:"required,min=1,max=7,dive,max=6" json:"work_days"` // 工作日 0-6（0 为周日）
+}
+
+// 更新考勤班次的请求，未提供的字段保持不变
+type UpdateShiftRequest struct {
+	ID         uint    `json:"-"`
+	Name       *string `validate:"omitempty,max=50" json:"name"`
+	StartTime  *string `validate:"omitempty,datetime=15:04" json:"start_time"`
+	EndTime    *string `validate:"omitempty,datetime=15:04" json:"end_time"`
+	LateGrace  *uint   `validate:"omitempty,max=240" json:"late_grace"`
+	EarlyGrace *uint   `validate:"omitempty,max=240" json:"early_grace"`
+	WorkDays   *[]uint `validate:"omitempty,min=1,max=7,dive,max=6" json:"work_days"`
+}
+
+// 分配班次的请求，人员和部门二选一
+type CreateShiftAssignmentRequest struct {
+	ShiftID      uint `validate:"required" json:"shift_id"`                                                  // 班次
+	PeopleId     uint `validate:"required_without=DepartmentId,excluded_with=DepartmentId" json:"people_id"` // 人员
+	DepartmentId uint `validate:"required_without=PeopleId" json:"department_id"`                            // 部门
+}
+
+// 考勤读卡器
+type AttendanceReaderRequest struct {
+	IBAddr     int `validate:"min=0" json:"ibaddr"`     // 接口板地址
+	ReaderAddr int `validate:"min=0" json:"readeraddr"` // 读卡器地址
+}
+
+// 设置考勤读卡器的请求，为空表示所有读卡器都参与考勤
+type UpdateAttendanceReadersRequest struct {
+	Readers []AttendanceReaderRequest `validate:"dive" json:"readers"`
+}
+
+// 重新计算考勤的请求
+type CalculateAttendanceRequest struct {
+	Date string `validate:"required,datetime=2006-01-02" json:"date"` // 考勤日期
+}
+
+// 考勤人工更正的请求
+type CreateAttendanceCorrectionRequest struct {
+	PeopleId uint   `validate:"required" json:"people_id"`                              // 人员
+	Date     string `validate:"required,datetime=2006-01-02" json:"date"`               // 考勤日期
+	FirstIn  string `validate:"omitempty,datetime=2006-01-02 15:04:05" json:"first_in"` // 更正后的上班时间，为空表示不更正
+	LastOut  string `validate:"omitempty,datetime=2006-01-02 15:04:05" json:"last_out"` // 更正后的下班时间，为空表示不更正
+	Excused  bool   `json:"excused"`                                                    // 是否免除考勤（请假、出差等）
+	Reason   string `validate:"required,max=255" json:"reason"`                         // 更正原因
+}
@@ -0,0 +1,19 @@
+package request
+
+// 批量操作的筛选条件，多个条件同时满足
+type BulkFilterRequest struct {
+	DepartmentId  uint   `json:"department_id"`             // 部门
+	AccessGroupId uint   `json:"access_group_id"`           // 门禁组
+	Keyword       string `validate:"max=50" json:"keyword"` // 人员编号或姓名包含的关键字
+}
+
+// 人员或凭证批量操作的请求，按 ID 列表或筛选条件选择对象
+type BulkRequest struct {
+	Action         string             `validate:"required,oneof=assign_access_group remove_access_group move_department enable disable delete extend_validity" json:"action"`
+	Ids            []uint             `validate:"required_without=Filter,max=10000" json:"ids"` // 人员 ID 或凭证 ID
+	Filter         *BulkFilterRequest `validate:"required_without=Ids" json:"filter"`           // 筛选条件，提供 ids 时忽略
+	AccessGroupIds []uint             `validate:"required_if=Action assign_access_group,required_if=Action remove_access_group" json:"access_group_ids"`
+	DepartmentId   uint               `json:"department_id"`                                             // 调整到的部门，0 表示不属于任何部门
+	ValidUntil     string             `validate:"required_if=Action extend_validity" json:"valid_until"` // 新的失效时间 YYYY-MM-DD[ HH:MM:SS]，只有日期时当天有效
+	Atomic         bool               `json:"atomic"`                                                    // 任一对象失败时全部回滚
+}
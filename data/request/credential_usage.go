@@ -0,0 +1,8 @@
+package request
+
+// 停用长期未使用凭证的请求
+type DisableStaleCredentialRequest struct {
+	Days      uint   `validate:"required,min=1,max=3650" json:"days"` // 未使用天数
+	UniqueIds []uint `validate:"max=10000" json:"unique_ids"`         // 只停用其中的凭证，为空时停用所有未使用的凭证
+	Atomic    bool   `json:"atomic"`                                  // 任一凭证失败时全部回滚
+}
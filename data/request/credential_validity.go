@@ -0,0 +1,9 @@
+package request
+
+// 设置凭证或人员有效期的请求，时间格式 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS，为空表示不限制
+// 失效时间只有日期时当天仍有效
+type UpdateValidityRequest struct {
+	ID         uint   `json:"-"`                             // 凭证 ID 或人员 ID
+	ValidFrom  string `validate:"max=19" json:"valid_from"`  // 生效时间
+	ValidUntil string `validate:"max=19" json:"valid_until"` // 失效时间
+}
@@ -0,0 +1,20 @@
+package request
+
+// 创建下级部门的请求
+type CreateSubDepartmentRequest struct {
+	ParentId uint   `json:"-"`                               // 上级部门
+	Name     string `validate:"required,max=50" json:"name"` // 部门名称
+}
+
+// 修改部门名称或上级部门的请求，字段为空表示不修改
+type UpdateDepartmentNodeRequest struct {
+	ID       uint    `json:"-"`
+	Name     *string `validate:"omitempty,min=1,max=50" json:"name"` // 部门名称
+	ParentId *uint   `json:"parent_id"`                              // 上级部门，0 表示移到顶级
+}
+
+// 设置部门门禁组的请求，部门及其下级部门的人员继承这些门禁组
+type SetDepartmentAccessRequest struct {
+	ID             uint   `json:"-"`
+	AccessGroupIds []uint `validate:"max=64" json:"access_group_ids"`
+}
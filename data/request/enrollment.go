@@ -0,0 +1,17 @@
+package request
+
+// 开始刷卡登记的请求：在指定读卡器上刷的下一张卡登记为人员的凭证
+type StartEnrollmentRequest struct {
+	IBAddr         int    `validate:"min=0" json:"ibaddr"`                     // 接口板地址
+	ReaderAddr     int    `validate:"min=0" json:"readeraddr"`                 // 读卡器地址
+	PeopleId       uint   `validate:"required" json:"people_id"`               // 人员
+	Timeout        uint   `validate:"omitempty,min=10,max=600" json:"timeout"` // 等待刷卡的秒数，默认 60
+	AccessGroupIds []uint `validate:"max=32" json:"access_group_ids"`          // 新凭证的门禁组
+}
+
+// 从事件创建凭证的请求，事件须为未注册卡的刷卡事件
+type CreateCredentialFromEventRequest struct {
+	MsgId          uint   `json:"-"`
+	PeopleId       uint   `validate:"required" json:"people_id"`      // 人员
+	AccessGroupIds []uint `validate:"max=32" json:"access_group_ids"` // 新凭证的门禁组
+}
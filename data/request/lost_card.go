@@ -0,0 +1,14 @@
+package request
+
+// 挂失凭证的请求
+type ReportLostCardRequest struct {
+	UniqueId uint   `json:"-"`
+	Reason   string `validate:"required,oneof=lost stolen" json:"reason"` // 挂失原因 lost：遗失 stolen：被盗
+	Note     string `validate:"max=255" json:"note"`                      // 备注
+}
+
+// 补发挂失卡的请求
+type ReplaceLostCardRequest struct {
+	ID     uint   `json:"-"`
+	CardNo string `validate:"required,max=50" json:"card_no"` // 新卡号
+}
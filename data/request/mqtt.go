@@ -0,0 +1,18 @@
+package request
+
+// 更新 MQTT 配置的请求，未提供的字段保持不变
+type UpdateMqttConfigRequest struct {
+	Enable             *bool   `json:"enable"`                                              // 是否启用
+	BrokerURL          *string `validate:"omitempty,url,max=255" json:"broker_url"`         // 服务器地址，如 tcp://host:1883、ssl://host:8883
+	ClientId           *string `validate:"omitempty,min=1,max=64" json:"client_id"`         // 客户端 ID
+	Username           *string `validate:"omitempty,max=100" json:"username"`               // 用户名
+	Password           *string `validate:"omitempty,max=100" json:"password"`               // 密码
+	TopicPrefix        *string `validate:"omitempty,max=100" json:"topic_prefix"`           // 主题前缀
+	QoS                *uint   `validate:"omitempty,max=2" json:"qos"`                      // 服务质量等级 0/1/2
+	CACert             *string `json:"ca_cert"`                                             // CA 证书（PEM）
+	InsecureSkipVerify *bool   `json:"insecure_skip_verify"`                                // 是否跳过服务器证书校验
+	CommandToken       *string `validate:"omitempty,max=100" json:"command_token"`          // 命令认证令牌，为空时不接受命令
+	PublishEvents      *bool   `json:"publish_events"`                                      // 是否发布事件消息
+	PublishStatus      *bool   `json:"publish_status"`                                      // 是否发布设备状态
+	StatusInterval     *uint   `validate:"omitempty,min=1,max=3600" json:"status_interval"` // 设备状态轮询间隔（秒）
+}
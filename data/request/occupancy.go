@@ -0,0 +1,23 @@
+package request
+
+// 区域读卡器
+type AreaReaderRequest struct {
+	IBAddr     int  `validate:"min=0" json:"ibaddr"`                 // 接口板地址
+	ReaderAddr int  `validate:"min=0" json:"readeraddr"`             // 读卡器地址
+	Direction  uint `validate:"required,oneof=1 2" json:"direction"` // 方向 1：进入 2：离开
+}
+
+// 创建区域的请求
+type CreateAreaRequest struct {
+	Name     string              `validate:"required,max=50" json:"name"` // 名称
+	Capacity uint                `json:"capacity"`                        // 最大容纳人数，0 表示不限制
+	Readers  []AreaReaderRequest `validate:"dive" json:"readers"`         // 进出读卡器
+}
+
+// 更新区域的请求，未提供的字段保持不变，提供 readers 时整体替换
+type UpdateAreaRequest struct {
+	ID       uint                 `json:"-"`
+	Name     *string              `validate:"omitempty,max=50" json:"name"`
+	Capacity *uint                `json:"capacity"`
+	Readers  *[]AreaReaderRequest `validate:"omitempty,dive" json:"readers"`
+}
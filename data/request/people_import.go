@@ -0,0 +1,8 @@
+package request
+
+// 人员文件导入的请求（multipart/form-data），文件字段名为 file，支持 CSV 和 XLSX
+type ImportPeopleFileRequest struct {
+	DryRun  bool   `form:"dry_run"` // 仅校验不写入
+	Sheet   string `form:"sheet"`   // XLSX 工作表名称，为空时使用第一个工作表
+	Mapping string `form:"mapping"` // 列映射 JSON，如 {"工号":"people_code"}，未映射的列按默认标题识别
+}
@@ -0,0 +1,8 @@
+package request
+
+// 全文检索的查询条件
+type SearchRequest struct {
+	Q     string `validate:"required,max=100" form:"q"`           // 关键字，多个词以空格分隔，需全部匹配
+	Types string `validate:"max=50" form:"types"`                 // 检索的对象类型，逗号分隔：people、credential、department，为空表示全部
+	Limit int    `validate:"omitempty,min=1,max=50" form:"limit"` // 每种对象返回的最大数量，默认 10
+}
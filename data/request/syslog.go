@@ -0,0 +1,19 @@
+package request
+
+// 更新 Syslog 转发配置的请求，未提供的字段保持不变
+type UpdateSyslogConfigRequest struct {
+	Enable             *bool   `json:"enable"`                                          // 是否启用
+	Protocol           *string `validate:"omitempty,oneof=udp tcp tls" json:"protocol"` // 传输协议
+	Host               *string `validate:"omitempty,max=255" json:"host"`               // 日志服务器地址
+	Port               *uint   `validate:"omitempty,min=1,max=65535" json:"port"`       // 日志服务器端口
+	Format             *string `validate:"omitempty,oneof=rfc5424 cef" json:"format"`   // 消息格式
+	Facility           *uint   `validate:"omitempty,max=23" json:"facility"`            // 设施代码
+	AppName            *string `validate:"omitempty,max=48" json:"app_name"`            // 应用名称
+	Hostname           *string `validate:"omitempty,max=255" json:"hostname"`           // 主机名
+	CACert             *string `json:"ca_cert"`                                         // CA 证书（PEM）
+	InsecureSkipVerify *bool   `json:"insecure_skip_verify"`                            // 是否跳过服务器证书校验
+	ForwardAccess      *bool   `json:"forward_access"`                                  // 是否转发通行事件
+	ForwardAlarm       *bool   `json:"forward_alarm"`                                   // 是否转发报警事件
+	ForwardAudit       *bool   `json:"forward_audit"`                                   // 是否转发操作审计
+	ForwardSystem      *bool   `json:"forward_system"`                                  // 是否转发系统事件
+}
@@ -0,0 +1,13 @@
+package request
+
+// 登记或替换指静脉模板的请求
+type EnrollVeinTemplateRequest struct {
+	PeopleId    uint   `json:"-"`
+	FingerIndex uint   `validate:"max=9" json:"-"`
+	Template    string `validate:"required,base64,max=16384" json:"template"` // 模板数据，Base64 编码
+}
+
+// 导出指静脉模板的请求，导出文件使用口令加密
+type ExportVeinTemplateRequest struct {
+	Passphrase string `validate:"required,min=8,max=128" json:"passphrase"`
+}
@@ -0,0 +1,29 @@
+package request
+
+// 访客预约的请求，时间格式 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS
+// 未提供卡号时生成 6 位数字密码，在带键盘的读卡器上输入
+type CreateVisitorRequest struct {
+	Name           string `validate:"required,max=50" json:"name"`                   // 姓名
+	Company        string `validate:"max=100" json:"company"`                        // 单位
+	Phone          string `validate:"max=30" json:"phone"`                           // 电话
+	HostPeopleId   uint   `validate:"required" json:"host_people_id"`                // 被访人
+	Purpose        string `validate:"max=255" json:"purpose"`                        // 来访事由
+	ExpectedFrom   string `validate:"max=19" json:"expected_from"`                   // 预约开始时间，为空表示立即生效
+	ExpectedUntil  string `validate:"required,max=19" json:"expected_until"`         // 预约结束时间，只有日期时当天有效
+	CardNo         string `validate:"omitempty,max=50" json:"card_no"`               // 临时卡号
+	AccessGroupIds []uint `validate:"required,min=1,max=20" json:"access_group_ids"` // 允许通行的门禁组
+}
+
+// 访客签到的请求
+type CheckInVisitorRequest struct {
+	ID     uint   `json:"-"`
+	CardNo string `validate:"omitempty,max=50" json:"card_no"` // 现场发放的卡号，为空表示使用预约时的卡号或密码
+}
+
+// 访客日志的查询条件
+type FindVisitorRequest struct {
+	Status    *uint  `validate:"omitempty,max=4" form:"status"` // 状态
+	Keyword   string `validate:"max=50" form:"keyword"`         // 姓名、单位或电话包含的关键字
+	StartTime string `form:"startTime"`                         // 预约时间起始
+	EndTime   string `form:"endTime"`                           // 预约时间结束，只有日期时包含当天
+}
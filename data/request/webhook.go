@@ -0,0 +1,26 @@
+package request
+
+// 创建 Webhook 订阅的请求
+type CreateWebhookRequest struct {
+	Name       string   `validate:"required,max=50" json:"name"`           // 名称
+	URL        string   `validate:"required,url,max=255" json:"url"`       // 推送地址
+	Secret     string   `validate:"max=100" json:"secret"`                 // HMAC-SHA256 签名密钥
+	Categories []string `json:"categories"`                                // 订阅的事件类别（access/alarm/system），为空表示全部
+	EventTypes []uint   `json:"event_types"`                               // 订阅的事件类型，为空表示全部
+	Enable     *bool    `validate:"required" json:"enable"`                // 是否启用
+	MaxRetries *uint    `validate:"omitempty,max=20" json:"max_retries"`   // 最大重试次数，默认 8
+	Timeout    *uint    `validate:"omitempty,min=1,max=60" json:"timeout"` // 请求超时（秒），默认 10
+}
+
+// 更新 Webhook 订阅的请求，未提供的字段保持不变
+type UpdateWebhookRequest struct {
+	ID         uint      `json:"-"`
+	Name       *string   `validate:"omitempty,max=50" json:"name"`
+	URL        *string   `validate:"omitempty,url,max=255" json:"url"`
+	Secret     *string   `validate:"omitempty,max=100" json:"secret"`
+	Categories *[]string `json:"categories"`
+	EventTypes *[]uint   `json:"event_types"`
+	Enable     *bool     `json:"enable"`
+	MaxRetries *uint     `validate:"omitempty,max=20" json:"max_retries"`
+	Timeout    *uint     `validate:"omitempty,min=1,max=60" json:"timeout"`
+}
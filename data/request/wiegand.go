@@ -0,0 +1,28 @@
+package request
+
+// 创建或修改韦根规则的请求，位序号从 0 开始，第 0 位为最先收到的位
+// 校验范围位数为 0 表示没有该校验位
+type WiegandRuleRequest struct {
+	ID               uint   `json:"-"`
+	Name             string `validate:"required,max=50" json:"name"`        // 名称
+	BitLength        uint   `validate:"required,max=128" json:"bit_length"` // 总位数，含校验位
+	FacilityStart    uint   `validate:"max=127" json:"facility_start"`      // 设备码起始位
+	FacilityLength   uint   `validate:"max=64" json:"facility_length"`      // 设备码位数，0 表示没有设备码
+	CardStart        uint   `validate:"max=127" json:"card_start"`          // 卡号起始位
+	CardLength       uint   `validate:"required,max=64" json:"card_length"` // 卡号位数
+	EvenParityBit    uint   `validate:"max=127" json:"even_parity_bit"`     // 偶校验位
+	EvenParityStart  uint   `validate:"max=127" json:"even_parity_start"`   // 偶校验范围起始位
+	EvenParityLength uint   `validate:"max=128" json:"even_parity_length"`  // 偶校验范围位数
+	OddParityBit     uint   `validate:"max=127" json:"odd_parity_bit"`      // 奇校验位
+	OddParityStart   uint   `validate:"max=127" json:"odd_parity_start"`    // 奇校验范围起始位
+	OddParityLength  uint   `validate:"max=128" json:"odd_parity_length"`   // 奇校验范围位数
+}
+
+// 解码韦根数据的请求：提供原始位串 bits，或原始数值 value（十进制或 0x 开头的十六进制）及位数 bit_length
+// 指定 rule_id 时只按该规则解码，否则尝试所有位数相同的规则
+type DecodeWiegandRequest struct {
+	Bits      string `validate:"required_without=Value,max=160" json:"bits"`
+	Value     string `validate:"required_without=Bits,max=50" json:"value"`
+	BitLength uint   `validate:"required_with=Value,max=128" json:"bit_length"`
+	RuleId    uint   `json:"rule_id"`
+}
@@ -0,0 +1,79 @@
+package response
+
+// 考勤班次
+type ShiftResponse struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	LateGrace  uint   `json:"late_grace"`
+	EarlyGrace uint   `json:"early_grace"`
+	WorkDays   []uint `json:"work_days"`
+}
+
+// 班次分配
+type ShiftAssignmentResponse struct {
+	ID             uint   `json:"id"`
+	ShiftID        uint   `json:"shift_id"`
+	ShiftName      string `json:"shift_name"`
+	PeopleId       uint   `json:"people_id"`
+	PeopleName     string `json:"people_name"`
+	DepartmentId   uint   `json:"department_id"`
+	DepartmentName string `json:"department_name"`
+}
+
+// 考勤读卡器
+type AttendanceReaderResponse struct {
+	IBAddr     int `json:"ibaddr"`
+	ReaderAddr int `json:"readeraddr"`
+}
+
+// 每日考勤结果
+type AttendanceRecordResponse struct {
+	PeopleId     uint   `json:"people_id"`
+	PeopleCode   string `json:"people_code"`
+	PeopleName   string `json:"people_name"`
+	Department   string `json:"department"`
+	Date         string `json:"date"`
+	ShiftID      uint   `json:"shift_id"`
+	ShiftName    string `json:"shift_name"`
+	WorkDay      bool   `json:"work_day"`
+	FirstIn      uint   `json:"first_in"` // UNIX时间戳，0 表示无记录
+	LastOut      uint   `json:"last_out"` // UNIX时间戳，0 表示无记录
+	Late         bool   `json:"late"`
+	LateMinutes  uint   `json:"late_minutes"`
+	EarlyLeave   bool   `json:"early_leave"`
+	EarlyMinutes uint   `json:"early_minutes"`
+	Absent       bool   `json:"absent"`
+	Excused      bool   `json:"excused"`
+	Corrected    bool   `json:"corrected"`
+}
+
+// 考勤人工更正记录
+type AttendanceCorrectionResponse struct {
+	ID        uint   `json:"id"`
+	PeopleId  uint   `json:"people_id"`
+	Date      string `json:"date"`
+	FirstIn   uint   `json:"first_in"`
+	LastOut   uint   `json:"last_out"`
+	Excused   bool   `json:"excused"`
+	Reason    string `json:"reason"`
+	UserId    uint   `json:"user_id"`
+	CreatedAt uint   `json:"created_at"`
+}
+
+// 月度考勤汇总
+type AttendanceReportResponse struct {
+	PeopleId     uint   `json:"people_id"`
+	PeopleCode   string `json:"people_code"`
+	PeopleName   string `json:"people_name"`
+	Department   string `json:"department"`
+	WorkDays     uint   `json:"work_days"`     // 应出勤天数
+	PresentDays  uint   `json:"present_days"`  // 实际出勤天数
+	LateCount    uint   `json:"late_count"`    // 迟到次数
+	LateMinutes  uint   `json:"late_minutes"`  // 迟到分钟数
+	EarlyCount   uint   `json:"early_count"`   // 早退次数
+	EarlyMinutes uint   `json:"early_minutes"` // 早退分钟数
+	AbsentDays   uint   `json:"absent_days"`   // 缺勤天数
+	ExcusedDays  uint   `json:"excused_days"`  // 免除考勤天数
+}
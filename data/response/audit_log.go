@@ -0,0 +1,15 @@
+package response
+
+// 操作审计日志
+type AuditLogResponse struct {
+	ID        uint   `json:"id"`
+	UserId    uint   `json:"user_id"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Target    string `json:"target"`
+	ClientIP  string `json:"client_ip"`
+	Status    int    `json:"status"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	CreatedAt uint   `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package response
+
+// 批量操作中单个对象的结果
+type BulkItemResponse struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// 批量操作结果
+type BulkResponse struct {
+	Action     string             `json:"action"`
+	Total      int                `json:"total"`
+	Succeeded  int                `json:"succeeded"`
+	Failed     int                `json:"failed"`
+	RolledBack bool               `json:"rolled_back"` // atomic 模式下有失败时全部回滚
+	Items      []BulkItemResponse `json:"items"`
+}
@@ -0,0 +1,19 @@
+package response
+
+// 凭证使用情况
+type CredentialUsageResponse struct {
+	UniqueId     uint   `json:"unique_id"`
+	CardNo       string `json:"card_no"`
+	PeopleId     uint   `json:"people_id"`
+	PeopleCode   string `json:"people_code"`
+	PeopleName   string `json:"people_name"`
+	UseCount     uint   `json:"use_count"`    // 允许通行次数
+	LastUsedAt   uint   `json:"last_used_at"` // 最近一次通行时间，0 表示未使用
+	IBAddr       int    `json:"ibaddr"`       // 最近一次通行的接口板地址
+	ReaderAddr   int    `json:"reader_addr"`  // 最近一次通行的读卡器地址
+	OutputAddr   int    `json:"output_addr"`  // 最近一次通行的输出（门）地址
+	IBName       string `json:"ib_name"`
+	ReaderName   string `json:"reader_name"`
+	TrackedSince uint   `json:"tracked_since"` // 开始跟踪时间
+	IdleDays     uint   `json:"idle_days"`     // 未使用天数，从最近一次通行或开始跟踪时计算
+}
@@ -0,0 +1,33 @@
+package response
+
+// 凭证或人员有效期
+type ValidityResponse struct {
+	ValidFrom  uint `json:"valid_from"`  // 生效时间 UNIX时间戳，0 表示不限制
+	ValidUntil uint `json:"valid_until"` // 失效时间 UNIX时间戳，0 表示不限制
+	Changed    bool `json:"changed"`     // 是否有凭证因此被启用或停用
+}
+
+// 即将到期的凭证
+type ExpiringCredentialResponse struct {
+	UniqueId   uint   `json:"unique_id"`
+	CardNo     string `json:"card_no"`
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+	Department string `json:"department"`
+	ValidFrom  uint   `json:"valid_from"`  // 生效时间（凭证与人员有效期的交集）
+	ValidUntil uint   `json:"valid_until"` // 失效时间（凭证与人员有效期的交集）
+	DaysLeft   int    `json:"days_left"`   // 剩余天数，不足一天为 0
+}
+
+// 凭证因有效期被启用或停用时推送的事件
+type CredentialValidityEventResponse struct {
+	UniqueId   uint   `json:"unique_id"`
+	CardNo     string `json:"card_no"`
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+	Status     string `json:"status"` // active：已启用 pending：未生效 expired：已失效
+	ValidFrom  uint   `json:"valid_from"`
+	ValidUntil uint   `json:"valid_until"`
+}
@@ -0,0 +1,41 @@
+package response
+
+// 数据质量报告
+type DataQualityResponse struct {
+	GeneratedAt              uint                            `json:"generated_at"`
+	DuplicateCards           []DuplicateCardResponse         `json:"duplicate_cards"`            // 分配给多个凭证的卡号
+	PeopleWithoutCredential  []DataQualityPeopleResponse     `json:"people_without_credential"`  // 没有凭证的人员
+	CredentialsWithoutAccess []DataQualityCredentialResponse `json:"credentials_without_access"` // 没有门禁组的凭证，不含已停用的凭证
+	MissingReferences        []MissingReferenceResponse      `json:"missing_references"`         // 引用不存在的部门、人员或门禁组
+}
+
+// 重复卡号
+type DuplicateCardResponse struct {
+	CardNo      string                          `json:"card_no"`
+	Credentials []DataQualityCredentialResponse `json:"credentials"`
+}
+
+// 数据质量报告中的人员
+type DataQualityPeopleResponse struct {
+	PeopleId     uint   `json:"people_id"`
+	PeopleCode   string `json:"people_code"`
+	PeopleName   string `json:"people_name"`
+	DepartmentId uint   `json:"department_id"`
+}
+
+// 数据质量报告中的凭证
+type DataQualityCredentialResponse struct {
+	UniqueId   uint   `json:"unique_id"`
+	CardNo     string `json:"card_no"`
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+}
+
+// 不存在的引用
+type MissingReferenceResponse struct {
+	Kind        string `json:"kind"`         // 引用方：people、credential、credential_access、department_access
+	RefId       uint   `json:"ref_id"`       // 引用方的 ID：人员 ID、凭证 ID 或部门 ID
+	MissingKind string `json:"missing_kind"` // 不存在的对象：department、people、access_group
+	MissingId   uint   `json:"missing_id"`
+}
@@ -0,0 +1,22 @@
+package response
+
+// 部门树的节点
+type DepartmentTreeResponse struct {
+	ID                      uint                     `json:"id"`
+	Name                    string                   `json:"name"`
+	ParentId                uint                     `json:"parent_id"`                  // 上级部门，0 表示顶级部门
+	Path                    string                   `json:"path"`                       // 从顶级部门开始的部门路径，以 / 分隔
+	PeopleCount             int                      `json:"people_count"`               // 部门直属人数
+	TotalPeopleCount        int                      `json:"total_people_count"`         // 包含下级部门的人数
+	AccessGroupIds          []uint                   `json:"access_group_ids"`           // 分配给部门的门禁组
+	InheritedAccessGroupIds []uint                   `json:"inherited_access_group_ids"` // 从上级部门继承的门禁组
+	Children                []DepartmentTreeResponse `json:"children"`
+}
+
+// 部门的门禁组
+type DepartmentAccessResponse struct {
+	DepartmentId            uint   `json:"department_id"`
+	AccessGroupIds          []uint `json:"access_group_ids"`           // 分配给部门的门禁组
+	InheritedAccessGroupIds []uint `json:"inherited_access_group_ids"` // 从上级部门继承的门禁组
+	Changed                 int    `json:"changed"`                    // 门禁组因此变化的凭证数
+}
@@ -0,0 +1,24 @@
+package response
+
+// 刷卡登记会话
+type EnrollmentResponse struct {
+	ID         uint   `json:"id"`
+	IBAddr     int    `json:"ibaddr"`
+	ReaderAddr int    `json:"readeraddr"`
+	PeopleId   uint   `json:"people_id"`
+	Status     string `json:"status"`            // 状态 waiting：等待刷卡 completed：已登记 failed：登记失败 expired：已超时 cancelled：已取消
+	StartedAt  uint   `json:"started_at"`        // 开始时间 UNIX时间戳
+	ExpiresAt  uint   `json:"expires_at"`        // 超时时间 UNIX时间戳
+	CardNo     string `json:"card_no"`           // 刷的卡号
+	UniqueId   uint   `json:"unique_id"`         // 创建的凭证
+	MsgId      uint   `json:"msgid"`             // 刷卡事件
+	Message    string `json:"message,omitempty"` // 失败原因
+}
+
+// 从事件创建的凭证
+type EnrolledCredentialResponse struct {
+	UniqueId uint   `json:"unique_id"`
+	PeopleId uint   `json:"people_id"`
+	CardNo   string `json:"card_no"`
+	MsgId    uint   `json:"msgid"`
+}
@@ -0,0 +1,35 @@
+package response
+
+// 挂失卡
+type LostCardResponse struct {
+	ID             uint   `json:"id"`
+	CardNo         string `json:"card_no"`
+	PeopleId       uint   `json:"people_id"`
+	PeopleCode     string `json:"people_code"`
+	PeopleName     string `json:"people_name"`
+	UniqueId       uint   `json:"unique_id"` // 原凭证，已删除
+	Reason         string `json:"reason"`
+	Note           string `json:"note"`
+	AccessGroupIds []uint `json:"access_group_ids"` // 补发时恢复的门禁组
+	ReportedAt     uint   `json:"reported_at"`
+	ReplacedBy     uint   `json:"replaced_by"` // 补发的凭证，0 表示未补发
+	LastSeenAt     uint   `json:"last_seen_at"`
+	SeenCount      uint   `json:"seen_count"`
+}
+
+// 挂失卡刷卡报警
+type LostCardAlarmResponse struct {
+	Priority   string `json:"priority"` // 报警级别，固定为 high
+	LostCardId uint   `json:"lost_card_id"`
+	CardNo     string `json:"card_no"`
+	Reason     string `json:"reason"`
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+	MsgId      uint   `json:"msgid"`
+	AccessTime string `json:"access_time"`
+	IBAddr     int    `json:"ibaddr"`
+	ReaderAddr int    `json:"readeraddr"`
+	IBName     string `json:"ib_name"`
+	ReaderName string `json:"reader_name"`
+}
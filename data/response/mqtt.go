@@ -0,0 +1,27 @@
+package response
+
+// MQTT 配置，不返回密码和命令令牌
+type MqttConfigResponse struct {
+	Enable             bool   `json:"enable"`
+	BrokerURL          string `json:"broker_url"`
+	ClientId           string `json:"client_id"`
+	Username           string `json:"username"`
+	HasPassword        bool   `json:"has_password"`
+	TopicPrefix        string `json:"topic_prefix"`
+	QoS                uint   `json:"qos"`
+	CACert             string `json:"ca_cert"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	HasCommandToken    bool   `json:"has_command_token"`
+	PublishEvents      bool   `json:"publish_events"`
+	PublishStatus      bool   `json:"publish_status"`
+	StatusInterval     uint   `json:"status_interval"`
+}
+
+// MQTT 连接状态
+type MqttStatusResponse struct {
+	Enable    bool   `json:"enable"`
+	Connected bool   `json:"connected"`
+	Buffered  int    `json:"buffered"`   // 离线缓存中的消息数
+	Dropped   uint   `json:"dropped"`    // 缓存已满被丢弃的消息数
+	LastError string `json:"last_error"` // 最近一次连接错误
+}
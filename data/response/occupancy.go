@@ -0,0 +1,47 @@
+package response
+
+// 区域读卡器
+type AreaReaderResponse struct {
+	IBAddr     int  `json:"ibaddr"`
+	ReaderAddr int  `json:"readeraddr"`
+	Direction  uint `json:"direction"`
+}
+
+// 区域
+type AreaResponse struct {
+	ID       uint                 `json:"id"`
+	Name     string               `json:"name"`
+	Capacity uint                 `json:"capacity"`
+	Readers  []AreaReaderResponse `json:"readers"`
+}
+
+// 区域当前人数
+type AreaOccupancyResponse struct {
+	AreaID       uint   `json:"area_id"`
+	AreaName     string `json:"area_name"`
+	Capacity     uint   `json:"capacity"`
+	Count        int64  `json:"count"`
+	OverCapacity bool   `json:"over_capacity"`
+}
+
+// 区域内人员（紧急疏散点名）
+type OccupantResponse struct {
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+	Department string `json:"department"`
+	CardNo     string `json:"card_no"`
+	AreaID     uint   `json:"area_id"`
+	AreaName   string `json:"area_name"`
+	ReaderName string `json:"reader_name"`
+	EnteredAt  uint   `json:"entered_at"`
+	PhotoUrl   string `json:"photo_url,omitempty"` // 照片缩略图，便于点名时核对身份
+}
+
+// 区域超员报警的附加数据
+type OccupancyAlarmResponse struct {
+	AreaID   uint   `json:"area_id"`
+	AreaName string `json:"area_name"`
+	Capacity uint   `json:"capacity"`
+	Count    int64  `json:"count"`
+}
@@ -0,0 +1,35 @@
+package response
+
+// 人员导入的行级错误
+type PeopleImportErrorResponse struct {
+	Row     int    `json:"row"`    // 文件中的行号，标题行为第 1 行
+	Column  string `json:"column"` // 列标题
+	Message string `json:"message"`
+}
+
+// 人员导入结果，试运行时为预计结果
+type PeopleImportResponse struct {
+	DryRun             bool                        `json:"dry_run"`
+	BatchId            uint                        `json:"batch_id"`            // 导入批次，试运行或校验失败时为 0
+	Total              int                         `json:"total"`               // 数据行数
+	Created            int                         `json:"created"`             // 新增人员
+	Updated            int                         `json:"updated"`             // 更新人员
+	DepartmentsCreated int                         `json:"departments_created"` // 自动创建的部门
+	CredentialsCreated int                         `json:"credentials_created"` // 新增凭证
+	Errors             []PeopleImportErrorResponse `json:"errors"`
+}
+
+// 人员导入批次
+type ImportBatchResponse struct {
+	ID                 uint   `json:"id"`
+	FileName           string `json:"file_name"`
+	UserId             uint   `json:"user_id"`
+	Created            int    `json:"created"`
+	Updated            int    `json:"updated"`
+	DepartmentsCreated int    `json:"departments_created"`
+	CredentialsCreated int    `json:"credentials_created"`
+	RolledBack         bool   `json:"rolled_back"`
+	RolledBackBy       uint   `json:"rolled_back_by"`
+	RolledBackAt       uint   `json:"rolled_back_at"`
+	CreatedAt          uint   `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package response
+
+// 人员照片
+type PeoplePhotoResponse struct {
+	PeopleId     uint   `json:"people_id"`
+	PhotoUrl     string `json:"photo_url"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+	Width        uint   `json:"width"`
+	Height       uint   `json:"height"`
+	UpdatedAt    uint   `json:"updated_at"`
+}
+
+// 照片存储空间使用情况
+type PeoplePhotoUsageResponse struct {
+	Count int   `json:"count"` // 照片数
+	Used  int64 `json:"used"`  // 已用空间（字节）
+	Quota int64 `json:"quota"` // 配额（字节）
+}
+
+// 批量上传中单个文件的结果
+type PeoplePhotoUploadItemResponse struct {
+	FileName   string `json:"file_name"`
+	PeopleCode string `json:"people_code"`
+	PeopleId   uint   `json:"people_id"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`
+}
+
+// 照片批量上传结果
+type PeoplePhotoUploadResponse struct {
+	Total     int                             `json:"total"`
+	Succeeded int                             `json:"succeeded"`
+	Failed    int                             `json:"failed"`
+	Items     []PeoplePhotoUploadItemResponse `json:"items"`
+}
@@ -0,0 +1,39 @@
+package response
+
+// 检索到的人员
+type SearchPeopleResponse struct {
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Department string `json:"department"`
+	PhotoUrl   string `json:"photo_url,omitempty"`
+	Score      int    `json:"score"` // 匹配得分，越高越相关
+}
+
+// 检索到的凭证
+type SearchCredentialResponse struct {
+	UniqueId   uint   `json:"unique_id"`
+	CardNo     string `json:"card_no"`
+	PeopleId   uint   `json:"people_id"`
+	PeopleCode string `json:"people_code"`
+	PeopleName string `json:"people_name"`
+	Score      int    `json:"score"`
+}
+
+// 检索到的部门
+type SearchDepartmentResponse struct {
+	DepartmentId uint   `json:"department_id"`
+	Name         string `json:"name"`
+	PeopleCount  int    `json:"people_count"` // 部门人数
+	Score        int    `json:"score"`
+}
+
+// 全文检索结果，按对象类型分组，组内按得分排序
+type SearchResponse struct {
+	Query       string                     `json:"query"`
+	Suggestion  string                     `json:"suggestion,omitempty"` // 没有匹配时纠正拼写后的关键字，结果按纠正后的关键字检索
+	People      []SearchPeopleResponse     `json:"people"`
+	Credentials []SearchCredentialResponse `json:"credentials"`
+	Departments []SearchDepartmentResponse `json:"departments"`
+}
@@ -0,0 +1,54 @@
+package response
+
+// 事件数汇总
+type StatisticsSummaryResponse struct {
+	Granted uint `json:"granted"` // 允许通行次数
+	Denied  uint `json:"denied"`  // 拒绝通行次数
+	Alarm   uint `json:"alarm"`   // 报警次数
+	Total   uint `json:"total"`   // 事件总数
+}
+
+// 按门汇总的事件数（门以接口板地址 + 输出地址区分）
+type DoorStatisticsResponse struct {
+	IBAddr     int    `json:"ibaddr"`
+	OutputAddr int    `json:"outputaddr"`
+	IBName     string `json:"ibname"`
+	StatisticsSummaryResponse
+}
+
+// 按读卡器汇总的事件数
+type ReaderStatisticsResponse struct {
+	IBAddr     int    `json:"ibaddr"`
+	ReaderAddr int    `json:"readeraddr"`
+	IBName     string `json:"ibname"`
+	ReaderName string `json:"readername"`
+	StatisticsSummaryResponse
+}
+
+// 按部门汇总的事件数
+type DepartmentStatisticsResponse struct {
+	Department string `json:"department"` // 部门名称，为空表示未登记人员
+	StatisticsSummaryResponse
+}
+
+// 热力图单元格
+type HeatmapCellResponse struct {
+	Weekday uint `json:"weekday"` // 星期 0：周日 1-6：周一至周六
+	Hour    uint `json:"hour"`    // 小时 0-23
+	StatisticsSummaryResponse
+}
+
+// 事件热力图，Hourly 按小时汇总，Weekday 按星期汇总，Cells 按星期 × 小时汇总
+type HeatmapResponse struct {
+	Hourly  []StatisticsSummaryResponse `json:"hourly"`  // 24 项
+	Weekday []StatisticsSummaryResponse `json:"weekday"` // 7 项，下标 0 为周日
+	Cells   []HeatmapCellResponse       `json:"cells"`
+}
+
+// 被拒绝次数最多的卡
+type DeniedCardResponse struct {
+	CardNo     string `json:"cardno"`
+	PeopleName string `json:"people_name"`
+	Count      uint   `json:"count"`
+	LastTime   uint   `json:"last_time"`
+}
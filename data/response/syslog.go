@@ -0,0 +1,28 @@
+package response
+
+// Syslog 转发配置
+type SyslogConfigResponse struct {
+	Enable             bool   `json:"enable"`
+	Protocol           string `json:"protocol"`
+	Host               string `json:"host"`
+	Port               uint   `json:"port"`
+	Format             string `json:"format"`
+	Facility           uint   `json:"facility"`
+	AppName            string `json:"app_name"`
+	Hostname           string `json:"hostname"`
+	CACert             string `json:"ca_cert"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	ForwardAccess      bool   `json:"forward_access"`
+	ForwardAlarm       bool   `json:"forward_alarm"`
+	ForwardAudit       bool   `json:"forward_audit"`
+	ForwardSystem      bool   `json:"forward_system"`
+}
+
+// Syslog 转发状态
+type SyslogStatusResponse struct {
+	Enable    bool   `json:"enable"`
+	Connected bool   `json:"connected"`
+	Queued    int64  `json:"queued"`     // 本地队列中等待发送的消息数
+	LastError string `json:"last_error"` // 最近一次发送错误
+	LastSent  uint   `json:"last_sent"`  // 最近一次发送成功时间 UNIX时间戳
+}
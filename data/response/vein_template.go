@@ -0,0 +1,36 @@
+package response
+
+// 指静脉模板，不返回模板数据
+type VeinTemplateResponse struct {
+	PeopleId    uint   `json:"people_id"`
+	FingerIndex uint   `json:"finger_index"`
+	Size        uint   `json:"size"`
+	Checksum    string `json:"checksum"`
+	UpdatedAt   uint   `json:"updated_at"`
+}
+
+// 指静脉模板数量和容量
+type VeinTemplateUsageResponse struct {
+	Count        int64 `json:"count"`          // 模板数
+	PeopleCount  int64 `json:"people_count"`   // 登记了模板的人数
+	Capacity     int64 `json:"capacity"`       // 模板容量
+	Free         int64 `json:"free"`           // 剩余容量
+	MaxPerPeople int   `json:"max_per_people"` // 每人最多模板数
+}
+
+// 导入中单个模板的结果
+type VeinTemplateImportItemResponse struct {
+	PeopleCode  string `json:"people_code"`
+	FingerIndex uint   `json:"finger_index"`
+	PeopleId    uint   `json:"people_id"`
+	Success     bool   `json:"success"`
+	Message     string `json:"message,omitempty"`
+}
+
+// 指静脉模板导入结果
+type VeinTemplateImportResponse struct {
+	Total     int                              `json:"total"`
+	Succeeded int                              `json:"succeeded"`
+	Failed    int                              `json:"failed"`
+	Items     []VeinTemplateImportItemResponse `json:"items"`
+}
@@ -0,0 +1,22 @@
+package response
+
+// 访客
+type VisitorResponse struct {
+	ID             uint   `json:"id"`
+	Name           string `json:"name"`
+	Company        string `json:"company"`
+	Phone          string `json:"phone"`
+	HostPeopleId   uint   `json:"host_people_id"`
+	HostName       string `json:"host_name"`
+	Purpose        string `json:"purpose"`
+	ExpectedFrom   uint   `json:"expected_from"`
+	ExpectedUntil  uint   `json:"expected_until"`
+	CardNo         string `json:"card_no"`
+	AccessGroupIds []uint `json:"access_group_ids"`
+	PeopleId       uint   `json:"people_id"`
+	UniqueId       uint   `json:"unique_id"`
+	Status         uint   `json:"status"` // 0：已预约 1：已签到 2：已签离 3：已过期 4：已取消
+	CheckedInAt    uint   `json:"checked_in_at"`
+	CheckedOutAt   uint   `json:"checked_out_at"`
+	CreatedAt      uint   `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package response
+
+// Webhook 订阅信息，不返回签名密钥
+type WebhookResponse struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	HasSecret  bool     `json:"has_secret"`
+	Categories []string `json:"categories"`
+	EventTypes []uint   `json:"event_types"`
+	Enable     bool     `json:"enable"`
+	MaxRetries uint     `json:"max_retries"`
+	Timeout    uint     `json:"timeout"`
+}
+
+// Webhook 投递记录
+type WebhookDeliveryResponse struct {
+	ID          uint   `json:"id"`
+	WebhookID   uint   `json:"webhook_id"`
+	Topic       string `json:"topic"`
+	MsgId       uint   `json:"msgid"`
+	Payload     string `json:"payload"`
+	Status      uint   `json:"status"` // 0：等待投递 1：成功 2：失败
+	Attempts    uint   `json:"attempts"`
+	NextAttempt uint   `json:"next_attempt"`
+	StatusCode  int    `json:"status_code"`
+	LastError   string `json:"last_error"`
+	DeliveredAt uint   `json:"delivered_at"`
+	CreatedAt   uint   `json:"created_at"`
+}
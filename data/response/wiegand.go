@@ -0,0 +1,44 @@
+package response
+
+// 韦根规则
+type WiegandRuleResponse struct {
+	ID               uint   `json:"id"`
+	Name             string `json:"name"`
+	BitLength        uint   `json:"bit_length"`
+	FacilityStart    uint   `json:"facility_start"`
+	FacilityLength   uint   `json:"facility_length"`
+	CardStart        uint   `json:"card_start"`
+	CardLength       uint   `json:"card_length"`
+	EvenParityBit    uint   `json:"even_parity_bit"`
+	EvenParityStart  uint   `json:"even_parity_start"`
+	EvenParityLength uint   `json:"even_parity_length"`
+	OddParityBit     uint   `json:"odd_parity_bit"`
+	OddParityStart   uint   `json:"odd_parity_start"`
+	OddParityLength  uint   `json:"odd_parity_length"`
+	Preset           bool   `json:"preset"` // 是否为预置规则，预置规则不在控制器中，ID 为 0
+}
+
+// 按一种韦根规则解码的结果
+type WiegandDecodeResponse struct {
+	RuleId       uint   `json:"rule_id"`
+	RuleName     string `json:"rule_name"`
+	BitLength    uint   `json:"bit_length"`
+	FacilityCode uint64 `json:"facility_code"`
+	CardNumber   uint64 `json:"card_number"`
+	ParityValid  bool   `json:"parity_valid"`            // 所有校验位是否正确
+	ParityErrors []uint `json:"parity_errors,omitempty"` // 校验错误的校验位
+}
+
+// 韦根数据的解码结果，校验正确的规则在前
+type WiegandDecodeResultResponse struct {
+	Bits    string                  `json:"bits"` // 原始位串
+	Results []WiegandDecodeResponse `json:"results"`
+}
+
+// 事件的韦根解码结果
+type EventWiegandResponse struct {
+	MsgId   uint                   `json:"msgid"`
+	Wiegand uint                   `json:"wiegand"` // 事件中的韦根位数
+	CardNo  string                 `json:"cardno"`  // 事件中的卡号
+	Decoded *WiegandDecodeResponse `json:"decoded"` // 解码结果，没有匹配的规则时为空
+}
@@ -50,6 +50,48 @@ func Migrate() {
 
 	// 在事件消息数据库（DbEventMessage）中自动迁移表
 	DB.DbEventMessage.AutoMigrate(&model.EventMessageData{})
+
+	MigrateExtension()
+}
+
+// MigrateExtension 迁移扩展功能使用的表结构
+// 服务启动时也会调用，已升级应用的设备无需重新初始化数据库即可创建新表
+func MigrateExtension() {
+	// 主数据库（DbConfig）
+	DB.DbConfig.AutoMigrate(&model.Webhook{})
+	DB.DbConfig.AutoMigrate(&model.MqttConfig{})
+	DB.DbConfig.AutoMigrate(&model.SyslogConfig{})
+	DB.DbConfig.AutoMigrate(&model.Shift{})
+	DB.DbConfig.AutoMigrate(&model.ShiftAssignment{})
+	DB.DbConfig.AutoMigrate(&model.AttendanceReader{})
+	DB.DbConfig.AutoMigrate(&model.Area{})
+	DB.DbConfig.AutoMigrate(&model.AreaReader{})
+
+	// 用户凭证数据库（DbCredential）
+	DB.DbCredential.AutoMigrate(&model.ImportBatch{})
+	DB.DbCredential.AutoMigrate(&model.CredentialState{})
+	DB.DbCredential.AutoMigrate(&model.PeopleValidity{})
+	DB.DbCredential.AutoMigrate(&model.Visitor{})
+	DB.DbCredential.AutoMigrate(&model.PeoplePhoto{})
+	DB.DbCredential.AutoMigrate(&model.DepartmentNode{})
+	DB.DbCredential.AutoMigrate(&model.DepartmentAccess{})
+	DB.DbCredential.AutoMigrate(&model.DepartmentAccessGrant{})
+	DB.DbCredential.AutoMigrate(&model.VeinTemplate{})
+	DB.DbCredential.AutoMigrate(&model.LostCard{})
+	migrateSearchIndex(DB.DbCredential)
+	migrateIntegrity(DB.DbCredential)
+
+	// 事件消息数据库（DbEventMessage）
+	DB.DbEventMessage.AutoMigrate(&model.EventCursor{})
+	DB.DbEventMessage.AutoMigrate(&model.WebhookDelivery{})
+	DB.DbEventMessage.AutoMigrate(&model.SyslogQueue{})
+	DB.DbEventMessage.AutoMigrate(&model.AuditLog{})
+	DB.DbEventMessage.AutoMigrate(&model.EventStatHour{})
+	DB.DbEventMessage.AutoMigrate(&model.EventStatCard{})
+	DB.DbEventMessage.AutoMigrate(&model.AttendanceRecord{})
+	DB.DbEventMessage.AutoMigrate(&model.AttendanceCorrection{})
+	DB.DbEventMessage.AutoMigrate(&model.Occupancy{})
+	DB.DbEventMessage.AutoMigrate(&model.CredentialUsage{})
 }
 
 // CloseDbConnection 关闭所有数据库连接
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"hoyang/ownsa/model"
+)
+
+// 数据完整性触发器的错误信息，任何写入路径违反约束时由数据库返回
+const (
+	integrityDuplicateCard     = "card number is already assigned to another credential"
+	integrityLostCard          = "card number is reported lost"
+	integrityMissingDepartment = "department does not exist"
+	integrityDepartmentInUse   = "department still has people, reassign them to another department first"
+)
+
+// 触发器拒绝写入时返回的错误，由 gorm 回调从 SQLite 的 RAISE 错误转换，可用 errors.Is 判断
+var (
+	ErrCardInUse          = errors.New(integrityDuplicateCard)
+	ErrCardLost           = errors.New(integrityLostCard)
+	ErrDepartmentNotFound = errors.New(integrityMissingDepartment)
+	ErrDepartmentInUse    = errors.New(integrityDepartmentInUse)
+)
+
+var integrityErrors = map[string]error{
+	integrityDuplicateCard:     ErrCardInUse,
+	integrityLostCard:          ErrCardLost,
+	integrityMissingDepartment: ErrDepartmentNotFound,
+	integrityDepartmentInUse:   ErrDepartmentInUse,
+}
+
+// integrityTrigger 数据完整性触发器，condition 为 BEFORE 子句和 WHEN 条件
+type integrityTrigger struct {
+	name      string
+	condition string
+	message   string
+}
+
+// parseSchema 解析模型的表结构，表不存在时返回 nil
+func parseSchema(db *gorm.DB, value interface{}) *schema.Schema {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		log.Printf("integrity: %v", err)
+		return nil
+	}
+	if !db.Migrator().HasTable(stmt.Schema.Table) || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	return stmt.Schema
+}
+
+// softDeleteColumn 返回软删除列名，表没有软删除列时返回空字符串
+func softDeleteColumn(s *schema.Schema) string {
+	if field := s.LookUpField("DeletedAt"); field != nil && field.DBName != "" {
+		return field.DBName
+	}
+	return ""
+}
+
+// aliveCondition 返回子查询中只匹配未删除记录的条件，表没有软删除列时返回空字符串
+func aliveCondition(s *schema.Schema) string {
+	if column := softDeleteColumn(s); column != "" {
+		return " AND " + column + " IS NULL"
+	}
+	return ""
+}
+
+// updateTrigger 返回修改 column 或恢复软删除记录时检查 check 的触发条件
+func updateTrigger(s *schema.Schema, column string, check string) string {
+	deletedAt := softDeleteColumn(s)
+	if deletedAt == "" {
+		return fmt.Sprintf("BEFORE UPDATE OF %s ON %s WHEN NEW.%s IS NOT OLD.%s AND %s",
+			column, s.Table, column, column, check)
+	}
+	return fmt.Sprintf("BEFORE UPDATE OF %s, %s ON %s WHEN NEW.%s IS NULL AND (NEW.%s IS NOT OLD.%s OR OLD.%s IS NOT NULL) AND %s",
+		column, deletedAt, s.Table, deletedAt, column, column, deletedAt, check)
+}
+
+// insertTrigger 返回插入未删除记录时检查 check 的触发条件
+func insertTrigger(s *schema.Schema, check string) string {
+	if deletedAt := softDeleteColumn(s); deletedAt != "" {
+		check = "NEW." + deletedAt + " IS NULL AND " + check
+	}
+	return fmt.Sprintf("BEFORE INSERT ON %s WHEN %s", s.Table, check)
+}
+
+// migrateIntegrity 在用户凭证数据库中创建数据完整性触发器
+// 同一卡号不能分配给多个凭证，挂失黑名单中的卡号不能分配给凭证，人员不能关联不存在的部门，仍有人员的部门不能删除；
+// 触发器只在卡号或部门变化、或恢复软删除的记录时检查，已有的重复数据不影响其他字段的修改，可通过数据质量报告查找；
+// 有软删除列的表只把未删除的记录视为存在，部门的软删除是 UPDATE，由单独的触发器检查
+func migrateIntegrity(db *gorm.DB) {
+	credential := parseSchema(db, &model.Credential{})
+	lostCard := parseSchema(db, &model.LostCard{})
+	people := parseSchema(db, &model.People{})
+	department := parseSchema(db, &model.Department{})
+
+	var triggers []integrityTrigger
+	if credential != nil {
+		pk := credential.PrioritizedPrimaryField.DBName
+		duplicate := fmt.Sprintf("NEW.card_no <> '' AND EXISTS (SELECT 1 FROM %s WHERE card_no = NEW.card_no AND %s <> NEW.%s%s)",
+			credential.Table, pk, pk, aliveCondition(credential))
+		triggers = append(triggers,
+			integrityTrigger{"red_integrity_credential_bi", insertTrigger(credential, duplicate), integrityDuplicateCard},
+			integrityTrigger{"red_integrity_credential_bu", updateTrigger(credential, "card_no", duplicate), integrityDuplicateCard},
+		)
+		// 上游的凭证接口不经过挂失检查，由触发器拒绝黑名单中的卡号
+		if lostCard != nil {
+			lost := fmt.Sprintf("NEW.card_no <> '' AND EXISTS (SELECT 1 FROM %s WHERE card_no = NEW.card_no)", lostCard.Table)
+			triggers = append(triggers,
+				integrityTrigger{"red_integrity_credential_lost_bi", insertTrigger(credential, lost), integrityLostCard},
+				integrityTrigger{"red_integrity_credential_lost_bu", updateTrigger(credential, "card_no", lost), integrityLostCard},
+			)
+		}
+	}
+	if people != nil && department != nil {
+		if field := people.LookUpField("DepartmentID"); field != nil {
+			column, pk := field.DBName, department.PrioritizedPrimaryField.DBName
+			missing := fmt.Sprintf("NEW.%s <> 0 AND NOT EXISTS (SELECT 1 FROM %s WHERE %s = NEW.%s%s)",
+				column, department.Table, pk, column, aliveCondition(department))
+			inUse := fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s = OLD.%s%s)",
+				people.Table, column, pk, aliveCondition(people))
+			triggers = append(triggers,
+				integrityTrigger{"red_integrity_people_bi", insertTrigger(people, missing), integrityMissingDepartment},
+				integrityTrigger{"red_integrity_people_bu", updateTrigger(people, column, missing), integrityMissingDepartment},
+				integrityTrigger{"red_integrity_department_bd", fmt.Sprintf("BEFORE DELETE ON %s WHEN %s", department.Table, inUse), integrityDepartmentInUse},
+			)
+			if deletedAt := softDeleteColumn(department); deletedAt != "" {
+				triggers = append(triggers, integrityTrigger{"red_integrity_department_bsd",
+					fmt.Sprintf("BEFORE UPDATE OF %s ON %s WHEN OLD.%s IS NULL AND NEW.%s IS NOT NULL AND %s",
+						deletedAt, department.Table, deletedAt, deletedAt, inUse),
+					integrityDepartmentInUse})
+			}
+		}
+	}
+
+	// 触发器条件可能随版本变化，每次启动时重建
+	for _, trigger := range triggers {
+		statements := []string{
+			"DROP TRIGGER IF EXISTS " + trigger.name,
+			fmt.Sprintf("CREATE TRIGGER %s %s BEGIN SELECT RAISE(ABORT, '%s'); END", trigger.name, trigger.condition, trigger.message),
+		}
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				log.Printf("integrity: %v", err)
+			}
+		}
+	}
+
+	registerIntegrityErrors(db)
+}
+
+// registerIntegrityErrors 注册 gorm 回调，把触发器的 RAISE 错误转换为 ErrCardInUse 等错误
+func registerIntegrityErrors(db *gorm.DB) {
+	const name = "red:integrity_error"
+	callbacks := db.Callback()
+	if callbacks.Create().Get(name) != nil {
+		return
+	}
+	callbacks.Create().After("gorm:create").Register(name, translateIntegrityError)
+	callbacks.Update().After("gorm:update").Register(name, translateIntegrityError)
+	callbacks.Delete().After("gorm:delete").Register(name, translateIntegrityError)
+	callbacks.Raw().After("gorm:raw").Register(name, translateIntegrityError)
+}
+
+// translateIntegrityError 转换触发器拒绝写入的错误，其他错误保持不变
+func translateIntegrityError(db *gorm.DB) {
+	var sqliteErr sqlite3.Error
+	if db.Error == nil || !errors.As(db.Error, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintTrigger {
+		return
+	}
+	for message, err := range integrityErrors {
+		if strings.Contains(sqliteErr.Error(), message) {
+			db.Error = err
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+)
+
+// searchSource 全文检索索引的数据来源
+type searchSource struct {
+	kind  string      // 对象类型
+	value interface{} // 数据表对应的模型
+}
+
+// 全文检索索引的数据来源，触发器在数据变化时记录待更新的对象
+var searchSources = []searchSource{
+	{kind: model.SearchKindPeople, value: &model.People{}},
+	{kind: model.SearchKindCredential, value: &model.Credential{}},
+	{kind: model.SearchKindDepartment, value: &model.Department{}},
+}
+
+// migrateSearchIndex 在用户凭证数据库中创建全文检索虚拟表和数据变化触发器
+// 使用 go-sqlite3 默认编译的 FTS4，不需要额外的编译标签；首次创建时将所有人员、凭证和部门加入待更新列表
+func migrateSearchIndex(db *gorm.DB) {
+	db.AutoMigrate(&model.SearchDirty{})
+
+	created := !db.Migrator().HasTable(model.SearchIndexTable)
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + model.SearchIndexTable + " USING fts4(title, code, tokenize=unicode61)").Error
+	if err != nil {
+		log.Printf("search index: %v", err)
+		return
+	}
+	err = db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + model.SearchTermsTable + " USING fts4aux(" + model.SearchIndexTable + ")").Error
+	if err != nil {
+		log.Printf("search index: %v", err)
+	}
+
+	dirtyTable := model.SearchDirty{}.TableName()
+	for _, source := range searchSources {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(source.value); err != nil {
+			log.Printf("search index: %s: %v", source.kind, err)
+			continue
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) || stmt.Schema.PrioritizedPrimaryField == nil {
+			continue
+		}
+		pk := stmt.Schema.PrioritizedPrimaryField.DBName
+
+		mark := func(row string) string {
+			return fmt.Sprintf("INSERT OR IGNORE INTO %s (kind, ref) VALUES ('%s', %s.%s);", dirtyTable, source.kind, row, pk)
+		}
+		triggers := []string{
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS red_search_%s_ai AFTER INSERT ON %s BEGIN %s END", source.kind, table, mark("NEW")),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS red_search_%s_au AFTER UPDATE ON %s BEGIN %s %s END", source.kind, table, mark("OLD"), mark("NEW")),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS red_search_%s_ad AFTER DELETE ON %s BEGIN %s END", source.kind, table, mark("OLD")),
+		}
+		for _, trigger := range triggers {
+			if err := db.Exec(trigger).Error; err != nil {
+				log.Printf("search index: %s: %v", source.kind, err)
+			}
+		}
+
+		if created {
+			err := db.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (kind, ref) SELECT '%s', %s FROM %s", dirtyTable, source.kind, pk, table)).Error
+			if err != nil {
+				log.Printf("search index: %s: %v", source.kind, err)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ module hoyang/ownsa
 go 1.22.3
 
 require (
+	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/gin-gonic/gin v1.10.0
 	github.com/go-errors/errors v1.5.1
 	github.com/golang-jwt/jwt/v5 v5.2.1
@@ -12,7 +13,9 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/sanity-io/litter v1.5.5
 	github.com/spf13/cast v1.7.0
+	github.com/xuri/excelize/v2 v2.8.1
 	golang.org/x/crypto v0.28.0
+	golang.org/x/image v0.18.0
 	gorm.io/driver/sqlite v1.5.6
 	gorm.io/gen v0.3.26
 	gorm.io/gorm v1.25.12
@@ -20,9 +23,14 @@ require (
 
 require (
 	github.com/beevik/ntp v1.4.3 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 	github.com/otiai10/copy v1.14.1 // indirect
 	github.com/otiai10/mint v1.6.3 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.3 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
+	github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
+	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
 )
 
 require (
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"hoyang/ownsa/model"
+)
+
+// 解析响应结果时最多缓存的响应体大小
+const auditBodyLimit = 64 << 10
+
+// auditResponseWriter 在写出响应的同时缓存响应体，用于判断操作是否成功
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write 写出响应并缓存不超过 auditBodyLimit 的内容
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if remain := auditBodyLimit - w.body.Len(); remain > 0 {
+		if len(data) > remain {
+			w.body.Write(data[:remain])
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// AuditMiddleware 是一个 Gin 中间件，记录 POST、PUT、PATCH、DELETE 请求的操作审计日志
+// 业务接口统一返回 HTTP 200，操作结果取自响应体中的 success 和 message 字段；
+// 业务错误以 panic 抛出，由外层的 ErrorHandler 生成响应，这里记录为失败后继续抛出
+func AuditMiddleware(record func(auditLog model.AuditLog)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			ctx.Next()
+			return
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		defer func() {
+			err := recover()
+
+			auditLog := model.AuditLog{
+				UserId:   ctx.GetUint("id"),
+				Method:   ctx.Request.Method,
+				Path:     ctx.FullPath(),
+				Target:   truncateString(ctx.Request.URL.RequestURI(), 255),
+				ClientIP: ctx.ClientIP(),
+				Status:   writer.Status(),
+			}
+			if auditLog.Path == "" {
+				auditLog.Path = truncateString(ctx.Request.URL.Path, 255)
+			}
+
+			var result struct {
+				Success *bool  `json:"success"`
+				Message string `json:"message"`
+			}
+			if err != nil {
+				auditLog.Message = auditMessage(fmt.Sprint(err))
+			} else if json.Unmarshal(writer.body.Bytes(), &result) == nil && result.Success != nil {
+				if *result.Success {
+					auditLog.Success = 1
+				} else {
+					auditLog.Message = auditMessage(result.Message)
+				}
+			} else if auditLog.Status < http.StatusBadRequest {
+				auditLog.Success = 1
+			}
+
+			record(auditLog)
+			if err != nil {
+				panic(err)
+			}
+		}()
+		ctx.Next()
+	}
+}
+
+// auditMessage 错误信息可能包含堆栈，只保留第一行
+func auditMessage(message string) string {
+	first, _, _ := strings.Cut(message, "\n")
+	return truncateString(first, 255)
+}
+
+// truncateString 截断过长的字符串
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
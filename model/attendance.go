@@ -0,0 +1,92 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// 考勤班次
+type Shift struct {
+	gorm.Model
+
+	Name       string `gorm:"type:varchar(50);not null"` // 名称
+	StartTime  string `gorm:"type:varchar(5);not null"`  // 上班时间 HH:MM
+	EndTime    string `gorm:"type:varchar(5);not null"`  // 下班时间 HH:MM，不晚于上班时间时表示次日下班（跨天班次）
+	LateGrace  uint   `gorm:"not null"`                  // 迟到宽限（分钟）
+	EarlyGrace uint   `gorm:"not null"`                  // 早退宽限（分钟）
+	WorkDays   string `gorm:"type:varchar(20)"`          // 工作日，逗号分隔 0-6（0 为周日）
+}
+
+// TableName 返回 Shift 类型的表名。
+func (Shift) TableName() string {
+	return "red_shift"
+}
+
+// 班次分配，分配给人员或部门，人员的分配优先于所属部门的分配
+type ShiftAssignment struct {
+	gorm.Model
+
+	ShiftID      uint `gorm:"index;not null"` // 班次
+	PeopleId     uint `gorm:"index"`          // 人员，为 0 时表示分配给部门
+	DepartmentId uint `gorm:"index"`          // 部门，为 0 时表示分配给人员
+}
+
+// TableName 返回 ShiftAssignment 类型的表名。
+func (ShiftAssignment) TableName() string {
+	return "red_shift_assignment"
+}
+
+// 考勤读卡器，未指定任何考勤读卡器时所有读卡器的通行记录都参与考勤
+type AttendanceReader struct {
+	ID uint `gorm:"primarykey"`
+
+	IBAddr     int `gorm:"uniqueIndex:idx_attendance_reader;not null"` // 接口板地址
+	ReaderAddr int `gorm:"uniqueIndex:idx_attendance_reader;not null"` // 读卡器地址
+}
+
+// TableName 返回 AttendanceReader 类型的表名。
+func (AttendanceReader) TableName() string {
+	return "red_attendance_reader"
+}
+
+// 每日考勤结果，由后台任务根据通行记录计算，人工更正后按更正内容重新计算
+type AttendanceRecord struct {
+	ID uint `gorm:"primarykey"`
+
+	PeopleId     uint   `gorm:"uniqueIndex:idx_attendance_record;not null"`                  // 人员
+	Date         string `gorm:"uniqueIndex:idx_attendance_record;type:varchar(10);not null"` // 考勤日期 2006-01-02
+	ShiftID      uint   `gorm:"not null"`                                                    // 班次
+	WorkDay      uint   `gorm:"not null"`                                                    // 是否工作日 0：否 1：是
+	FirstIn      uint   // 首次刷卡时间 UNIX时间戳，0 表示无记录
+	LastOut      uint   // 最后刷卡时间 UNIX时间戳，0 表示无记录
+	Late         uint   `gorm:"not null"` // 是否迟到 0：否 1：是
+	LateMinutes  uint   `gorm:"not null"` // 迟到分钟数
+	EarlyLeave   uint   `gorm:"not null"` // 是否早退（含未打下班卡） 0：否 1：是
+	EarlyMinutes uint   `gorm:"not null"` // 早退分钟数
+	Absent       uint   `gorm:"not null"` // 是否缺勤 0：否 1：是
+	Excused      uint   `gorm:"not null"` // 是否免除考勤（请假、出差等） 0：否 1：是
+	Corrected    uint   `gorm:"not null"` // 是否经过人工更正 0：否 1：是
+	UpdatedAt    uint   // 计算时间 UNIX时间戳
+}
+
+// TableName 返回 AttendanceRecord 类型的表名。
+func (AttendanceRecord) TableName() string {
+	return "red_attendance_record"
+}
+
+// 考勤人工更正记录，同一人员同一天以最后一次更正为准
+type AttendanceCorrection struct {
+	gorm.Model
+
+	PeopleId uint   `gorm:"index:idx_attendance_correction;not null"`                  // 人员
+	Date     string `gorm:"index:idx_attendance_correction;type:varchar(10);not null"` // 考勤日期 2006-01-02
+	FirstIn  uint   // 更正后的上班时间 UNIX时间戳，0 表示不更正
+	LastOut  uint   // 更正后的下班时间 UNIX时间戳，0 表示不更正
+	Excused  uint   `gorm:"not null"`                   // 是否免除考勤 0：否 1：是
+	Reason   string `gorm:"type:varchar(255);not null"` // 更正原因
+	UserId   uint   // 操作员 ID
+}
+
+// TableName 返回 AttendanceCorrection 类型的表名。
+func (AttendanceCorrection) TableName() string {
+	return "red_attendance_correction"
+}
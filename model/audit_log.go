@@ -0,0 +1,21 @@
+package model
+
+// 操作审计日志，记录操作员通过 Web 接口进行的修改操作
+type AuditLog struct {
+	ID uint `gorm:"primarykey"`
+
+	UserId    uint   `gorm:"index"`             // 操作员 ID，未登录（如登录请求）时为 0
+	Method    string `gorm:"type:varchar(10)"`  // 请求方法
+	Path      string `gorm:"type:varchar(255)"` // 请求路径（路由模板，如 /api/people/:peopleId）
+	Target    string `gorm:"type:varchar(255)"` // 实际请求地址
+	ClientIP  string `gorm:"type:varchar(50)"`  // 客户端 IP
+	Status    int    // HTTP 状态码
+	Success   uint   `gorm:"not null"`          // 是否成功 0：失败 1：成功
+	Message   string `gorm:"type:varchar(255)"` // 失败原因
+	CreatedAt uint   `gorm:"index"`             // 操作时间 UNIX时间戳
+}
+
+// TableName 返回 AuditLog 类型的表名。
+func (AuditLog) TableName() string {
+	return "red_audit_log"
+}
@@ -0,0 +1,41 @@
+package model
+
+// 凭证停用原因
+const (
+	CredentialDisabledManual  = "manual"  // 人工停用
+	CredentialDisabledPending = "pending" // 未到生效时间，到期后自动启用
+	CredentialDisabledExpired = "expired" // 已过失效时间，延长有效期后自动启用
+	CredentialDisabledStale   = "stale"   // 长期未使用，需人工启用
+)
+
+// 凭证状态，与凭证存放在同一个数据库（DbCredential）
+// 停用凭证时将其门禁组移到 SuspendedAccess 并删除门禁组关联，控制器即不再放行；启用时恢复
+type CredentialState struct {
+	UniqueId uint `gorm:"primarykey;autoIncrement:false"` // 凭证
+
+	Disabled        uint   `gorm:"not null"`         // 是否停用 0：否 1：是
+	DisabledReason  string `gorm:"type:varchar(50)"` // 停用原因
+	SuspendedAccess string `gorm:"type:text"`        // 停用期间保存的门禁组，JSON 数组
+	ValidFrom       uint   `gorm:"not null"`         // 生效时间 UNIX时间戳，0 表示不限制
+	ValidUntil      uint   `gorm:"not null"`         // 失效时间 UNIX时间戳，0 表示不限制
+	UpdatedAt       uint   // 更新时间 UNIX时间戳
+}
+
+// TableName 返回 CredentialState 类型的表名。
+func (CredentialState) TableName() string {
+	return "red_credential_state"
+}
+
+// 人员有效期，作用于该人员的所有凭证，与凭证自身的有效期取交集
+type PeopleValidity struct {
+	PeopleId uint `gorm:"primarykey;autoIncrement:false"` // 人员
+
+	ValidFrom  uint `gorm:"not null"` // 生效时间 UNIX时间戳，0 表示不限制
+	ValidUntil uint `gorm:"not null"` // 失效时间 UNIX时间戳，0 表示不限制
+	UpdatedAt  uint // 更新时间 UNIX时间戳
+}
+
+// TableName 返回 PeopleValidity 类型的表名。
+func (PeopleValidity) TableName() string {
+	return "red_people_validity"
+}
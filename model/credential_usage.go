@@ -0,0 +1,21 @@
+package model
+
+// 凭证使用情况，与事件消息存放在同一个数据库（DbEventMessage）
+// 由后台任务按事件消息 ID 增量汇总允许通行事件；没有使用记录的凭证从首次跟踪时开始计算未使用天数
+type CredentialUsage struct {
+	UniqueId uint `gorm:"primarykey;autoIncrement:false"` // 凭证
+
+	UseCount     uint   `gorm:"not null"`          // 允许通行次数
+	LastUsedAt   uint   `gorm:"index;not null"`    // 最近一次通行时间 UNIX时间戳，0 表示未使用
+	IBAddr       int    `gorm:"not null"`          // 最近一次通行的接口板地址
+	ReaderAddr   int    `gorm:"not null"`          // 最近一次通行的读卡器地址
+	OutputAddr   int    `gorm:"not null"`          // 最近一次通行的输出（门）地址
+	IBName       string `gorm:"type:varchar(100)"` // 最近一次通行的接口板名称
+	ReaderName   string `gorm:"type:varchar(100)"` // 最近一次通行的读卡器名称
+	TrackedSince uint   `gorm:"not null"`          // 开始跟踪时间 UNIX时间戳
+}
+
+// TableName 返回 CredentialUsage 类型的表名。
+func (CredentialUsage) TableName() string {
+	return "red_credential_usage"
+}
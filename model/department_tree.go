@@ -0,0 +1,39 @@
+package model
+
+// 部门的上级部门，与部门存放在同一个数据库（DbCredential）；没有记录的部门为顶级部门
+type DepartmentNode struct {
+	DepartmentId uint `gorm:"primarykey;autoIncrement:false"` // 部门
+
+	ParentId  uint `gorm:"index;not null"` // 上级部门，0 表示顶级部门
+	UpdatedAt uint // 更新时间 UNIX时间戳
+}
+
+// TableName 返回 DepartmentNode 类型的表名。
+func (DepartmentNode) TableName() string {
+	return "red_department_node"
+}
+
+// 分配给部门的门禁组，部门及其下级部门的人员的凭证都继承该门禁组
+type DepartmentAccess struct {
+	DepartmentId  uint `gorm:"primarykey;autoIncrement:false"` // 部门
+	AccessGroupId uint `gorm:"primarykey;autoIncrement:false"` // 门禁组
+}
+
+// TableName 返回 DepartmentAccess 类型的表名。
+func (DepartmentAccess) TableName() string {
+	return "red_department_access"
+}
+
+// 因部门继承而为凭证添加的门禁组，人员调离部门或部门取消门禁组时只移除这些门禁组，直接分配给凭证的门禁组不受影响
+// 继承的门禁组被单独从凭证移除后记录保留并标记为已移除，同步时不再添加，不再继承时删除记录
+type DepartmentAccessGrant struct {
+	UniqueId      uint `gorm:"primarykey;autoIncrement:false"` // 凭证
+	AccessGroupId uint `gorm:"primarykey;autoIncrement:false"` // 门禁组
+
+	Removed uint `gorm:"not null;default:0"` // 已单独从凭证移除 0：否 1：是
+}
+
+// TableName 返回 DepartmentAccessGrant 类型的表名。
+func (DepartmentAccessGrant) TableName() string {
+	return "red_department_access_grant"
+}
@@ -0,0 +1,13 @@
+package model
+
+// 事件消费游标，记录各后台消费者已处理到的事件消息 ID
+type EventCursor struct {
+	Name      string `gorm:"type:varchar(50);primaryKey"` // 消费者名称
+	LastMsgId uint   `gorm:"not null"`                    // 已处理的最后一条事件消息 ID
+	UpdatedAt uint   // 更新时间 UNIX时间戳
+}
+
+// TableName 返回 EventCursor 类型的表名。
+func (EventCursor) TableName() string {
+	return "red_event_cursor"
+}
@@ -0,0 +1,31 @@
+package model
+
+// 事件类型（EventMessageData.EventType）
+const (
+	EventTypeSystem  = 1 // 系统事件
+	EventTypeDenied  = 2 // 拒绝通行事件（未注册卡、无权限等）
+	EventTypeGranted = 3 // 允许通行事件
+	EventTypeAlarm   = 4 // 报警事件（强闯、开门超时、防撬等）
+)
+
+// 事件类别，用于对外推送时的订阅过滤
+const (
+	EventCategorySystem = "system" // 系统
+	EventCategoryAccess = "access" // 通行
+	EventCategoryAlarm  = "alarm"  // 报警
+	EventCategoryAudit  = "audit"  // 操作审计
+)
+
+// EventTopic 根据事件类型返回对外推送使用的主题名称
+func EventTopic(eventType uint) string {
+	switch eventType {
+	case EventTypeDenied:
+		return "access.denied"
+	case EventTypeGranted:
+		return "access.granted"
+	case EventTypeAlarm:
+		return "alarm.event"
+	default:
+		return "system.event"
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+// 人员导入批次，记录导入写入的数据和修改前的快照，用于回滚
+// 与人员、凭证存放在同一个数据库（DbCredential），导入和回滚都在一个事务中完成
+type ImportBatch struct {
+	ID uint `gorm:"primarykey"`
+
+	FileName     string `gorm:"type:varchar(255)"` // 导入文件名
+	UserId       uint   // 操作员 ID
+	Changes      string `gorm:"type:text"` // 导入写入的数据 JSON（ImportChanges）
+	Snapshot     string `gorm:"type:text"` // 修改前的数据 JSON（ImportSnapshot）
+	RolledBack   uint   `gorm:"not null"`  // 是否已回滚 0：否 1：是
+	RolledBackBy uint   // 回滚操作员 ID
+	RolledBackAt uint   // 回滚时间 UNIX时间戳
+	CreatedAt    uint   `gorm:"index"` // 导入时间 UNIX时间戳
+}
+
+// TableName 返回 ImportBatch 类型的表名。
+func (ImportBatch) TableName() string {
+	return "red_import_batch"
+}
+
+// 导入批次写入的数据
+type ImportChanges struct {
+	CreatedPeopleIds     []uint `json:"created_people_ids"`     // 新增的人员
+	UpdatedPeopleIds     []uint `json:"updated_people_ids"`     // 更新的人员
+	CreatedDepartmentIds []uint `json:"created_department_ids"` // 自动创建的部门
+	CreatedCredentialIds []uint `json:"created_credential_ids"` // 新增的凭证
+	UpdatedCredentialIds []uint `json:"updated_credential_ids"` // 门禁组被替换的已有凭证
+	ValidityPeopleIds    []uint `json:"validity_people_ids"`    // 设置了有效期的人员
+
+	AccessGroupIds map[uint][]uint `json:"access_group_ids"` // 导入为凭证设置的门禁组，回滚时用于检查门禁组是否在导入后被修改
+}
+
+// 导入批次修改前的数据
+type ImportSnapshot struct {
+	People           []People           `json:"people"`            // 被更新人员修改前的数据
+	CredentialAccess []CredentialAccess `json:"credential_access"` // 被替换门禁组的已有凭证修改前的门禁组，停用的凭证为停用期间保存的门禁组
+	PeopleValidity   []PeopleValidity   `json:"people_validity"`   // 被设置有效期的人员修改前的有效期
+}
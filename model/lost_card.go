@@ -0,0 +1,34 @@
+package model
+
+// 挂失原因
+const (
+	LostCardReasonLost   = "lost"   // 遗失
+	LostCardReasonStolen = "stolen" // 被盗
+)
+
+// 挂失卡黑名单，与凭证存放在同一个数据库（DbCredential）
+// 挂失时删除原凭证并保存其门禁组、有效期和停用状态，补发新卡时恢复；黑名单中的卡再次刷卡时发布报警
+// 黑名单中的卡号不能再分配给任何凭证，由凭证表的触发器检查
+type LostCard struct {
+	ID uint `gorm:"primarykey"`
+
+	CardNo         string `gorm:"type:varchar(50);index;not null"` // 卡号
+	PeopleId       uint   `gorm:"index;not null"`                  // 持卡人员
+	UniqueId       uint   `gorm:"not null"`                        // 原凭证，挂失时已删除
+	Reason         string `gorm:"type:varchar(20);not null"`       // 挂失原因 lost：遗失 stolen：被盗
+	Note           string `gorm:"type:varchar(255)"`               // 备注
+	AccessGroups   string `gorm:"type:text"`                       // 原凭证单独分配的门禁组，JSON 数组，不含部门继承的门禁组
+	ValidFrom      uint   `gorm:"not null"`                        // 原凭证的生效时间 UNIX时间戳
+	ValidUntil     uint   `gorm:"not null"`                        // 原凭证的失效时间 UNIX时间戳
+	Disabled       uint   `gorm:"not null;default:0"`              // 原凭证是否停用 0：否 1：是
+	DisabledReason string `gorm:"type:varchar(50)"`                // 原凭证的停用原因
+	ReportedAt     uint   `gorm:"not null"`                        // 挂失时间 UNIX时间戳
+	ReplacedBy     uint   `gorm:"not null"`                        // 补发的凭证，0 表示未补发
+	LastSeenAt     uint   // 挂失后最近一次刷卡时间 UNIX时间戳
+	SeenCount      uint   `gorm:"not null"` // 挂失后刷卡次数
+}
+
+// TableName 返回 LostCard 类型的表名。
+func (LostCard) TableName() string {
+	return "red_lost_card"
+}
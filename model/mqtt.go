@@ -0,0 +1,25 @@
+package model
+
+// MQTT 客户端配置，表中只保存一条记录
+type MqttConfig struct {
+	ID uint `gorm:"primarykey"`
+
+	Enable             uint   `gorm:"not null"`          // 是否启用 0：禁用 1：启用
+	BrokerURL          string `gorm:"type:varchar(255)"` // 服务器地址，如 tcp://192.168.1.10:1883、ssl://broker:8883
+	ClientId           string `gorm:"type:varchar(100)"` // 客户端 ID
+	Username           string `gorm:"type:varchar(100)"` // 用户名
+	Password           string `gorm:"type:varchar(100)"` // 密码
+	TopicPrefix        string `gorm:"type:varchar(100)"` // 主题前缀，如 ownsa/controller1
+	QoS                uint   `gorm:"not null"`          // 发布和订阅使用的 QoS 0/1/2
+	CACert             string `gorm:"type:text"`         // TLS 服务器 CA 证书（PEM），为空时使用系统证书
+	InsecureSkipVerify uint   `gorm:"not null"`          // 是否跳过 TLS 证书校验 0：否 1：是
+	CommandToken       string `gorm:"type:varchar(100)"` // 命令主题的认证令牌，为空时不接受命令
+	PublishEvents      uint   `gorm:"not null"`          // 是否发布事件消息 0：否 1：是
+	PublishStatus      uint   `gorm:"not null"`          // 是否发布设备状态变化 0：否 1：是
+	StatusInterval     uint   `gorm:"not null"`          // 设备状态轮询间隔（秒）
+}
+
+// TableName 返回 MqttConfig 类型的表名。
+func (MqttConfig) TableName() string {
+	return "red_mqtt_config"
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// 区域读卡器方向
+const (
+	AreaDirectionEnter = 1 // 进入区域
+	AreaDirectionExit  = 2 // 离开区域
+)
+
+// 区域，用于人数统计和紧急疏散点名
+type Area struct {
+	gorm.Model
+
+	Name     string `gorm:"type:varchar(50);not null"` // 名称
+	Capacity uint   `gorm:"not null"`                  // 最大容纳人数，0 表示不限制
+}
+
+// TableName 返回 Area 类型的表名。
+func (Area) TableName() string {
+	return "red_area"
+}
+
+// 区域读卡器，同一读卡器可以同时是一个区域的出口和另一个区域的入口
+type AreaReader struct {
+	ID uint `gorm:"primarykey"`
+
+	AreaID     uint `gorm:"uniqueIndex:idx_area_reader;not null"` // 所属区域
+	IBAddr     int  `gorm:"uniqueIndex:idx_area_reader;not null"` // 接口板地址
+	ReaderAddr int  `gorm:"uniqueIndex:idx_area_reader;not null"` // 读卡器地址
+	Direction  uint `gorm:"not null"`                             // 方向 1：进入 2：离开
+}
+
+// TableName 返回 AreaReader 类型的表名。
+func (AreaReader) TableName() string {
+	return "red_area_reader"
+}
+
+// 区域内人员，每人同一时间只在一个区域内，记录进入时的人员信息快照，紧急情况下无需关联其他数据库
+type Occupancy struct {
+	PeopleId uint `gorm:"primarykey;autoIncrement:false"` // 人员
+
+	AreaID     uint   `gorm:"index;not null"`    // 所在区域
+	PeopleCode string `gorm:"type:varchar(50)"`  // 人员编号
+	PeopleName string `gorm:"type:varchar(100)"` // 姓名
+	Department string `gorm:"type:varchar(100)"` // 部门
+	CardNo     string `gorm:"type:varchar(50)"`  // 卡号
+	ReaderName string `gorm:"type:varchar(100)"` // 进入时的读卡器
+	MsgId      uint   // 进入事件的消息 ID
+	EnteredAt  uint   // 进入时间 UNIX时间戳
+}
+
+// TableName 返回 Occupancy 类型的表名。
+func (Occupancy) TableName() string {
+	return "red_occupancy"
+}
@@ -0,0 +1,16 @@
+package model
+
+// 人员照片，文件保存在 web/uploads/people 目录，与人员存放在同一个数据库（DbCredential）
+type PeoplePhoto struct {
+	PeopleId uint `gorm:"primarykey;autoIncrement:false"` // 人员
+
+	Width     uint  // 照片宽度（像素）
+	Height    uint  // 照片高度（像素）
+	Size      int64 `gorm:"not null"` // 照片和缩略图占用的空间（字节）
+	UpdatedAt uint  // 更新时间 UNIX时间戳，用于照片地址去缓存
+}
+
+// TableName 返回 PeoplePhoto 类型的表名。
+func (PeoplePhoto) TableName() string {
+	return "red_people_photo"
+}
@@ -0,0 +1,25 @@
+package model
+
+// 全文检索的对象类型
+const (
+	SearchKindPeople     = "people"     // 人员：姓名、人员编号
+	SearchKindCredential = "credential" // 凭证：卡号
+	SearchKindDepartment = "department" // 部门：部门名称
+)
+
+// 全文检索索引的虚拟表名（SQLite FTS4），与人员存放在同一个数据库（DbCredential）
+const SearchIndexTable = "red_search_index"
+
+// 全文检索索引词表的虚拟表名（fts4aux），用于纠正拼写错误的关键字
+const SearchTermsTable = "red_search_terms"
+
+// 待更新全文检索索引的对象，由人员、凭证和部门表上的触发器写入，查询前增量更新索引后删除
+type SearchDirty struct {
+	Kind string `gorm:"primarykey;type:varchar(20)"`    // 对象类型
+	Ref  uint   `gorm:"primarykey;autoIncrement:false"` // 对象 ID
+}
+
+// TableName 返回 SearchDirty 类型的表名。
+func (SearchDirty) TableName() string {
+	return "red_search_dirty"
+}
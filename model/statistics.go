@@ -0,0 +1,40 @@
+package model
+
+// 事件小时统计，按小时、读卡器、输出（门）、部门和事件类型累计事件数
+// 由后台任务按事件消息 ID 增量汇总，统计查询不再扫描事件消息表
+type EventStatHour struct {
+	ID uint `gorm:"primarykey"`
+
+	Hour       uint   `gorm:"uniqueIndex:idx_event_stat_hour;not null"`                   // 小时起始时间 UNIX时间戳
+	Weekday    uint   `gorm:"not null"`                                                   // 星期 0：周日 1-6：周一至周六（本地时间）
+	HourOfDay  uint   `gorm:"not null"`                                                   // 小时 0-23（本地时间）
+	IBAddr     int    `gorm:"uniqueIndex:idx_event_stat_hour;not null"`                   // 接口板地址
+	ReaderAddr int    `gorm:"uniqueIndex:idx_event_stat_hour;not null"`                   // 读卡器地址
+	OutputAddr int    `gorm:"uniqueIndex:idx_event_stat_hour;not null"`                   // 输出（门）地址
+	Department string `gorm:"uniqueIndex:idx_event_stat_hour;type:varchar(100);not null"` // 部门名称
+	EventType  uint   `gorm:"uniqueIndex:idx_event_stat_hour;not null"`                   // 事件类型
+	IBName     string `gorm:"type:varchar(100)"`                                          // 接口板名称（最近一次）
+	ReaderName string `gorm:"type:varchar(100)"`                                          // 读卡器名称（最近一次）
+	Count      uint   `gorm:"not null"`                                                   // 事件数
+}
+
+// TableName 返回 EventStatHour 类型的表名。
+func (EventStatHour) TableName() string {
+	return "red_event_stat_hour"
+}
+
+// 拒绝通行卡号日统计，用于查询被拒绝次数最多的卡
+type EventStatCard struct {
+	ID uint `gorm:"primarykey"`
+
+	Day        uint   `gorm:"uniqueIndex:idx_event_stat_card;not null"`                  // 日期起始时间 UNIX时间戳（本地时间零点）
+	CardNo     string `gorm:"uniqueIndex:idx_event_stat_card;type:varchar(50);not null"` // 卡号
+	PeopleName string `gorm:"type:varchar(100)"`                                         // 持卡人姓名（最近一次）
+	Count      uint   `gorm:"not null"`                                                  // 拒绝次数
+	LastTime   uint   // 最近一次被拒绝时间 UNIX时间戳
+}
+
+// TableName 返回 EventStatCard 类型的表名。
+func (EventStatCard) TableName() string {
+	return "red_event_stat_card"
+}
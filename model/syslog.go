@@ -0,0 +1,52 @@
+package model
+
+// Syslog 传输协议
+const (
+	SyslogProtocolUDP = "udp"
+	SyslogProtocolTCP = "tcp"
+	SyslogProtocolTLS = "tls"
+)
+
+// Syslog 消息格式
+const (
+	SyslogFormatRFC5424 = "rfc5424" // RFC 5424，消息内容为 JSON
+	SyslogFormatCEF     = "cef"     // RFC 5424 头部 + ArcSight CEF 消息内容
+)
+
+// Syslog 转发配置，表中只保存一条记录
+type SyslogConfig struct {
+	ID uint `gorm:"primarykey"`
+
+	Enable             uint   `gorm:"not null"`          // 是否启用 0：禁用 1：启用
+	Protocol           string `gorm:"type:varchar(10)"`  // 传输协议 udp/tcp/tls
+	Host               string `gorm:"type:varchar(255)"` // 日志服务器地址
+	Port               uint   `gorm:"not null"`          // 日志服务器端口
+	Format             string `gorm:"type:varchar(10)"`  // 消息格式 rfc5424/cef
+	Facility           uint   `gorm:"not null"`          // 设施代码 0-23，默认 16（local0）
+	AppName            string `gorm:"type:varchar(48)"`  // 应用名称
+	Hostname           string `gorm:"type:varchar(255)"` // 主机名，为空时使用系统主机名
+	CACert             string `gorm:"type:text"`         // TLS 服务器 CA 证书（PEM），为空时使用系统证书
+	InsecureSkipVerify uint   `gorm:"not null"`          // 是否跳过 TLS 证书校验 0：否 1：是
+	ForwardAccess      uint   `gorm:"not null"`          // 是否转发通行事件 0：否 1：是
+	ForwardAlarm       uint   `gorm:"not null"`          // 是否转发报警事件 0：否 1：是
+	ForwardAudit       uint   `gorm:"not null"`          // 是否转发操作审计 0：否 1：是
+	ForwardSystem      uint   `gorm:"not null"`          // 是否转发系统事件 0：否 1：是
+}
+
+// TableName 返回 SyslogConfig 类型的表名。
+func (SyslogConfig) TableName() string {
+	return "red_syslog_config"
+}
+
+// Syslog 待发送队列，日志服务器不可达时消息保存在本地，恢复后按顺序补发
+type SyslogQueue struct {
+	ID uint `gorm:"primarykey"`
+
+	Message   string `gorm:"type:text"` // 已格式化的 syslog 消息
+	CreatedAt uint   // 入队时间 UNIX时间戳
+}
+
+// TableName 返回 SyslogQueue 类型的表名。
+func (SyslogQueue) TableName() string {
+	return "red_syslog_queue"
+}
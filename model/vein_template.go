@@ -0,0 +1,19 @@
+package model
+
+// 指静脉模板，与人员存放在同一个数据库（DbCredential）
+// 模板使用 AES-256-GCM 加密保存，Template 为随机数与密文的拼接
+type VeinTemplate struct {
+	ID uint `gorm:"primarykey"`
+
+	PeopleId    uint   `gorm:"uniqueIndex:idx_vein_template;not null"` // 人员
+	FingerIndex uint   `gorm:"uniqueIndex:idx_vein_template;not null"` // 手指序号 0-9
+	Template    []byte `gorm:"not null"`                               // 加密后的模板
+	Size        uint   `gorm:"not null"`                               // 模板原始大小（字节）
+	Checksum    string `gorm:"type:varchar(64);index;not null"`        // 模板原文的 SHA-256，用于导出校验和查重
+	UpdatedAt   uint   // 更新时间 UNIX时间戳
+}
+
+// TableName 返回 VeinTemplate 类型的表名。
+func (VeinTemplate) TableName() string {
+	return "red_vein_template"
+}
@@ -0,0 +1,37 @@
+package model
+
+// 访客状态
+const (
+	VisitorStatusRegistered = 0 // 已预约，未签到
+	VisitorStatusCheckedIn  = 1 // 已签到
+	VisitorStatusCheckedOut = 2 // 已签离
+	VisitorStatusExpired    = 3 // 超过预约时间自动注销
+	VisitorStatusCancelled  = 4 // 已取消预约
+)
+
+// 访客，与人员、凭证存放在同一个数据库（DbCredential）
+// 预约时为访客创建人员和临时凭证，凭证有效期为预约时间段，签离、取消或过期时删除人员和凭证，访客记录保留作为访客日志
+type Visitor struct {
+	ID uint `gorm:"primarykey"`
+
+	Name           string `gorm:"type:varchar(50);not null"` // 姓名
+	Company        string `gorm:"type:varchar(100)"`         // 单位
+	Phone          string `gorm:"type:varchar(30)"`          // 电话
+	HostPeopleId   uint   `gorm:"index"`                     // 被访人
+	Purpose        string `gorm:"type:varchar(255)"`         // 来访事由
+	ExpectedFrom   uint   // 预约开始时间 UNIX时间戳
+	ExpectedUntil  uint   // 预约结束时间 UNIX时间戳，临时凭证到期失效
+	CardNo         string `gorm:"type:varchar(50)"`  // 临时卡号或密码
+	AccessGroupIds string `gorm:"type:varchar(255)"` // 允许通行的门禁组，逗号分隔
+	PeopleId       uint   // 为访客创建的人员，注销后为 0
+	UniqueId       uint   // 临时凭证，注销后为 0
+	Status         uint   `gorm:"index;not null"` // 状态
+	CheckedInAt    uint   // 签到时间 UNIX时间戳
+	CheckedOutAt   uint   // 签离或注销时间 UNIX时间戳
+	CreatedAt      uint   `gorm:"index"` // 预约时间 UNIX时间戳
+}
+
+// TableName 返回 Visitor 类型的表名。
+func (Visitor) TableName() string {
+	return "red_visitor"
+}
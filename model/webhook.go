@@ -0,0 +1,52 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending = iota // 0：等待投递
+	WebhookDeliverySuccess = iota // 1：投递成功
+	WebhookDeliveryFailed  = iota // 2：重试耗尽，投递失败
+)
+
+// 出站 Webhook 订阅
+type Webhook struct {
+	gorm.Model
+
+	Name       string `gorm:"type:varchar(50);not null"`  // 名称
+	URL        string `gorm:"type:varchar(255);not null"` // 推送地址
+	Secret     string `gorm:"type:varchar(100)"`          // HMAC-SHA256 签名密钥，为空时不签名
+	Categories string `gorm:"type:varchar(100)"`          // 订阅的事件类别，逗号分隔（access,alarm,system），为空表示全部
+	EventTypes string `gorm:"type:varchar(100)"`          // 订阅的事件类型，逗号分隔，为空表示全部
+	Enable     uint   `gorm:"not null"`                   // 是否启用 0：禁用 1：启用
+	MaxRetries uint   `gorm:"not null"`                   // 最大重试次数
+	Timeout    uint   `gorm:"not null"`                   // 请求超时（秒）
+}
+
+// TableName 返回 Webhook 类型的表名。
+func (Webhook) TableName() string {
+	return "red_webhook"
+}
+
+// Webhook 投递记录，同时作为持久化的投递队列，设备重启后继续重试
+type WebhookDelivery struct {
+	gorm.Model
+
+	WebhookID   uint   `gorm:"index;not null"`   // 所属 Webhook
+	Topic       string `gorm:"type:varchar(50)"` // 事件主题
+	MsgId       uint   // 关联的事件消息 ID，非事件消息时为 0
+	Payload     string `gorm:"type:text"`      // 推送的 JSON 内容
+	Status      uint   `gorm:"index;not null"` // 状态 0：等待投递 1：成功 2：失败
+	Attempts    uint   `gorm:"not null"`       // 已尝试次数
+	NextAttempt uint   `gorm:"index"`          // 下次尝试时间 UNIX时间戳
+	StatusCode  int    // 最后一次响应状态码
+	LastError   string `gorm:"type:varchar(255)"` // 最后一次错误信息
+	DeliveredAt uint   // 投递成功时间 UNIX时间戳
+}
+
+// TableName 返回 WebhookDelivery 类型的表名。
+func (WebhookDelivery) TableName() string {
+	return "red_webhook_delivery"
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// ShiftRepository 考勤班次的数据访问接口
+type ShiftRepository interface {
+	Save(shift model.Shift) (*model.Shift, error)
+	Update(shift model.Shift) error
+	Delete(id uint)
+	FindById(id uint) (*model.Shift, error)
+	FindAll() []*model.Shift
+}
+
+// ShiftRepositoryImpl 考勤班次的数据访问实现
+type ShiftRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewShiftRepositoryImpl 创建并返回一个新的 ShiftRepositoryImpl 实例
+func NewShiftRepositoryImpl(Db *gorm.DB) ShiftRepository {
+	return &ShiftRepositoryImpl{Db: Db}
+}
+
+// Save 新增班次
+func (r *ShiftRepositoryImpl) Save(shift model.Shift) (*model.Shift, error) {
+	result := r.Db.Create(&shift)
+	return &shift, result.Error
+}
+
+// Update 更新班次
+func (r *ShiftRepositoryImpl) Update(shift model.Shift) error {
+	result := r.Db.Save(&shift)
+	return result.Error
+}
+
+// Delete 删除班次及其分配
+func (r *ShiftRepositoryImpl) Delete(id uint) {
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shift_id = ?", id).Delete(&model.ShiftAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Shift{}, id).Error
+	})
+	utils.ErrorPanic(err)
+}
+
+// FindById 根据 ID 查询班次
+func (r *ShiftRepositoryImpl) FindById(id uint) (*model.Shift, error) {
+	var shift model.Shift
+	result := r.Db.First(&shift, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &shift, nil
+}
+
+// FindAll 查询所有班次
+func (r *ShiftRepositoryImpl) FindAll() []*model.Shift {
+	var shifts []*model.Shift
+	result := r.Db.Order("id").Find(&shifts)
+	utils.ErrorPanic(result.Error)
+	return shifts
+}
+
+// ShiftAssignmentRepository 班次分配的数据访问接口
+type ShiftAssignmentRepository interface {
+	Save(assignment model.ShiftAssignment) (*model.ShiftAssignment, error)
+	Delete(id uint)
+	FindAll() []*model.ShiftAssignment
+}
+
+// ShiftAssignmentRepositoryImpl 班次分配的数据访问实现
+type ShiftAssignmentRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewShiftAssignmentRepositoryImpl 创建并返回一个新的 ShiftAssignmentRepositoryImpl 实例
+func NewShiftAssignmentRepositoryImpl(Db *gorm.DB) ShiftAssignmentRepository {
+	return &ShiftAssignmentRepositoryImpl{Db: Db}
+}
+
+// Save 分配班次，同一人员或部门已有分配时替换为新的班次
+func (r *ShiftAssignmentRepositoryImpl) Save(assignment model.ShiftAssignment) (*model.ShiftAssignment, error) {
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("people_id = ? AND department_id = ?", assignment.PeopleId, assignment.DepartmentId).
+			Delete(&model.ShiftAssignment{})
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Create(&assignment).Error
+	})
+	return &assignment, err
+}
+
+// Delete 删除班次分配
+func (r *ShiftAssignmentRepositoryImpl) Delete(id uint) {
+	result := r.Db.Delete(&model.ShiftAssignment{}, id)
+	utils.ErrorPanic(result.Error)
+}
+
+// FindAll 查询所有班次分配
+func (r *ShiftAssignmentRepositoryImpl) FindAll() []*model.ShiftAssignment {
+	var assignments []*model.ShiftAssignment
+	result := r.Db.Order("id").Find(&assignments)
+	utils.ErrorPanic(result.Error)
+	return assignments
+}
+
+// AttendanceReaderRepository 考勤读卡器的数据访问接口
+type AttendanceReaderRepository interface {
+	FindAll() []*model.AttendanceReader
+	Replace(readers []model.AttendanceReader) error
+}
+
+// AttendanceReaderRepositoryImpl 考勤读卡器的数据访问实现
+type AttendanceReaderRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewAttendanceReaderRepositoryImpl 创建并返回一个新的 AttendanceReaderRepositoryImpl 实例
+func NewAttendanceReaderRepositoryImpl(Db *gorm.DB) AttendanceReaderRepository {
+	return &AttendanceReaderRepositoryImpl{Db: Db}
+}
+
+// FindAll 查询所有考勤读卡器
+func (r *AttendanceReaderRepositoryImpl) FindAll() []*model.AttendanceReader {
+	var readers []*model.AttendanceReader
+	result := r.Db.Order("ib_addr, reader_addr").Find(&readers)
+	utils.ErrorPanic(result.Error)
+	return readers
+}
+
+// Replace 替换全部考勤读卡器
+func (r *AttendanceReaderRepositoryImpl) Replace(readers []model.AttendanceReader) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&model.AttendanceReader{}).Error; err != nil {
+			return err
+		}
+		if len(readers) == 0 {
+			return nil
+		}
+		return tx.Create(&readers).Error
+	})
+}
+
+// AttendanceRecordRepository 每日考勤结果的数据访问接口
+type AttendanceRecordRepository interface {
+	SaveDay(date string, records []*model.AttendanceRecord) error
+	FindByDateRange(from string, to string, peopleId uint) []*model.AttendanceRecord
+	FindEvents(start string, end string) []*model.EventMessageData
+}
+
+// AttendanceRecordRepositoryImpl 每日考勤结果的数据访问实现
+type AttendanceRecordRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewAttendanceRecordRepositoryImpl 创建并返回一个新的 AttendanceRecordRepositoryImpl 实例
+func NewAttendanceRecordRepositoryImpl(Db *gorm.DB) AttendanceRecordRepository {
+	return &AttendanceRecordRepositoryImpl{Db: Db}
+}
+
+// SaveDay 替换指定日期的全部考勤结果
+func (r *AttendanceRecordRepositoryImpl) SaveDay(date string, records []*model.AttendanceRecord) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date = ?", date).Delete(&model.AttendanceRecord{}).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(records, 200).Error
+	})
+}
+
+// FindByDateRange 查询日期范围内（包含首尾）的考勤结果，peopleId 为 0 时查询所有人员
+func (r *AttendanceRecordRepositoryImpl) FindByDateRange(from string, to string, peopleId uint) []*model.AttendanceRecord {
+	var records []*model.AttendanceRecord
+	query := r.Db.Where("date >= ? AND date <= ?", from, to)
+	if peopleId > 0 {
+		query = query.Where("people_id = ?", peopleId)
+	}
+	result := query.Order("date, people_id").Find(&records)
+	utils.ErrorPanic(result.Error)
+	return records
+}
+
+// FindEvents 查询时间段内的事件消息，时间格式为 "2006-01-02 15:04:05"，包含开始、不包含结束
+func (r *AttendanceRecordRepositoryImpl) FindEvents(start string, end string) []*model.EventMessageData {
+	var events []*model.EventMessageData
+	result := r.Db.Where("accesstime >= ? AND accesstime < ?", start, end).Order("accesstime").Find(&events)
+	utils.ErrorPanic(result.Error)
+	return events
+}
+
+// AttendanceCorrectionRepository 考勤人工更正的数据访问接口
+type AttendanceCorrectionRepository interface {
+	Save(correction model.AttendanceCorrection) (*model.AttendanceCorrection, error)
+	FindByDateRange(from string, to string, peopleId uint) []*model.AttendanceCorrection
+}
+
+// AttendanceCorrectionRepositoryImpl 考勤人工更正的数据访问实现
+type AttendanceCorrectionRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewAttendanceCorrectionRepositoryImpl 创建并返回一个新的 AttendanceCorrectionRepositoryImpl 实例
+func NewAttendanceCorrectionRepositoryImpl(Db *gorm.DB) AttendanceCorrectionRepository {
+	return &AttendanceCorrectionRepositoryImpl{Db: Db}
+}
+
+// Save 新增更正记录
+func (r *AttendanceCorrectionRepositoryImpl) Save(correction model.AttendanceCorrection) (*model.AttendanceCorrection, error) {
+	result := r.Db.Create(&correction)
+	return &correction, result.Error
+}
+
+// FindByDateRange 查询日期范围内（包含首尾）的更正记录，按创建顺序排列，peopleId 为 0 时查询所有人员
+func (r *AttendanceCorrectionRepositoryImpl) FindByDateRange(from string, to string, peopleId uint) []*model.AttendanceCorrection {
+	var corrections []*model.AttendanceCorrection
+	query := r.Db.Where("date >= ? AND date <= ?", from, to)
+	if peopleId > 0 {
+		query = query.Where("people_id = ?", peopleId)
+	}
+	result := query.Order("id").Find(&corrections)
+	utils.ErrorPanic(result.Error)
+	return corrections
+}
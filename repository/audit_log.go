@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// AuditLogRepository 操作审计日志的数据访问接口
+type AuditLogRepository interface {
+	Save(auditLog model.AuditLog) (*model.AuditLog, error)
+	FindAll(pg *utils.Pagination) []*model.AuditLog
+}
+
+// AuditLogRepositoryImpl 操作审计日志的数据访问实现
+type AuditLogRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewAuditLogRepositoryImpl 创建并返回一个新的 AuditLogRepositoryImpl 实例
+func NewAuditLogRepositoryImpl(Db *gorm.DB) AuditLogRepository {
+	return &AuditLogRepositoryImpl{Db: Db}
+}
+
+// Save 新增操作审计日志
+func (r *AuditLogRepositoryImpl) Save(auditLog model.AuditLog) (*model.AuditLog, error) {
+	result := r.Db.Create(&auditLog)
+	return &auditLog, result.Error
+}
+
+// FindAll 分页查询操作审计日志，按时间倒序
+func (r *AuditLogRepositoryImpl) FindAll(pg *utils.Pagination) []*model.AuditLog {
+	var auditLogs []*model.AuditLog
+	result := r.Db.Order("id desc").Scopes(pg.Paginate()).Find(&auditLogs)
+	utils.ErrorPanic(result.Error)
+	return auditLogs
+}
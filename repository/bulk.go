@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+)
+
+// 批量操作类型
+const (
+	BulkActionAssignAccessGroup = "assign_access_group" // 添加门禁组
+	BulkActionRemoveAccessGroup = "remove_access_group" // 移除门禁组
+	BulkActionMoveDepartment    = "move_department"     // 调整部门（仅人员）
+	BulkActionEnable            = "enable"              // 启用凭证
+	BulkActionDisable           = "disable"             // 停用凭证
+	BulkActionDelete            = "delete"              // 删除
+	BulkActionExtendValidity    = "extend_validity"     // 设置失效时间（人员操作设置人员有效期）
+)
+
+// BulkFilter 批量操作的筛选条件，多个条件同时满足
+type BulkFilter struct {
+	DepartmentId  uint   // 部门
+	AccessGroupId uint   // 门禁组
+	Keyword       string // 人员编号或姓名包含的关键字
+}
+
+// BulkOperation 批量操作
+type BulkOperation struct {
+	Action         string // 操作类型
+	AccessGroupIds []uint // 添加或移除的门禁组
+	DepartmentId   uint   // 调整到的部门，0 表示不属于任何部门
+	ValidUntil     uint   // 新的失效时间 UNIX时间戳
+	Reason         string // 停用原因，为空时为人工停用
+}
+
+// BulkItemResult 单个对象的操作结果
+type BulkItemResult struct {
+	ID      uint
+	Success bool
+	Message string
+}
+
+// BulkRepository 人员和凭证批量操作的数据访问接口
+type BulkRepository interface {
+	FindPeopleIds(filter BulkFilter) ([]uint, error)
+	FindCredentialIds(filter BulkFilter) ([]uint, error)
+	ApplyPeople(peopleIds []uint, operation BulkOperation, atomic bool) ([]BulkItemResult, error)
+	ApplyCredentials(uniqueIds []uint, operation BulkOperation, atomic bool) ([]BulkItemResult, error)
+	SuspendAccess(uniqueId uint) (bool, error)
+}
+
+// BulkRepositoryImpl 人员和凭证批量操作的数据访问实现
+type BulkRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewBulkRepositoryImpl 创建并返回一个新的 BulkRepositoryImpl 实例
+func NewBulkRepositoryImpl(Db *gorm.DB) BulkRepository {
+	return &BulkRepositoryImpl{Db: Db}
+}
+
+// FindPeopleIds 查询满足筛选条件的人员
+func (r *BulkRepositoryImpl) FindPeopleIds(filter BulkFilter) ([]uint, error) {
+	var peopleIds []uint
+	err := r.peopleQuery(filter).Order("id").Pluck("id", &peopleIds).Error
+	return peopleIds, err
+}
+
+// FindCredentialIds 查询满足筛选条件的人员的凭证
+func (r *BulkRepositoryImpl) FindCredentialIds(filter BulkFilter) ([]uint, error) {
+	var uniqueIds []uint
+	err := r.Db.Model(&model.Credential{}).
+		Where("people_id IN (?)", r.peopleQuery(filter).Select("id")).
+		Order("unique_id").
+		Pluck("unique_id", &uniqueIds).Error
+	return uniqueIds, err
+}
+
+// peopleQuery 按筛选条件查询人员
+func (r *BulkRepositoryImpl) peopleQuery(filter BulkFilter) *gorm.DB {
+	query := r.Db.Model(&model.People{})
+	if filter.DepartmentId > 0 {
+		query = query.Where("department_id = ?", filter.DepartmentId)
+	}
+	if filter.AccessGroupId > 0 {
+		accessQuery := r.Db.Model(&model.CredentialAccess{}).Select("unique_id").Where("access_group_id = ?", filter.AccessGroupId)
+		credentialQuery := r.Db.Model(&model.Credential{}).Select("people_id").Where("unique_id IN (?)", accessQuery)
+		query = query.Where("id IN (?)", credentialQuery)
+	}
+	if filter.Keyword != "" {
+		keyword := "%" + filter.Keyword + "%"
+		query = query.Where("(people_code LIKE ? OR first_name LIKE ? OR last_name LIKE ?)", keyword, keyword, keyword)
+	}
+	return query
+}
+
+// ApplyPeople 在一个事务中对人员执行批量操作，凭证类操作作用于人员的所有凭证
+// 每个人员使用独立的保存点，atomic 为 true 时任一人员失败则全部回滚
+func (r *BulkRepositoryImpl) ApplyPeople(peopleIds []uint, operation BulkOperation, atomic bool) ([]BulkItemResult, error) {
+	return r.apply(peopleIds, atomic, func(tx *gorm.DB, peopleId uint) (string, error) {
+		var people model.People
+		if err := tx.First(&people, peopleId).Error; err != nil {
+			return "", err
+		}
+
+		if operation.Action == BulkActionMoveDepartment {
+			return "", tx.Model(&people).Update("department_id", operation.DepartmentId).Error
+		}
+
+		if operation.Action == BulkActionExtendValidity {
+			validity, err := findPeopleValidity(tx, peopleId)
+			if err != nil {
+				return "", err
+			}
+			_, err = setPeopleValidity(tx, peopleId, validity.ValidFrom, operation.ValidUntil)
+			return "", err
+		}
+
+		if operation.Action == BulkActionDelete {
+			return "", deletePeople(tx, peopleId)
+		}
+
+		uniqueIds, err := peopleCredentialIds(tx, peopleId)
+		if err != nil {
+			return "", err
+		}
+
+		if len(uniqueIds) == 0 {
+			return "no credentials", nil
+		}
+		changed := 0
+		for _, uniqueId := range uniqueIds {
+			ok, err := applyCredentialOperation(tx, uniqueId, operation)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				changed++
+			}
+		}
+		if changed == 0 {
+			return "unchanged", nil
+		}
+		return "", nil
+	})
+}
+
+// ApplyCredentials 在一个事务中对凭证执行批量操作
+// 每个凭证使用独立的保存点，atomic 为 true 时任一凭证失败则全部回滚
+func (r *BulkRepositoryImpl) ApplyCredentials(uniqueIds []uint, operation BulkOperation, atomic bool) ([]BulkItemResult, error) {
+	return r.apply(uniqueIds, atomic, func(tx *gorm.DB, uniqueId uint) (string, error) {
+		var credential model.Credential
+		if err := tx.First(&credential, uniqueId).Error; err != nil {
+			return "", err
+		}
+
+		if operation.Action == BulkActionDelete {
+			return "", deleteCredential(tx, uniqueId)
+		}
+
+		ok, err := applyCredentialOperation(tx, uniqueId, operation)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "unchanged", nil
+		}
+		return "", nil
+	})
+}
+
+// SuspendAccess 凭证已停用时将直接写入的门禁组移到停用期间保存的门禁组，返回凭证是否已停用
+func (r *BulkRepositoryImpl) SuspendAccess(uniqueId uint) (bool, error) {
+	var suspended bool
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		suspended, err = suspendCredentialAccess(tx, uniqueId)
+		return err
+	})
+	return suspended, err
+}
+
+// apply 在一个事务中逐个执行操作并记录结果
+func (r *BulkRepositoryImpl) apply(ids []uint, atomic bool, fn func(tx *gorm.DB, id uint) (string, error)) ([]BulkItemResult, error) {
+	results := make([]BulkItemResult, 0, len(ids))
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			var message string
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				var err error
+				message, err = fn(itemTx, id)
+				return err
+			})
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					err = errors.New("not found")
+				}
+				results = append(results, BulkItemResult{ID: id, Success: false, Message: err.Error()})
+				if atomic {
+					return fmt.Errorf("id %d: %w", id, err)
+				}
+				continue
+			}
+			results = append(results, BulkItemResult{ID: id, Success: true, Message: message})
+		}
+		return nil
+	})
+	return results, err
+}
+
+// applyCredentialOperation 对单个凭证执行门禁组、启用停用或有效期操作，返回是否有变化
+func applyCredentialOperation(tx *gorm.DB, uniqueId uint, operation BulkOperation) (bool, error) {
+	switch operation.Action {
+	case BulkActionAssignAccessGroup:
+		return true, addCredentialAccess(tx, uniqueId, operation.AccessGroupIds)
+	case BulkActionRemoveAccessGroup:
+		return true, removeCredentialAccess(tx, uniqueId, operation.AccessGroupIds)
+	case BulkActionEnable:
+		return enableCredential(tx, uniqueId)
+	case BulkActionDisable:
+		reason := operation.Reason
+		if reason == "" {
+			reason = model.CredentialDisabledManual
+		}
+		return disableCredential(tx, uniqueId, reason)
+	case BulkActionExtendValidity:
+		state, err := findCredentialState(tx, uniqueId)
+		if err != nil {
+			return false, err
+		}
+		if _, err := setCredentialValidity(tx, uniqueId, state.ValidFrom, operation.ValidUntil); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, errors.New("unsupported bulk action: " + operation.Action)
+	}
+}
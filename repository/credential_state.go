@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// CredentialStateRepository 凭证状态的数据访问接口
+type CredentialStateRepository interface {
+	FindByIds(uniqueIds []uint) []*model.CredentialState
+}
+
+// CredentialStateRepositoryImpl 凭证状态的数据访问实现
+type CredentialStateRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewCredentialStateRepositoryImpl 创建并返回一个新的 CredentialStateRepositoryImpl 实例
+func NewCredentialStateRepositoryImpl(Db *gorm.DB) CredentialStateRepository {
+	return &CredentialStateRepositoryImpl{Db: Db}
+}
+
+// FindByIds 根据凭证 ID 列表查询凭证状态，未停用过的凭证没有记录
+func (r *CredentialStateRepositoryImpl) FindByIds(uniqueIds []uint) []*model.CredentialState {
+	var states []*model.CredentialState
+	if len(uniqueIds) == 0 {
+		return states
+	}
+	result := r.Db.Where("unique_id IN ?", uniqueIds).Find(&states)
+	utils.ErrorPanic(result.Error)
+	return states
+}
+
+// findCredentialState 查询凭证状态，不存在时返回启用状态
+func findCredentialState(tx *gorm.DB, uniqueId uint) (*model.CredentialState, error) {
+	state := model.CredentialState{UniqueId: uniqueId}
+	err := tx.First(&state, uniqueId).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// saveCredentialState 保存凭证状态
+func saveCredentialState(tx *gorm.DB, state *model.CredentialState, suspended []uint) error {
+	data, err := json.Marshal(suspended)
+	if err != nil {
+		return err
+	}
+	state.SuspendedAccess = string(data)
+	state.UpdatedAt = uint(time.Now().Unix())
+	return tx.Save(state).Error
+}
+
+// suspendedAccess 返回停用期间保存的门禁组
+func suspendedAccess(state *model.CredentialState) ([]uint, error) {
+	var groupIds []uint
+	if state.SuspendedAccess == "" {
+		return groupIds, nil
+	}
+	err := json.Unmarshal([]byte(state.SuspendedAccess), &groupIds)
+	return groupIds, err
+}
+
+// credentialAccessGroupIds 查询凭证当前的门禁组
+func credentialAccessGroupIds(tx *gorm.DB, uniqueId uint) ([]uint, error) {
+	var groupIds []uint
+	err := tx.Model(&model.CredentialAccess{}).Where("unique_id = ?", uniqueId).Order("id").Pluck("access_group_id", &groupIds).Error
+	return groupIds, err
+}
+
+// disableCredential 停用凭证：保存并删除其门禁组，已停用时返回 false
+func disableCredential(tx *gorm.DB, uniqueId uint, reason string) (bool, error) {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	if state.Disabled == 1 {
+		return false, nil
+	}
+
+	groupIds, err := credentialAccessGroupIds(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Where("unique_id = ?", uniqueId).Delete(&model.CredentialAccess{}).Error; err != nil {
+		return false, err
+	}
+
+	state.Disabled = 1
+	state.DisabledReason = reason
+	return true, saveCredentialState(tx, state, groupIds)
+}
+
+// enableCredential 启用凭证：恢复停用期间保存的门禁组，未停用时返回 false
+func enableCredential(tx *gorm.DB, uniqueId uint) (bool, error) {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	if state.Disabled == 0 {
+		return false, nil
+	}
+
+	groupIds, err := suspendedAccess(state)
+	if err != nil {
+		return false, err
+	}
+	for _, groupId := range groupIds {
+		if err := tx.Create(&model.CredentialAccess{UniqueId: uniqueId, AccessGroupId: groupId}).Error; err != nil {
+			return false, err
+		}
+	}
+
+	state.Disabled = 0
+	state.DisabledReason = ""
+	return true, saveCredentialState(tx, state, nil)
+}
+
+// addCredentialAccess 为凭证添加门禁组，已有的门禁组忽略；凭证停用时添加到保存的门禁组
+func addCredentialAccess(tx *gorm.DB, uniqueId uint, groupIds []uint) error {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return err
+	}
+
+	if state.Disabled == 1 {
+		suspended, err := suspendedAccess(state)
+		if err != nil {
+			return err
+		}
+		for _, groupId := range groupIds {
+			if !slices.Contains(suspended, groupId) {
+				suspended = append(suspended, groupId)
+			}
+		}
+		return saveCredentialState(tx, state, suspended)
+	}
+
+	existing, err := credentialAccessGroupIds(tx, uniqueId)
+	if err != nil {
+		return err
+	}
+	for _, groupId := range groupIds {
+		if slices.Contains(existing, groupId) {
+			continue
+		}
+		if err := tx.Create(&model.CredentialAccess{UniqueId: uniqueId, AccessGroupId: groupId}).Error; err != nil {
+			return err
+		}
+		existing = append(existing, groupId)
+	}
+	return nil
+}
+
+// removeCredentialAccess 移除凭证的门禁组；凭证停用时从保存的门禁组中移除
+func removeCredentialAccess(tx *gorm.DB, uniqueId uint, groupIds []uint) error {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return err
+	}
+
+	if state.Disabled == 1 {
+		suspended, err := suspendedAccess(state)
+		if err != nil {
+			return err
+		}
+		suspended = slices.DeleteFunc(suspended, func(groupId uint) bool {
+			return slices.Contains(groupIds, groupId)
+		})
+		return saveCredentialState(tx, state, suspended)
+	}
+
+	return tx.Where("unique_id = ? AND access_group_id IN ?", uniqueId, groupIds).Delete(&model.CredentialAccess{}).Error
+}
+
+// replaceCredentialAccess 将凭证的门禁组替换为 groupIds；凭证停用时替换保存的门禁组
+func replaceCredentialAccess(tx *gorm.DB, uniqueId uint, groupIds []uint) error {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return err
+	}
+	var current []uint
+	if state.Disabled == 1 {
+		current, err = suspendedAccess(state)
+	} else {
+		current, err = credentialAccessGroupIds(tx, uniqueId)
+	}
+	if err != nil {
+		return err
+	}
+
+	if removed := withoutGroupIds(current, groupIds); len(removed) > 0 {
+		if err := removeCredentialAccess(tx, uniqueId, removed); err != nil {
+			return err
+		}
+	}
+	return addCredentialAccess(tx, uniqueId, groupIds)
+}
+
+// suspendCredentialAccess 凭证停用时将直接写入的门禁组替换保存的门禁组并删除，凭证未停用时返回 false
+// 用于其他模块不经过 addCredentialAccess 写入门禁组之后，保证停用的凭证没有门禁组
+func suspendCredentialAccess(tx *gorm.DB, uniqueId uint) (bool, error) {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	if state.Disabled == 0 {
+		return false, nil
+	}
+
+	groupIds, err := credentialAccessGroupIds(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Where("unique_id = ?", uniqueId).Delete(&model.CredentialAccess{}).Error; err != nil {
+		return false, err
+	}
+	return true, saveCredentialState(tx, state, groupIds)
+}
+
+// deleteCredential 删除凭证及其门禁组、继承记录和状态
+// 使用情况记录在事件消息数据库中，由使用情况跟踪任务清理
+func deleteCredential(tx *gorm.DB, uniqueId uint) error {
+	if err := tx.Where("unique_id = ?", uniqueId).Delete(&model.CredentialAccess{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("unique_id = ?", uniqueId).Delete(&model.DepartmentAccessGrant{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&model.CredentialState{}, uniqueId).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&model.Credential{}, uniqueId).Error
+}
+
+// deletePeople 删除人员及其所有凭证、指静脉模板和有效期
+// 照片文件不在数据库中，照片记录保留到照片服务删除文件时一并删除
+func deletePeople(tx *gorm.DB, peopleId uint) error {
+	uniqueIds, err := peopleCredentialIds(tx, peopleId)
+	if err != nil {
+		return err
+	}
+	for _, uniqueId := range uniqueIds {
+		if err := deleteCredential(tx, uniqueId); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("people_id = ?", peopleId).Delete(&model.VeinTemplate{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("people_id = ?", peopleId).Delete(&model.VeinData{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&model.PeopleValidity{}, peopleId).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&model.People{}, peopleId).Error
+}
+
+// credentialAssignedGroupIds 查询直接分配给凭证的门禁组（包括停用期间保存的门禁组，不包括部门继承的门禁组），按 ID 排序
+func credentialAssignedGroupIds(tx *gorm.DB, uniqueId uint) ([]uint, error) {
+	groupIds, err := credentialAccessGroupIds(tx, uniqueId)
+	if err != nil {
+		return nil, err
+	}
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return nil, err
+	}
+	suspended, err := suspendedAccess(state)
+	if err != nil {
+		return nil, err
+	}
+	inherited, err := credentialInheritedGroupIds(tx, uniqueId)
+	if err != nil {
+		return nil, err
+	}
+	return withoutGroupIds(append(groupIds, suspended...), inherited), nil
+}
+
+// credentialInheritedGroupIds 查询因部门继承而为凭证添加且未被单独移除的门禁组
+func credentialInheritedGroupIds(tx *gorm.DB, uniqueId uint) ([]uint, error) {
+	var groupIds []uint
+	err := tx.Model(&model.DepartmentAccessGrant{}).Where("unique_id = ? AND removed = 0", uniqueId).Pluck("access_group_id", &groupIds).Error
+	return groupIds, err
+}
+
+// withoutGroupIds 返回 groupIds 中不在 excluded 中的门禁组，去重并按 ID 排序
+func withoutGroupIds(groupIds []uint, excluded []uint) []uint {
+	result := []uint{}
+	for _, groupId := range groupIds {
+		if !slices.Contains(excluded, groupId) && !slices.Contains(result, groupId) {
+			result = append(result, groupId)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// peopleCredentialIds 查询人员的所有凭证
+func peopleCredentialIds(tx *gorm.DB, peopleId uint) ([]uint, error) {
+	var uniqueIds []uint
+	err := tx.Model(&model.Credential{}).Where("people_id = ?", peopleId).Order("unique_id").Pluck("unique_id", &uniqueIds).Error
+	return uniqueIds, err
+}
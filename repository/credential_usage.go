@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// CredentialUsageRepository 凭证使用情况的数据访问接口
+type CredentialUsageRepository interface {
+	FindAll() []*model.CredentialUsage
+	Apply(usages []*model.CredentialUsage, cursorName string, lastMsgId uint) error
+	Track(uniqueIds []uint, removedIds []uint, now uint) error
+}
+
+// CredentialUsageRepositoryImpl 凭证使用情况的数据访问实现
+type CredentialUsageRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewCredentialUsageRepositoryImpl 创建并返回一个新的 CredentialUsageRepositoryImpl 实例
+func NewCredentialUsageRepositoryImpl(Db *gorm.DB) CredentialUsageRepository {
+	return &CredentialUsageRepositoryImpl{Db: Db}
+}
+
+// FindAll 查询所有凭证的使用情况
+func (r *CredentialUsageRepositoryImpl) FindAll() []*model.CredentialUsage {
+	var usages []*model.CredentialUsage
+	result := r.Db.Order("unique_id").Find(&usages)
+	utils.ErrorPanic(result.Error)
+	return usages
+}
+
+// Apply 在同一事务中累加一批使用记录并保存游标位置，保证事件不会被重复统计
+// 最近一次通行的门只在通行时间不早于已有记录时更新
+func (r *CredentialUsageRepositoryImpl) Apply(usages []*model.CredentialUsage, cursorName string, lastMsgId uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		newer := "excluded.last_used_at >= red_credential_usage.last_used_at"
+		latest := func(column string) clause.Expr {
+			return gorm.Expr("CASE WHEN " + newer + " THEN excluded." + column + " ELSE red_credential_usage." + column + " END")
+		}
+		for _, usage := range usages {
+			result := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "unique_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"use_count":     gorm.Expr("red_credential_usage.use_count + ?", usage.UseCount),
+					"last_used_at":  gorm.Expr("MAX(red_credential_usage.last_used_at, excluded.last_used_at)"),
+					"ib_addr":       latest("ib_addr"),
+					"reader_addr":   latest("reader_addr"),
+					"output_addr":   latest("output_addr"),
+					"ib_name":       latest("ib_name"),
+					"reader_name":   latest("reader_name"),
+					"tracked_since": gorm.Expr("MIN(red_credential_usage.tracked_since, excluded.tracked_since)"),
+				}),
+			}).Create(usage)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return NewEventCursorRepositoryImpl(tx).Save(cursorName, lastMsgId)
+	})
+}
+
+// Track 为尚无记录的凭证从 now 开始跟踪，并删除已删除凭证的记录
+func (r *CredentialUsageRepositoryImpl) Track(uniqueIds []uint, removedIds []uint, now uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		for _, uniqueId := range uniqueIds {
+			usage := model.CredentialUsage{UniqueId: uniqueId, TrackedSince: now}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&usage).Error; err != nil {
+				return err
+			}
+		}
+		if len(removedIds) > 0 {
+			return tx.Where("unique_id IN ?", removedIds).Delete(&model.CredentialUsage{}).Error
+		}
+		return nil
+	})
+}
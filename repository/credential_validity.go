@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// 凭证有效期状态
+const (
+	ValidityStatusActive  = "active"                        // 有效期内
+	ValidityStatusPending = model.CredentialDisabledPending // 未到生效时间
+	ValidityStatusExpired = model.CredentialDisabledExpired // 已过失效时间
+)
+
+// CredentialValidityRepository 凭证和人员有效期的数据访问接口
+type CredentialValidityRepository interface {
+	FindAllStates() []*model.CredentialState
+	FindAllPeopleValidity() []*model.PeopleValidity
+	SetCredentialValidity(uniqueId uint, validFrom uint, validUntil uint) (bool, error)
+	SetPeopleValidity(peopleId uint, validFrom uint, validUntil uint) (bool, error)
+	Evaluate(uniqueId uint) (string, bool, error)
+}
+
+// CredentialValidityRepositoryImpl 凭证和人员有效期的数据访问实现
+type CredentialValidityRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewCredentialValidityRepositoryImpl 创建并返回一个新的 CredentialValidityRepositoryImpl 实例
+func NewCredentialValidityRepositoryImpl(Db *gorm.DB) CredentialValidityRepository {
+	return &CredentialValidityRepositoryImpl{Db: Db}
+}
+
+// FindAllStates 查询所有凭证状态
+func (r *CredentialValidityRepositoryImpl) FindAllStates() []*model.CredentialState {
+	var states []*model.CredentialState
+	result := r.Db.Find(&states)
+	utils.ErrorPanic(result.Error)
+	return states
+}
+
+// FindAllPeopleValidity 查询所有人员有效期
+func (r *CredentialValidityRepositoryImpl) FindAllPeopleValidity() []*model.PeopleValidity {
+	var validities []*model.PeopleValidity
+	result := r.Db.Find(&validities)
+	utils.ErrorPanic(result.Error)
+	return validities
+}
+
+// SetCredentialValidity 设置凭证有效期并立即按新的有效期启用或停用凭证，返回凭证是否被启用或停用
+func (r *CredentialValidityRepositoryImpl) SetCredentialValidity(uniqueId uint, validFrom uint, validUntil uint) (bool, error) {
+	var changed bool
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&model.Credential{}, uniqueId).Error; err != nil {
+			return err
+		}
+		var err error
+		changed, err = setCredentialValidity(tx, uniqueId, validFrom, validUntil)
+		return err
+	})
+	return changed, err
+}
+
+// SetPeopleValidity 设置人员有效期并立即按新的有效期启用或停用该人员的凭证，返回是否有凭证被启用或停用
+func (r *CredentialValidityRepositoryImpl) SetPeopleValidity(peopleId uint, validFrom uint, validUntil uint) (bool, error) {
+	var changed bool
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&model.People{}, peopleId).Error; err != nil {
+			return err
+		}
+		var err error
+		changed, err = setPeopleValidity(tx, peopleId, validFrom, validUntil)
+		return err
+	})
+	return changed, err
+}
+
+// Evaluate 按当前时间检查凭证有效期，返回有效期状态以及凭证是否被启用或停用
+func (r *CredentialValidityRepositoryImpl) Evaluate(uniqueId uint) (string, bool, error) {
+	var status string
+	var changed bool
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		status, changed, err = evaluateCredentialValidity(tx, uniqueId, uint(time.Now().Unix()))
+		return err
+	})
+	return status, changed, err
+}
+
+// EffectiveValidity 返回凭证有效期与人员有效期的交集，0 表示不限制
+func EffectiveValidity(credentialFrom uint, credentialUntil uint, peopleFrom uint, peopleUntil uint) (uint, uint) {
+	validFrom := max(credentialFrom, peopleFrom)
+	validUntil := credentialUntil
+	if validUntil == 0 || (peopleUntil > 0 && peopleUntil < validUntil) {
+		validUntil = peopleUntil
+	}
+	return validFrom, validUntil
+}
+
+// ValidityStatus 返回 now 时刻的有效期状态，失效时间本身已不在有效期内
+func ValidityStatus(validFrom uint, validUntil uint, now uint) string {
+	switch {
+	case validUntil > 0 && now >= validUntil:
+		return ValidityStatusExpired
+	case validFrom > 0 && now < validFrom:
+		return ValidityStatusPending
+	default:
+		return ValidityStatusActive
+	}
+}
+
+// ValidityChangeNeeded 判断凭证的停用状态是否与有效期状态不一致，人工停用的凭证不受有效期影响
+func ValidityChangeNeeded(state *model.CredentialState, status string) bool {
+	if state.Disabled == 0 {
+		return status != ValidityStatusActive
+	}
+	switch state.DisabledReason {
+	case model.CredentialDisabledPending, model.CredentialDisabledExpired:
+		return state.DisabledReason != status
+	default:
+		return false
+	}
+}
+
+// setCredentialValidity 保存凭证有效期并按新的有效期启用或停用凭证
+func setCredentialValidity(tx *gorm.DB, uniqueId uint, validFrom uint, validUntil uint) (bool, error) {
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return false, err
+	}
+	suspended, err := suspendedAccess(state)
+	if err != nil {
+		return false, err
+	}
+	state.ValidFrom = validFrom
+	state.ValidUntil = validUntil
+	if err := saveCredentialState(tx, state, suspended); err != nil {
+		return false, err
+	}
+
+	_, changed, err := evaluateCredentialValidity(tx, uniqueId, uint(time.Now().Unix()))
+	return changed, err
+}
+
+// setPeopleValidity 保存人员有效期并按新的有效期启用或停用该人员的凭证，有效期不限制时删除记录
+func setPeopleValidity(tx *gorm.DB, peopleId uint, validFrom uint, validUntil uint) (bool, error) {
+	var err error
+	if validFrom == 0 && validUntil == 0 {
+		err = tx.Delete(&model.PeopleValidity{}, peopleId).Error
+	} else {
+		err = tx.Save(&model.PeopleValidity{
+			PeopleId:   peopleId,
+			ValidFrom:  validFrom,
+			ValidUntil: validUntil,
+			UpdatedAt:  uint(time.Now().Unix()),
+		}).Error
+	}
+	if err != nil {
+		return false, err
+	}
+
+	uniqueIds, err := peopleCredentialIds(tx, peopleId)
+	if err != nil {
+		return false, err
+	}
+	now := uint(time.Now().Unix())
+	changed := false
+	for _, uniqueId := range uniqueIds {
+		_, ok, err := evaluateCredentialValidity(tx, uniqueId, now)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || ok
+	}
+	return changed, nil
+}
+
+// findPeopleValidity 查询人员有效期，不存在时返回不限制
+func findPeopleValidity(tx *gorm.DB, peopleId uint) (*model.PeopleValidity, error) {
+	validity := model.PeopleValidity{PeopleId: peopleId}
+	err := tx.First(&validity, peopleId).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &validity, nil
+}
+
+// evaluateCredentialValidity 按 now 时刻的有效期停用或启用凭证
+// 超出有效期的启用凭证以 pending 或 expired 原因停用，回到有效期内时自动启用；人工停用的凭证保持不变
+func evaluateCredentialValidity(tx *gorm.DB, uniqueId uint, now uint) (string, bool, error) {
+	var credential model.Credential
+	if err := tx.First(&credential, uniqueId).Error; err != nil {
+		return "", false, err
+	}
+	state, err := findCredentialState(tx, uniqueId)
+	if err != nil {
+		return "", false, err
+	}
+	peopleValidity, err := findPeopleValidity(tx, credential.PeopleId)
+	if err != nil {
+		return "", false, err
+	}
+
+	validFrom, validUntil := EffectiveValidity(state.ValidFrom, state.ValidUntil, peopleValidity.ValidFrom, peopleValidity.ValidUntil)
+	status := ValidityStatus(validFrom, validUntil, now)
+	if !ValidityChangeNeeded(state, status) {
+		return status, false, nil
+	}
+
+	switch {
+	case status == ValidityStatusActive:
+		changed, err := enableCredential(tx, uniqueId)
+		return status, changed, err
+	case state.Disabled == 0:
+		changed, err := disableCredential(tx, uniqueId, status)
+		return status, changed, err
+	default:
+		// 停用原因在未生效和已失效之间切换，门禁组保持停用
+		return status, true, tx.Model(state).Update("disabled_reason", status).Error
+	}
+}
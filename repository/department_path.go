@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+)
+
+// DepartmentPathSeparator 部门完整路径中上级部门与下级部门的分隔符
+const DepartmentPathSeparator = "/"
+
+// 部门查找的错误
+var (
+	ErrDepartmentAmbiguous   = errors.New("department name is not unique, use the full path") // 部门名称对应多个部门
+	ErrDepartmentPathInvalid = errors.New("invalid department path")                          // 路径中有空的部门名称
+)
+
+// DepartmentIndex 部门名称只在同一上级部门下唯一，按完整路径（如 "总部/研发部"）查找部门
+type DepartmentIndex struct {
+	names    map[uint]string
+	parents  map[uint]uint
+	children map[uint]map[string]uint // 上级部门 -> 部门名称 -> 部门
+	byName   map[string][]uint
+}
+
+// NewDepartmentIndex 根据所有部门和部门的上级部门创建索引
+func NewDepartmentIndex(departments []*model.Department, nodes []*model.DepartmentNode) *DepartmentIndex {
+	index := &DepartmentIndex{
+		names:    map[uint]string{},
+		parents:  map[uint]uint{},
+		children: map[uint]map[string]uint{},
+		byName:   map[string][]uint{},
+	}
+	for _, node := range nodes {
+		index.parents[node.DepartmentId] = node.ParentId
+	}
+	for _, department := range departments {
+		index.Add(department.ID, index.parents[department.ID], department.Name)
+	}
+	return index
+}
+
+// Add 添加部门
+func (i *DepartmentIndex) Add(id uint, parentId uint, name string) {
+	i.names[id] = name
+	if parentId != 0 {
+		i.parents[id] = parentId
+	}
+	if i.children[parentId] == nil {
+		i.children[parentId] = map[string]uint{}
+	}
+	i.children[parentId][name] = id
+	i.byName[name] = append(i.byName[name], id)
+}
+
+// Path 返回部门的完整路径，部门不存在时返回空字符串
+func (i *DepartmentIndex) Path(id uint) string {
+	var names []string
+	visited := map[uint]bool{}
+	for ; id != 0 && !visited[id]; id = i.parents[id] {
+		name, ok := i.names[id]
+		if !ok {
+			break
+		}
+		visited[id] = true
+		names = append([]string{name}, names...)
+	}
+	return strings.Join(names, DepartmentPathSeparator)
+}
+
+// Find 查找部门，path 包含分隔符时为从顶级部门开始的完整路径，否则为部门名称
+// 返回已存在的最深一级部门（0 表示顶级）和其下不存在、需要依次创建的部门名称；部门名称对应多个部门时返回 ErrDepartmentAmbiguous
+func (i *DepartmentIndex) Find(path string) (uint, []string, error) {
+	parts := strings.Split(path, DepartmentPathSeparator)
+	for j := range parts {
+		parts[j] = strings.TrimSpace(parts[j])
+		if parts[j] == "" {
+			return 0, nil, ErrDepartmentPathInvalid
+		}
+	}
+
+	if len(parts) == 1 {
+		ids := i.byName[parts[0]]
+		if len(ids) > 1 {
+			return 0, nil, ErrDepartmentAmbiguous
+		}
+		if len(ids) == 1 {
+			return ids[0], nil, nil
+		}
+		return 0, parts, nil
+	}
+
+	var parentId uint
+	for j, name := range parts {
+		id, ok := i.children[parentId][name]
+		if !ok {
+			return parentId, parts[j:], nil
+		}
+		parentId = id
+	}
+	return parentId, nil, nil
+}
+
+// loadDepartmentIndex 查询所有部门并创建索引
+func loadDepartmentIndex(tx *gorm.DB) (*DepartmentIndex, error) {
+	var departments []*model.Department
+	if err := tx.Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	var nodes []*model.DepartmentNode
+	if err := tx.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return NewDepartmentIndex(departments, nodes), nil
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// DepartmentTreeRepository 部门层级和部门门禁组的数据访问接口
+type DepartmentTreeRepository interface {
+	FindAllNodes() []*model.DepartmentNode
+	FindAllAccess() []*model.DepartmentAccess
+	CreateChild(parentId uint, name string) (*model.Department, error)
+	Update(id uint, name *string, parentId *uint) error
+	SetAccess(id uint, accessGroupIds []uint) error
+	Delete(id uint, reassignTo uint) error
+	Reconcile(accessGroupIds []uint) (int, error)
+}
+
+// DepartmentTreeRepositoryImpl 部门层级和部门门禁组的数据访问实现
+type DepartmentTreeRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewDepartmentTreeRepositoryImpl 创建并返回一个新的 DepartmentTreeRepositoryImpl 实例
+func NewDepartmentTreeRepositoryImpl(Db *gorm.DB) DepartmentTreeRepository {
+	return &DepartmentTreeRepositoryImpl{Db: Db}
+}
+
+// FindAllNodes 查询所有部门的上级部门
+func (r *DepartmentTreeRepositoryImpl) FindAllNodes() []*model.DepartmentNode {
+	var nodes []*model.DepartmentNode
+	result := r.Db.Find(&nodes)
+	utils.ErrorPanic(result.Error)
+	return nodes
+}
+
+// FindAllAccess 查询所有部门的门禁组
+func (r *DepartmentTreeRepositoryImpl) FindAllAccess() []*model.DepartmentAccess {
+	var accesses []*model.DepartmentAccess
+	result := r.Db.Order("department_id, access_group_id").Find(&accesses)
+	utils.ErrorPanic(result.Error)
+	return accesses
+}
+
+// CreateChild 在一个事务中创建下级部门
+func (r *DepartmentTreeRepositoryImpl) CreateChild(parentId uint, name string) (*model.Department, error) {
+	department := model.Department{Name: name}
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := findDepartment(tx, parentId); err != nil {
+			return err
+		}
+		if err := checkDepartmentName(tx, 0, parentId, name); err != nil {
+			return err
+		}
+		if err := tx.Create(&department).Error; err != nil {
+			return err
+		}
+		return saveDepartmentParent(tx, department.ID, parentId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
+// Update 在一个事务中修改部门名称和上级部门，name 或 parentId 为 nil 时不修改；上级部门不能是部门自身或其下级部门
+func (r *DepartmentTreeRepositoryImpl) Update(id uint, name *string, parentId *uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var department model.Department
+		if err := tx.First(&department, id).Error; err != nil {
+			return err
+		}
+		parents, err := departmentParents(tx)
+		if err != nil {
+			return err
+		}
+
+		newParentId := parents[id]
+		if parentId != nil && *parentId != newParentId {
+			newParentId = *parentId
+			if newParentId != 0 {
+				if err := findDepartment(tx, newParentId); err != nil {
+					return err
+				}
+				for ancestor := newParentId; ancestor != 0; ancestor = parents[ancestor] {
+					if ancestor == id {
+						return errors.New("department cannot be moved under itself or its sub-department")
+					}
+				}
+			}
+			if err := saveDepartmentParent(tx, id, newParentId); err != nil {
+				return err
+			}
+		}
+
+		newName := department.Name
+		if name != nil {
+			newName = *name
+		}
+		if err := checkDepartmentName(tx, id, newParentId, newName); err != nil {
+			return err
+		}
+		if newName != department.Name {
+			return tx.Model(&department).Update("name", newName).Error
+		}
+		return nil
+	})
+}
+
+// SetAccess 替换分配给部门的门禁组
+func (r *DepartmentTreeRepositoryImpl) SetAccess(id uint, accessGroupIds []uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := findDepartment(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Where("department_id = ?", id).Delete(&model.DepartmentAccess{}).Error; err != nil {
+			return err
+		}
+		for _, accessGroupId := range accessGroupIds {
+			if err := tx.Create(&model.DepartmentAccess{DepartmentId: id, AccessGroupId: accessGroupId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete 在一个事务中删除部门：部门有人员或下级部门时，reassignTo 为 0 则拒绝删除，否则先将人员和下级部门转移到 reassignTo
+func (r *DepartmentTreeRepositoryImpl) Delete(id uint, reassignTo uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		return deleteDepartment(tx, id, reassignTo)
+	})
+}
+
+// Reconcile 在一个事务中同步继承的门禁组：凭证继承人员所在部门及其所有上级部门的门禁组，
+// 不再继承的门禁组从凭证移除，直接分配给凭证的门禁组不受影响；accessGroupIds 为存在的门禁组。返回门禁组有变化的凭证数
+// 单独从凭证移除的继承门禁组记录为已移除，不再自动添加；之后重新分配给凭证时作为直接分配的门禁组
+func (r *DepartmentTreeRepositoryImpl) Reconcile(accessGroupIds []uint) (int, error) {
+	type accessKey struct {
+		UniqueId      uint
+		AccessGroupId uint
+	}
+
+	changed := map[uint]bool{}
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		parents, err := departmentParents(tx)
+		if err != nil {
+			return err
+		}
+		var accesses []*model.DepartmentAccess
+		if err := tx.Find(&accesses).Error; err != nil {
+			return err
+		}
+		departmentGroups := map[uint][]uint{}
+		for _, access := range accesses {
+			if slices.Contains(accessGroupIds, access.AccessGroupId) {
+				departmentGroups[access.DepartmentId] = append(departmentGroups[access.DepartmentId], access.AccessGroupId)
+			}
+		}
+
+		var peoples []*model.People
+		if err := tx.Select("id", "department_id").Find(&peoples).Error; err != nil {
+			return err
+		}
+		peopleDepartments := map[uint]uint{}
+		for _, people := range peoples {
+			peopleDepartments[people.ID] = people.DepartmentID
+		}
+
+		// 凭证应继承的门禁组
+		var credentials []*model.Credential
+		if err := tx.Select("unique_id", "people_id").Find(&credentials).Error; err != nil {
+			return err
+		}
+		desired := map[accessKey]bool{}
+		for _, credential := range credentials {
+			for _, groupId := range InheritedAccessGroupIds(peopleDepartments[credential.PeopleId], parents, departmentGroups) {
+				desired[accessKey{UniqueId: credential.UniqueId, AccessGroupId: groupId}] = true
+			}
+		}
+
+		// 凭证当前的门禁组，停用的凭证为停用期间保存的门禁组
+		current := map[accessKey]bool{}
+		var credentialAccesses []*model.CredentialAccess
+		if err := tx.Find(&credentialAccesses).Error; err != nil {
+			return err
+		}
+		for _, access := range credentialAccesses {
+			current[accessKey{UniqueId: access.UniqueId, AccessGroupId: access.AccessGroupId}] = true
+		}
+		var states []*model.CredentialState
+		if err := tx.Where("disabled = 1").Find(&states).Error; err != nil {
+			return err
+		}
+		for _, state := range states {
+			groupIds, err := suspendedAccess(state)
+			if err != nil {
+				return err
+			}
+			for _, groupId := range groupIds {
+				current[accessKey{UniqueId: state.UniqueId, AccessGroupId: groupId}] = true
+			}
+		}
+
+		var grants []model.DepartmentAccessGrant
+		if err := tx.Find(&grants).Error; err != nil {
+			return err
+		}
+		granted := map[accessKey]bool{}
+		for _, grant := range grants {
+			key := accessKey{UniqueId: grant.UniqueId, AccessGroupId: grant.AccessGroupId}
+			granted[key] = true
+			switch {
+			case !desired[key]:
+				// 不再继承：移除门禁组并删除记录，已单独移除后又重新分配的门禁组保留
+				if current[key] && grant.Removed == 0 {
+					if err := removeCredentialAccess(tx, grant.UniqueId, []uint{grant.AccessGroupId}); err != nil {
+						return err
+					}
+					changed[grant.UniqueId] = true
+				}
+				if err := tx.Delete(&grant).Error; err != nil {
+					return err
+				}
+			case !current[key] && grant.Removed == 0:
+				// 继承的门禁组被单独移除：记录，不再添加
+				if err := tx.Model(&grant).Update("removed", 1).Error; err != nil {
+					return err
+				}
+			case current[key] && grant.Removed == 1:
+				// 单独移除后又重新分配：作为直接分配的门禁组
+				if err := tx.Delete(&grant).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for key := range desired {
+			if current[key] || granted[key] {
+				continue
+			}
+			// 新继承的门禁组：添加并记录
+			if err := addCredentialAccess(tx, key.UniqueId, []uint{key.AccessGroupId}); err != nil {
+				return err
+			}
+			changed[key.UniqueId] = true
+			if err := tx.Create(&model.DepartmentAccessGrant{UniqueId: key.UniqueId, AccessGroupId: key.AccessGroupId}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(changed), err
+}
+
+// InheritedAccessGroupIds 返回部门及其所有上级部门的门禁组，parents 为部门的上级部门，departmentGroups 为分配给部门的门禁组
+func InheritedAccessGroupIds(departmentId uint, parents map[uint]uint, departmentGroups map[uint][]uint) []uint {
+	groupIds := []uint{}
+	visited := map[uint]bool{}
+	for id := departmentId; id != 0 && !visited[id]; id = parents[id] {
+		visited[id] = true
+		for _, groupId := range departmentGroups[id] {
+			if !slices.Contains(groupIds, groupId) {
+				groupIds = append(groupIds, groupId)
+			}
+		}
+	}
+	return groupIds
+}
+
+// deleteDepartment 删除部门及其层级和门禁组：部门有人员或下级部门时，reassignTo 为 0 则拒绝删除，否则先转移到 reassignTo
+func deleteDepartment(tx *gorm.DB, id uint, reassignTo uint) error {
+	if err := findDepartment(tx, id); err != nil {
+		return err
+	}
+
+	var peopleCount, childCount int64
+	if err := tx.Model(&model.People{}).Where("department_id = ?", id).Count(&peopleCount).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.DepartmentNode{}).Where("parent_id = ?", id).Count(&childCount).Error; err != nil {
+		return err
+	}
+
+	if peopleCount > 0 || childCount > 0 {
+		if reassignTo == 0 {
+			return fmt.Errorf("department has %d people and %d sub-departments, reassign them to another department first", peopleCount, childCount)
+		}
+		if err := findDepartment(tx, reassignTo); err != nil {
+			return err
+		}
+		parents, err := departmentParents(tx)
+		if err != nil {
+			return err
+		}
+		for ancestor := reassignTo; ancestor != 0; ancestor = parents[ancestor] {
+			if ancestor == id {
+				return errors.New("cannot reassign to the department itself or its sub-department")
+			}
+		}
+
+		if err := tx.Model(&model.People{}).Where("department_id = ?", id).Update("department_id", reassignTo).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&model.DepartmentNode{}).Where("parent_id = ?", id).
+			Updates(map[string]interface{}{"parent_id": reassignTo, "updated_at": uint(time.Now().Unix())}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("department_id = ?", id).Delete(&model.DepartmentAccess{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&model.DepartmentNode{}, id).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&model.Department{}, id).Error
+}
+
+// findDepartment 检查部门存在
+func findDepartment(tx *gorm.DB, id uint) error {
+	var department model.Department
+	if err := tx.Select("id").First(&department, id).Error; err != nil {
+		return fmt.Errorf("department %d not found: %w", id, err)
+	}
+	return nil
+}
+
+// departmentParents 查询所有部门的上级部门
+func departmentParents(tx *gorm.DB) (map[uint]uint, error) {
+	var nodes []*model.DepartmentNode
+	if err := tx.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	parents := map[uint]uint{}
+	for _, node := range nodes {
+		parents[node.DepartmentId] = node.ParentId
+	}
+	return parents, nil
+}
+
+// saveDepartmentParent 保存部门的上级部门，移到顶级时删除记录
+func saveDepartmentParent(tx *gorm.DB, id uint, parentId uint) error {
+	if parentId == 0 {
+		return tx.Delete(&model.DepartmentNode{}, id).Error
+	}
+	return tx.Save(&model.DepartmentNode{DepartmentId: id, ParentId: parentId, UpdatedAt: uint(time.Now().Unix())}).Error
+}
+
+// checkDepartmentName 检查同一上级部门下没有除 id 外的同名部门
+func checkDepartmentName(tx *gorm.DB, id uint, parentId uint, name string) error {
+	parents, err := departmentParents(tx)
+	if err != nil {
+		return err
+	}
+	var departments []*model.Department
+	if err := tx.Where("name = ? AND id <> ?", name, id).Find(&departments).Error; err != nil {
+		return err
+	}
+	for _, department := range departments {
+		if parents[department.ID] == parentId {
+			return errors.New("department " + name + " already exists")
+		}
+	}
+	return nil
+}
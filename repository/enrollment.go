@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+)
+
+// EnrollmentRepository 刷卡登记凭证的数据访问接口
+type EnrollmentRepository interface {
+	CreateCredential(peopleId uint, cardNo string, accessGroupIds []uint) (*model.Credential, error)
+	CardNoAvailable(cardNo string) error
+}
+
+// EnrollmentRepositoryImpl 刷卡登记凭证的数据访问实现
+type EnrollmentRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewEnrollmentRepositoryImpl 创建并返回一个新的 EnrollmentRepositoryImpl 实例
+func NewEnrollmentRepositoryImpl(Db *gorm.DB) EnrollmentRepository {
+	return &EnrollmentRepositoryImpl{Db: Db}
+}
+
+// CreateCredential 为人员创建凭证并分配门禁组，卡号已被使用或已挂失时拒绝
+func (r *EnrollmentRepositoryImpl) CreateCredential(peopleId uint, cardNo string, accessGroupIds []uint) (*model.Credential, error) {
+	var credential *model.Credential
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.People{}).Where("id = ?", peopleId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("people " + strconv.FormatUint(uint64(peopleId), 10) + " not found")
+		}
+		if err := checkCardNoAvailable(tx, cardNo, 0); err != nil {
+			return err
+		}
+
+		credential = &model.Credential{PeopleId: peopleId, CardNo: cardNo}
+		if err := tx.Create(credential).Error; err != nil {
+			return err
+		}
+		return addCredentialAccess(tx, credential.UniqueId, accessGroupIds)
+	})
+	return credential, err
+}
+
+// CardNoAvailable 检查卡号未被使用且未挂失
+func (r *EnrollmentRepositoryImpl) CardNoAvailable(cardNo string) error {
+	return checkCardNoAvailable(r.Db, cardNo, 0)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// EventCursorRepository 事件消费游标的数据访问接口
+type EventCursorRepository interface {
+	FindByName(name string) (*model.EventCursor, error)
+	Save(name string, lastMsgId uint) error
+	LastMsgId() uint
+	FindEventsAfter(msgId uint, limit int) []*model.EventMessageData
+	FindEventsByMsgIds(msgIds []uint) []*model.EventMessageData
+}
+
+// EventCursorRepositoryImpl 事件消费游标的数据访问实现，使用事件消息数据库
+type EventCursorRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewEventCursorRepositoryImpl 创建并返回一个新的 EventCursorRepositoryImpl 实例
+func NewEventCursorRepositoryImpl(Db *gorm.DB) EventCursorRepository {
+	return &EventCursorRepositoryImpl{Db: Db}
+}
+
+// FindByName 根据消费者名称查询游标，不存在时返回 gorm.ErrRecordNotFound
+func (r *EventCursorRepositoryImpl) FindByName(name string) (*model.EventCursor, error) {
+	var cursor model.EventCursor
+	result := r.Db.Where("name = ?", name).First(&cursor)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &cursor, nil
+}
+
+// Save 保存消费者的游标位置，不存在时创建
+func (r *EventCursorRepositoryImpl) Save(name string, lastMsgId uint) error {
+	cursor := model.EventCursor{
+		Name:      name,
+		LastMsgId: lastMsgId,
+		UpdatedAt: uint(time.Now().Unix()),
+	}
+	result := r.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_msg_id", "updated_at"}),
+	}).Create(&cursor)
+	return result.Error
+}
+
+// LastMsgId 返回当前事件消息表中最大的消息 ID
+func (r *EventCursorRepositoryImpl) LastMsgId() uint {
+	var lastMsgId uint
+	r.Db.Model(&model.EventMessageData{}).Select("COALESCE(MAX(msgid), 0)").Scan(&lastMsgId)
+	return lastMsgId
+}
+
+// FindEventsAfter 按消息 ID 升序查询指定 ID 之后的事件消息
+func (r *EventCursorRepositoryImpl) FindEventsAfter(msgId uint, limit int) []*model.EventMessageData {
+	var events []*model.EventMessageData
+	result := r.Db.Where("msgid > ?", msgId).Order("msgid").Limit(limit).Find(&events)
+	utils.ErrorPanic(result.Error)
+	return events
+}
+
+// FindEventsByMsgIds 根据消息 ID 列表查询事件消息
+func (r *EventCursorRepositoryImpl) FindEventsByMsgIds(msgIds []uint) []*model.EventMessageData {
+	var events []*model.EventMessageData
+	if len(msgIds) == 0 {
+		return events
+	}
+	result := r.Db.Where("msgid IN ?", msgIds).Order("msgid").Find(&events)
+	utils.ErrorPanic(result.Error)
+	return events
+}
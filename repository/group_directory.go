@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// GroupDirectoryRepository 门禁组、门组和时间组的只读查询接口，供导入、查询等模块批量读取
+type GroupDirectoryRepository interface {
+	FindAllAccessGroups() []*model.AccessGroup
+}
+
+// GroupDirectoryRepositoryImpl 门禁组、门组和时间组的只读查询实现
+type GroupDirectoryRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewGroupDirectoryRepositoryImpl 创建并返回一个新的 GroupDirectoryRepositoryImpl 实例
+func NewGroupDirectoryRepositoryImpl(Db *gorm.DB) GroupDirectoryRepository {
+	return &GroupDirectoryRepositoryImpl{Db: Db}
+}
+
+// FindAllAccessGroups 查询所有门禁组
+func (r *GroupDirectoryRepositoryImpl) FindAllAccessGroups() []*model.AccessGroup {
+	var accessGroups []*model.AccessGroup
+	result := r.Db.Order("id").Find(&accessGroups)
+	utils.ErrorPanic(result.Error)
+	return accessGroups
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// ImportBatchRepository 人员导入批次的数据访问接口
+type ImportBatchRepository interface {
+	FindAll(pg *utils.Pagination) []*model.ImportBatch
+	FindById(id uint) (*model.ImportBatch, error)
+	Rollback(id uint, userId uint) error
+}
+
+// ImportBatchRepositoryImpl 人员导入批次的数据访问实现
+type ImportBatchRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewImportBatchRepositoryImpl 创建并返回一个新的 ImportBatchRepositoryImpl 实例
+func NewImportBatchRepositoryImpl(Db *gorm.DB) ImportBatchRepository {
+	return &ImportBatchRepositoryImpl{Db: Db}
+}
+
+// FindAll 分页查询导入批次，按时间倒序
+func (r *ImportBatchRepositoryImpl) FindAll(pg *utils.Pagination) []*model.ImportBatch {
+	var batches []*model.ImportBatch
+	result := r.Db.Order("id desc").Scopes(pg.Paginate()).Find(&batches)
+	utils.ErrorPanic(result.Error)
+	return batches
+}
+
+// FindById 根据 ID 查询导入批次
+func (r *ImportBatchRepositoryImpl) FindById(id uint) (*model.ImportBatch, error) {
+	var batch model.ImportBatch
+	result := r.Db.First(&batch, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &batch, nil
+}
+
+// Rollback 在一个事务中撤销导入批次：删除新增的人员、凭证和无人使用的部门，恢复被更新的人员和门禁组
+// 之后未回滚的批次修改过相同人员或凭证时拒绝回滚，需先回滚之后的批次；
+// 导入的凭证已分配给其他人员或门禁组在导入后被修改时也拒绝回滚，避免覆盖之后的修改
+func (r *ImportBatchRepositoryImpl) Rollback(id uint, userId uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var batch model.ImportBatch
+		if err := tx.First(&batch, id).Error; err != nil {
+			return err
+		}
+		if batch.RolledBack == 1 {
+			return errors.New("import batch already rolled back")
+		}
+
+		var changes model.ImportChanges
+		if err := json.Unmarshal([]byte(batch.Changes), &changes); err != nil {
+			return err
+		}
+		var snapshot model.ImportSnapshot
+		if err := json.Unmarshal([]byte(batch.Snapshot), &snapshot); err != nil {
+			return err
+		}
+		if err := checkImportRollback(tx, &batch, &changes); err != nil {
+			return err
+		}
+
+		// 恢复已有凭证的门禁组，停用的凭证恢复到停用期间保存的门禁组，已删除的凭证跳过
+		snapshotGroups := map[uint][]uint{}
+		for _, access := range snapshot.CredentialAccess {
+			snapshotGroups[access.UniqueId] = append(snapshotGroups[access.UniqueId], access.AccessGroupId)
+		}
+		for _, uniqueId := range changes.UpdatedCredentialIds {
+			var count int64
+			if err := tx.Model(&model.Credential{}).Where("unique_id = ?", uniqueId).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				continue
+			}
+			if err := replaceCredentialAccess(tx, uniqueId, snapshotGroups[uniqueId]); err != nil {
+				return err
+			}
+		}
+
+		// 恢复人员有效期，凭证由有效期后台任务重新停用或启用
+		if len(changes.ValidityPeopleIds) > 0 {
+			if err := tx.Delete(&model.PeopleValidity{}, changes.ValidityPeopleIds).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshot.PeopleValidity) > 0 {
+			if err := tx.Create(&snapshot.PeopleValidity).Error; err != nil {
+				return err
+			}
+		}
+
+		// 删除新增的凭证，新增的人员连同导入后添加的凭证、照片等一并删除
+		for _, uniqueId := range changes.CreatedCredentialIds {
+			if err := deleteCredential(tx, uniqueId); err != nil {
+				return err
+			}
+		}
+		for _, peopleId := range changes.CreatedPeopleIds {
+			if err := deletePeople(tx, peopleId); err != nil {
+				return err
+			}
+		}
+
+		// 恢复被更新的人员
+		for _, people := range snapshot.People {
+			if err := tx.Save(&people).Error; err != nil {
+				return err
+			}
+		}
+
+		// 删除自动创建且已没有人员和下级部门的部门，下级部门在上级部门之后创建，倒序删除
+		for i := len(changes.CreatedDepartmentIds) - 1; i >= 0; i-- {
+			departmentId := changes.CreatedDepartmentIds[i]
+			var department model.Department
+			err := tx.Select("id").First(&department, departmentId).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			var peopleCount, childCount int64
+			if err := tx.Model(&model.People{}).Where("department_id = ?", departmentId).Count(&peopleCount).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.DepartmentNode{}).Where("parent_id = ?", departmentId).Count(&childCount).Error; err != nil {
+				return err
+			}
+			if peopleCount > 0 || childCount > 0 {
+				continue
+			}
+			if err := deleteDepartment(tx, departmentId, 0); err != nil {
+				return err
+			}
+		}
+
+		batch.RolledBack = 1
+		batch.RolledBackBy = userId
+		batch.RolledBackAt = uint(time.Now().Unix())
+		return tx.Save(&batch).Error
+	})
+}
+
+// checkImportRollback 检查导入批次能否回滚：之后的批次未修改相同人员或凭证，
+// 新增的凭证仍属于本批次的人员，导入设置的门禁组未被修改（部门继承的门禁组除外）
+func checkImportRollback(tx *gorm.DB, batch *model.ImportBatch, changes *model.ImportChanges) error {
+	peopleIds := map[uint]bool{}
+	for _, peopleId := range append(changes.CreatedPeopleIds, changes.UpdatedPeopleIds...) {
+		peopleIds[peopleId] = true
+	}
+	credentialIds := map[uint]bool{}
+	for _, uniqueId := range append(changes.CreatedCredentialIds, changes.UpdatedCredentialIds...) {
+		credentialIds[uniqueId] = true
+	}
+
+	var laterBatches []*model.ImportBatch
+	if err := tx.Where("id > ? AND rolled_back = 0", batch.ID).Find(&laterBatches).Error; err != nil {
+		return err
+	}
+	for _, later := range laterBatches {
+		var laterChanges model.ImportChanges
+		if err := json.Unmarshal([]byte(later.Changes), &laterChanges); err != nil {
+			return err
+		}
+		for _, peopleId := range append(laterChanges.CreatedPeopleIds, laterChanges.UpdatedPeopleIds...) {
+			if peopleIds[peopleId] {
+				return fmt.Errorf("import batch %d modified the same people, roll it back first", later.ID)
+			}
+		}
+		for _, uniqueId := range append(laterChanges.CreatedCredentialIds, laterChanges.UpdatedCredentialIds...) {
+			if credentialIds[uniqueId] {
+				return fmt.Errorf("import batch %d modified the same credentials, roll it back first", later.ID)
+			}
+		}
+	}
+
+	// 已删除的凭证不检查
+	for _, uniqueId := range changes.CreatedCredentialIds {
+		var credential model.Credential
+		err := tx.First(&credential, uniqueId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !peopleIds[credential.PeopleId] {
+			return fmt.Errorf("credential %s was assigned to another people after the import", credential.CardNo)
+		}
+	}
+
+	uniqueIds := make([]uint, 0, len(changes.AccessGroupIds))
+	for uniqueId := range changes.AccessGroupIds {
+		uniqueIds = append(uniqueIds, uniqueId)
+	}
+	slices.Sort(uniqueIds)
+	for _, uniqueId := range uniqueIds {
+		var credential model.Credential
+		err := tx.First(&credential, uniqueId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		current, err := credentialAssignedGroupIds(tx, uniqueId)
+		if err != nil {
+			return err
+		}
+		inherited, err := credentialInheritedGroupIds(tx, uniqueId)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(current, withoutGroupIds(changes.AccessGroupIds[uniqueId], inherited)) {
+			return fmt.Errorf("access groups of credential %s were changed after the import", credential.CardNo)
+		}
+	}
+	return nil
+}
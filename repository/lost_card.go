@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// LostCardRepository 挂失卡黑名单的数据访问接口
+type LostCardRepository interface {
+	Report(uniqueId uint, reason string, note string) (*model.LostCard, error)
+	Replace(id uint, cardNo string) (*model.Credential, error)
+	Delete(id uint) error
+	FindById(id uint) (*model.LostCard, error)
+	FindAll() []*model.LostCard
+	RecordSeen(id uint, seenAt uint) error
+}
+
+// LostCardRepositoryImpl 挂失卡黑名单的数据访问实现
+type LostCardRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewLostCardRepositoryImpl 创建并返回一个新的 LostCardRepositoryImpl 实例
+func NewLostCardRepositoryImpl(Db *gorm.DB) LostCardRepository {
+	return &LostCardRepositoryImpl{Db: Db}
+}
+
+// Report 挂失凭证：保存门禁组、有效期和停用状态后删除凭证，卡号加入黑名单
+// 部门继承的门禁组不保存，补发后由部门门禁组同步重新授予
+func (r *LostCardRepositoryImpl) Report(uniqueId uint, reason string, note string) (*model.LostCard, error) {
+	var lostCard *model.LostCard
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var credential model.Credential
+		if err := tx.First(&credential, uniqueId).Error; err != nil {
+			return err
+		}
+
+		state, err := findCredentialState(tx, uniqueId)
+		if err != nil {
+			return err
+		}
+		groupIds, err := credentialAssignedGroupIds(tx, uniqueId)
+		if err != nil {
+			return err
+		}
+		accessGroups, err := json.Marshal(groupIds)
+		if err != nil {
+			return err
+		}
+
+		lostCard = &model.LostCard{
+			CardNo:         credential.CardNo,
+			PeopleId:       credential.PeopleId,
+			UniqueId:       uniqueId,
+			Reason:         reason,
+			Note:           note,
+			AccessGroups:   string(accessGroups),
+			ValidFrom:      state.ValidFrom,
+			ValidUntil:     state.ValidUntil,
+			Disabled:       state.Disabled,
+			DisabledReason: state.DisabledReason,
+			ReportedAt:     uint(time.Now().Unix()),
+		}
+		if err := tx.Create(lostCard).Error; err != nil {
+			return err
+		}
+		return deleteCredential(tx, uniqueId)
+	})
+	return lostCard, err
+}
+
+// Replace 为挂失卡的人员补发新卡，恢复原凭证的门禁组、有效期和停用状态，每张挂失卡只能补发一次
+// 有效期导致的停用由有效期重新计算；人工停用和长期未使用停用需人工启用，新卡同样停用
+func (r *LostCardRepositoryImpl) Replace(id uint, cardNo string) (*model.Credential, error) {
+	var credential *model.Credential
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var lostCard model.LostCard
+		if err := tx.First(&lostCard, id).Error; err != nil {
+			return err
+		}
+		if lostCard.ReplacedBy > 0 {
+			return errors.New("lost card has already been replaced")
+		}
+		if err := checkCardNoAvailable(tx, cardNo, 0); err != nil {
+			return err
+		}
+
+		var groupIds []uint
+		if lostCard.AccessGroups != "" {
+			if err := json.Unmarshal([]byte(lostCard.AccessGroups), &groupIds); err != nil {
+				return err
+			}
+		}
+
+		credential = &model.Credential{PeopleId: lostCard.PeopleId, CardNo: cardNo}
+		if err := tx.Create(credential).Error; err != nil {
+			return err
+		}
+		if err := addCredentialAccess(tx, credential.UniqueId, groupIds); err != nil {
+			return err
+		}
+		if lostCard.ValidFrom > 0 || lostCard.ValidUntil > 0 {
+			if _, err := setCredentialValidity(tx, credential.UniqueId, lostCard.ValidFrom, lostCard.ValidUntil); err != nil {
+				return err
+			}
+		}
+		if lostCard.Disabled == 1 && (lostCard.DisabledReason == model.CredentialDisabledManual || lostCard.DisabledReason == model.CredentialDisabledStale) {
+			disabled, err := disableCredential(tx, credential.UniqueId, lostCard.DisabledReason)
+			if err != nil {
+				return err
+			}
+			if !disabled {
+				err := tx.Model(&model.CredentialState{}).Where("unique_id = ?", credential.UniqueId).Update("disabled_reason", lostCard.DisabledReason).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(&lostCard).Update("replaced_by", credential.UniqueId).Error
+	})
+	return credential, err
+}
+
+// Delete 将卡号移出黑名单，如卡已找回
+func (r *LostCardRepositoryImpl) Delete(id uint) error {
+	return r.Db.Delete(&model.LostCard{}, id).Error
+}
+
+// FindById 根据 ID 查询挂失卡
+func (r *LostCardRepositoryImpl) FindById(id uint) (*model.LostCard, error) {
+	var lostCard model.LostCard
+	result := r.Db.First(&lostCard, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &lostCard, nil
+}
+
+// FindAll 查询黑名单中的所有挂失卡，最近挂失的在前
+func (r *LostCardRepositoryImpl) FindAll() []*model.LostCard {
+	var lostCards []*model.LostCard
+	result := r.Db.Order("reported_at DESC, id DESC").Find(&lostCards)
+	utils.ErrorPanic(result.Error)
+	return lostCards
+}
+
+// RecordSeen 记录挂失卡再次刷卡
+func (r *LostCardRepositoryImpl) RecordSeen(id uint, seenAt uint) error {
+	return r.Db.Model(&model.LostCard{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": seenAt, "seen_count": gorm.Expr("seen_count + 1")}).Error
+}
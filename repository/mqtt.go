@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+)
+
+// MqttConfigRepository MQTT 配置的数据访问接口
+type MqttConfigRepository interface {
+	Find() (*model.MqttConfig, error)
+	Save(config model.MqttConfig) (*model.MqttConfig, error)
+}
+
+// MqttConfigRepositoryImpl MQTT 配置的数据访问实现
+type MqttConfigRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewMqttConfigRepositoryImpl 创建并返回一个新的 MqttConfigRepositoryImpl 实例
+func NewMqttConfigRepositoryImpl(Db *gorm.DB) MqttConfigRepository {
+	return &MqttConfigRepositoryImpl{Db: Db}
+}
+
+// Find 查询 MQTT 配置，尚未配置时返回默认配置
+func (r *MqttConfigRepositoryImpl) Find() (*model.MqttConfig, error) {
+	var config model.MqttConfig
+	result := r.Db.First(&config)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &model.MqttConfig{
+			ClientId:       "ownsa",
+			TopicPrefix:    "ownsa",
+			QoS:            1,
+			PublishEvents:  1,
+			PublishStatus:  1,
+			StatusInterval: 10,
+		}, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &config, nil
+}
+
+// Save 保存 MQTT 配置
+func (r *MqttConfigRepositoryImpl) Save(config model.MqttConfig) (*model.MqttConfig, error) {
+	result := r.Db.Save(&config)
+	return &config, result.Error
+}
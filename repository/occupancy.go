@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// AreaRepository 区域的数据访问接口
+type AreaRepository interface {
+	Save(area model.Area, readers []model.AreaReader) (*model.Area, error)
+	Update(area model.Area, readers []model.AreaReader) error
+	Delete(id uint)
+	FindById(id uint) (*model.Area, []*model.AreaReader, error)
+	FindAll() []*model.Area
+	FindAllReaders() []*model.AreaReader
+}
+
+// AreaRepositoryImpl 区域的数据访问实现
+type AreaRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewAreaRepositoryImpl 创建并返回一个新的 AreaRepositoryImpl 实例
+func NewAreaRepositoryImpl(Db *gorm.DB) AreaRepository {
+	return &AreaRepositoryImpl{Db: Db}
+}
+
+// Save 新增区域及其读卡器
+func (r *AreaRepositoryImpl) Save(area model.Area, readers []model.AreaReader) (*model.Area, error) {
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&area).Error; err != nil {
+			return err
+		}
+		return saveAreaReaders(tx, area.ID, readers)
+	})
+	return &area, err
+}
+
+// Update 更新区域并替换其读卡器
+func (r *AreaRepositoryImpl) Update(area model.Area, readers []model.AreaReader) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&area).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("area_id = ?", area.ID).Delete(&model.AreaReader{}).Error; err != nil {
+			return err
+		}
+		return saveAreaReaders(tx, area.ID, readers)
+	})
+}
+
+// Delete 删除区域及其读卡器
+func (r *AreaRepositoryImpl) Delete(id uint) {
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("area_id = ?", id).Delete(&model.AreaReader{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Area{}, id).Error
+	})
+	utils.ErrorPanic(err)
+}
+
+// FindById 根据 ID 查询区域及其读卡器
+func (r *AreaRepositoryImpl) FindById(id uint) (*model.Area, []*model.AreaReader, error) {
+	var area model.Area
+	result := r.Db.First(&area, id)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	var readers []*model.AreaReader
+	result = r.Db.Where("area_id = ?", id).Order("id").Find(&readers)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	return &area, readers, nil
+}
+
+// FindAll 查询所有区域
+func (r *AreaRepositoryImpl) FindAll() []*model.Area {
+	var areas []*model.Area
+	result := r.Db.Order("id").Find(&areas)
+	utils.ErrorPanic(result.Error)
+	return areas
+}
+
+// FindAllReaders 查询所有区域读卡器
+func (r *AreaRepositoryImpl) FindAllReaders() []*model.AreaReader {
+	var readers []*model.AreaReader
+	result := r.Db.Order("id").Find(&readers)
+	utils.ErrorPanic(result.Error)
+	return readers
+}
+
+// saveAreaReaders 保存区域读卡器
+func saveAreaReaders(tx *gorm.DB, areaId uint, readers []model.AreaReader) error {
+	if len(readers) == 0 {
+		return nil
+	}
+	for i := range readers {
+		readers[i].ID = 0
+		readers[i].AreaID = areaId
+	}
+	return tx.Create(&readers).Error
+}
+
+// OccupancyRepository 区域内人员的数据访问接口
+type OccupancyRepository interface {
+	Enter(occupancy model.Occupancy) error
+	FindByPeopleId(peopleId uint) (*model.Occupancy, error)
+	FindAll(areaId uint) []*model.Occupancy
+	CountByArea(areaId uint) int64
+	DeleteByPeopleId(peopleId uint)
+	DeleteByAreaId(areaId uint)
+}
+
+// OccupancyRepositoryImpl 区域内人员的数据访问实现
+type OccupancyRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewOccupancyRepositoryImpl 创建并返回一个新的 OccupancyRepositoryImpl 实例
+func NewOccupancyRepositoryImpl(Db *gorm.DB) OccupancyRepository {
+	return &OccupancyRepositoryImpl{Db: Db}
+}
+
+// Enter 记录人员进入区域，已在其他区域时移动到新区域
+func (r *OccupancyRepositoryImpl) Enter(occupancy model.Occupancy) error {
+	result := r.Db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&occupancy)
+	return result.Error
+}
+
+// FindByPeopleId 查询人员当前所在区域
+func (r *OccupancyRepositoryImpl) FindByPeopleId(peopleId uint) (*model.Occupancy, error) {
+	var occupancy model.Occupancy
+	result := r.Db.First(&occupancy, peopleId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &occupancy, nil
+}
+
+// FindAll 查询区域内人员，areaId 为 0 时查询所有区域
+func (r *OccupancyRepositoryImpl) FindAll(areaId uint) []*model.Occupancy {
+	var occupancies []*model.Occupancy
+	query := r.Db.Order("area_id, entered_at")
+	if areaId > 0 {
+		query = query.Where("area_id = ?", areaId)
+	}
+	result := query.Find(&occupancies)
+	utils.ErrorPanic(result.Error)
+	return occupancies
+}
+
+// CountByArea 查询区域内人数
+func (r *OccupancyRepositoryImpl) CountByArea(areaId uint) int64 {
+	var count int64
+	result := r.Db.Model(&model.Occupancy{}).Where("area_id = ?", areaId).Count(&count)
+	utils.ErrorPanic(result.Error)
+	return count
+}
+
+// DeleteByPeopleId 人员离开区域
+func (r *OccupancyRepositoryImpl) DeleteByPeopleId(peopleId uint) {
+	result := r.Db.Delete(&model.Occupancy{}, peopleId)
+	utils.ErrorPanic(result.Error)
+}
+
+// DeleteByAreaId 清空区域内人员，areaId 为 0 时清空所有区域
+func (r *OccupancyRepositoryImpl) DeleteByAreaId(areaId uint) {
+	query := r.Db.Where("1 = 1")
+	if areaId > 0 {
+		query = r.Db.Where("area_id = ?", areaId)
+	}
+	result := query.Delete(&model.Occupancy{})
+	utils.ErrorPanic(result.Error)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// PeopleDirectoryRepository 人员、部门和凭证的只读查询接口，供考勤、统计等模块批量读取
+type PeopleDirectoryRepository interface {
+	FindAllPeople() []*model.People
+	FindPeopleByIds(ids []uint) []*model.People
+	FindAllDepartments() []*model.Department
+	FindAllCredentials() []*model.Credential
+	FindCredentialsByIds(uniqueIds []uint) []*model.Credential
+	FindCredentialByCardNo(cardNo string) (*model.Credential, error)
+	FindAllCredentialAccesses() []*model.CredentialAccess
+	FindAllCredentialStates() []*model.CredentialState
+}
+
+// PeopleDirectoryRepositoryImpl 人员、部门和凭证的只读查询实现
+type PeopleDirectoryRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewPeopleDirectoryRepositoryImpl 创建并返回一个新的 PeopleDirectoryRepositoryImpl 实例
+func NewPeopleDirectoryRepositoryImpl(Db *gorm.DB) PeopleDirectoryRepository {
+	return &PeopleDirectoryRepositoryImpl{Db: Db}
+}
+
+// FindAllPeople 查询所有人员
+func (r *PeopleDirectoryRepositoryImpl) FindAllPeople() []*model.People {
+	var peoples []*model.People
+	result := r.Db.Order("id").Find(&peoples)
+	utils.ErrorPanic(result.Error)
+	return peoples
+}
+
+// FindPeopleByIds 根据 ID 列表查询人员
+func (r *PeopleDirectoryRepositoryImpl) FindPeopleByIds(ids []uint) []*model.People {
+	var peoples []*model.People
+	if len(ids) == 0 {
+		return peoples
+	}
+	result := r.Db.Where("id IN ?", ids).Order("id").Find(&peoples)
+	utils.ErrorPanic(result.Error)
+	return peoples
+}
+
+// FindAllDepartments 查询所有部门
+func (r *PeopleDirectoryRepositoryImpl) FindAllDepartments() []*model.Department {
+	var departments []*model.Department
+	result := r.Db.Order("id").Find(&departments)
+	utils.ErrorPanic(result.Error)
+	return departments
+}
+
+// FindAllCredentials 查询所有凭证
+func (r *PeopleDirectoryRepositoryImpl) FindAllCredentials() []*model.Credential {
+	var credentials []*model.Credential
+	result := r.Db.Find(&credentials)
+	utils.ErrorPanic(result.Error)
+	return credentials
+}
+
+// FindCredentialsByIds 根据凭证 ID 列表查询凭证
+func (r *PeopleDirectoryRepositoryImpl) FindCredentialsByIds(uniqueIds []uint) []*model.Credential {
+	var credentials []*model.Credential
+	if len(uniqueIds) == 0 {
+		return credentials
+	}
+	result := r.Db.Where("unique_id IN ?", uniqueIds).Find(&credentials)
+	utils.ErrorPanic(result.Error)
+	return credentials
+}
+
+// FindCredentialByCardNo 根据卡号查询凭证
+func (r *PeopleDirectoryRepositoryImpl) FindCredentialByCardNo(cardNo string) (*model.Credential, error) {
+	var credential model.Credential
+	result := r.Db.Where("card_no = ?", cardNo).First(&credential)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &credential, nil
+}
+
+// FindAllCredentialAccesses 查询所有凭证的门禁组
+func (r *PeopleDirectoryRepositoryImpl) FindAllCredentialAccesses() []*model.CredentialAccess {
+	var accesses []*model.CredentialAccess
+	result := r.Db.Order("id").Find(&accesses)
+	utils.ErrorPanic(result.Error)
+	return accesses
+}
+
+// FindAllCredentialStates 查询所有凭证状态，未停用过的凭证没有记录
+func (r *PeopleDirectoryRepositoryImpl) FindAllCredentialStates() []*model.CredentialState {
+	var states []*model.CredentialState
+	result := r.Db.Find(&states)
+	utils.ErrorPanic(result.Error)
+	return states
+}
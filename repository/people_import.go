@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// PeopleImportRow 一行已校验的导入数据
+type PeopleImportRow struct {
+	Row             int          // 文件中的行号
+	People          model.People // 人员，ID 为 0 表示新增
+	Continued       bool         // 同一人员的后续行，只写入卡号和门禁组
+	HasDepartment   bool         // 修改人员的部门，部门单元格为空时不修改
+	DepartmentName  string       // 部门完整路径（如 "总部/研发部"）或唯一的部门名称，不存在时自动创建，为空表示清空人员的部门
+	CardNos         []string     // 卡号，不存在时新增凭证
+	HasAccessGroups bool         // 修改门禁组，门禁组单元格为空时不修改
+	AccessGroupIds  []uint       // 门禁组，替换本行卡号（未提供卡号时为该人员所有凭证）的门禁组，为空表示清空
+	ValidFrom       *uint        // 人员生效时间，nil 表示不修改
+	ValidUntil      *uint        // 人员失效时间，nil 表示不修改
+}
+
+// PeopleImportRepository 人员批量导入的数据访问接口
+type PeopleImportRepository interface {
+	FindPeopleByCodes(codes []string) []*model.People
+	FindCredentialsByCardNos(cardNos []string) []*model.Credential
+	FindLostCardNos(cardNos []string) []string
+	FindDepartmentIndex() *DepartmentIndex
+	Apply(rows []PeopleImportRow, batch model.ImportBatch) (*model.ImportBatch, *model.ImportChanges, error)
+}
+
+// PeopleImportRepositoryImpl 人员批量导入的数据访问实现
+type PeopleImportRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewPeopleImportRepositoryImpl 创建并返回一个新的 PeopleImportRepositoryImpl 实例
+func NewPeopleImportRepositoryImpl(Db *gorm.DB) PeopleImportRepository {
+	return &PeopleImportRepositoryImpl{Db: Db}
+}
+
+// FindPeopleByCodes 根据人员编号列表查询人员
+func (r *PeopleImportRepositoryImpl) FindPeopleByCodes(codes []string) []*model.People {
+	var peoples []*model.People
+	if len(codes) == 0 {
+		return peoples
+	}
+	result := r.Db.Where("people_code IN ?", codes).Find(&peoples)
+	utils.ErrorPanic(result.Error)
+	return peoples
+}
+
+// FindCredentialsByCardNos 根据卡号列表查询凭证
+func (r *PeopleImportRepositoryImpl) FindCredentialsByCardNos(cardNos []string) []*model.Credential {
+	var credentials []*model.Credential
+	if len(cardNos) == 0 {
+		return credentials
+	}
+	result := r.Db.Where("card_no IN ?", cardNos).Find(&credentials)
+	utils.ErrorPanic(result.Error)
+	return credentials
+}
+
+// FindLostCardNos 返回卡号列表中已挂失的卡号
+func (r *PeopleImportRepositoryImpl) FindLostCardNos(cardNos []string) []string {
+	var lost []string
+	if len(cardNos) == 0 {
+		return lost
+	}
+	result := r.Db.Model(&model.LostCard{}).Where("card_no IN ?", cardNos).Pluck("card_no", &lost)
+	utils.ErrorPanic(result.Error)
+	return lost
+}
+
+// FindDepartmentIndex 查询所有部门，用于按完整路径查找部门
+func (r *PeopleImportRepositoryImpl) FindDepartmentIndex() *DepartmentIndex {
+	index, err := loadDepartmentIndex(r.Db)
+	utils.ErrorPanic(err)
+	return index
+}
+
+// Apply 在一个事务中写入所有导入行并记录导入批次，任一行失败时全部回滚
+func (r *PeopleImportRepositoryImpl) Apply(rows []PeopleImportRow, batch model.ImportBatch) (*model.ImportBatch, *model.ImportChanges, error) {
+	changes := &model.ImportChanges{AccessGroupIds: map[uint][]uint{}}
+	snapshot := &model.ImportSnapshot{}
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		departments, err := loadDepartmentIndex(tx)
+		if err != nil {
+			return err
+		}
+
+		snapshotted := map[uint]bool{}
+		importedPeople := map[string]uint{}
+		for _, row := range rows {
+			people := row.People
+			if row.Continued {
+				people.ID = importedPeople[people.PeopleCode]
+			} else {
+				if row.HasDepartment {
+					people.DepartmentID = 0
+					if row.DepartmentName != "" {
+						departmentId, missing, err := departments.Find(row.DepartmentName)
+						if err != nil {
+							return fmt.Errorf("row %d: %w", row.Row, err)
+						}
+						for _, name := range missing {
+							department := model.Department{Name: name}
+							if err := tx.Create(&department).Error; err != nil {
+								return fmt.Errorf("row %d: %w", row.Row, err)
+							}
+							if departmentId != 0 {
+								if err := saveDepartmentParent(tx, department.ID, departmentId); err != nil {
+									return err
+								}
+							}
+							departments.Add(department.ID, departmentId, name)
+							changes.CreatedDepartmentIds = append(changes.CreatedDepartmentIds, department.ID)
+							departmentId = department.ID
+						}
+						people.DepartmentID = departmentId
+					}
+				}
+
+				if people.ID == 0 {
+					if err := tx.Create(&people).Error; err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					changes.CreatedPeopleIds = append(changes.CreatedPeopleIds, people.ID)
+				} else {
+					var before model.People
+					if err := tx.First(&before, people.ID).Error; err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					snapshot.People = append(snapshot.People, before)
+					if err := tx.Save(&people).Error; err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					changes.UpdatedPeopleIds = append(changes.UpdatedPeopleIds, people.ID)
+				}
+
+				// 人员有效期只写入记录，由有效期后台任务停用或启用凭证
+				if row.ValidFrom != nil || row.ValidUntil != nil {
+					validity, err := findPeopleValidity(tx, people.ID)
+					if err != nil {
+						return err
+					}
+					if validity.UpdatedAt > 0 {
+						snapshot.PeopleValidity = append(snapshot.PeopleValidity, *validity)
+					}
+					if row.ValidFrom != nil {
+						validity.ValidFrom = *row.ValidFrom
+					}
+					if row.ValidUntil != nil {
+						validity.ValidUntil = *row.ValidUntil
+					}
+					validity.UpdatedAt = uint(time.Now().Unix())
+					if err := tx.Save(validity).Error; err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					changes.ValidityPeopleIds = append(changes.ValidityPeopleIds, people.ID)
+				}
+				importedPeople[people.PeopleCode] = people.ID
+			}
+
+			var uniqueIds []uint
+			created := map[uint]bool{}
+			for _, cardNo := range row.CardNos {
+				var credential model.Credential
+				err := tx.Where("card_no = ?", cardNo).First(&credential).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					if err := checkCardNoAvailable(tx, cardNo, 0); err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					credential = model.Credential{PeopleId: people.ID, CardNo: cardNo}
+					if err := tx.Create(&credential).Error; err != nil {
+						return fmt.Errorf("row %d: %w", row.Row, err)
+					}
+					created[credential.UniqueId] = true
+					changes.CreatedCredentialIds = append(changes.CreatedCredentialIds, credential.UniqueId)
+				} else if err != nil {
+					return err
+				} else if credential.PeopleId != people.ID {
+					return fmt.Errorf("row %d: card %s belongs to another people", row.Row, cardNo)
+				}
+				uniqueIds = append(uniqueIds, credential.UniqueId)
+			}
+
+			if !row.HasAccessGroups {
+				continue
+			}
+			if len(row.CardNos) == 0 {
+				if err := tx.Model(&model.Credential{}).Where("people_id = ?", people.ID).Pluck("unique_id", &uniqueIds).Error; err != nil {
+					return err
+				}
+			}
+			// 停用的凭证替换停用期间保存的门禁组，快照同样记录保存的门禁组
+			for _, uniqueId := range uniqueIds {
+				if !created[uniqueId] && !snapshotted[uniqueId] {
+					groupIds, err := credentialAccessGroupIds(tx, uniqueId)
+					if err != nil {
+						return err
+					}
+					state, err := findCredentialState(tx, uniqueId)
+					if err != nil {
+						return err
+					}
+					suspended, err := suspendedAccess(state)
+					if err != nil {
+						return err
+					}
+					for _, groupId := range append(groupIds, suspended...) {
+						snapshot.CredentialAccess = append(snapshot.CredentialAccess, model.CredentialAccess{UniqueId: uniqueId, AccessGroupId: groupId})
+					}
+					snapshotted[uniqueId] = true
+					changes.UpdatedCredentialIds = append(changes.UpdatedCredentialIds, uniqueId)
+				}
+				if err := replaceCredentialAccess(tx, uniqueId, row.AccessGroupIds); err != nil {
+					return fmt.Errorf("row %d: %w", row.Row, err)
+				}
+				changes.AccessGroupIds[uniqueId] = withoutGroupIds(row.AccessGroupIds, nil)
+			}
+		}
+
+		changesJson, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		snapshotJson, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		batch.Changes = string(changesJson)
+		batch.Snapshot = string(snapshotJson)
+		batch.CreatedAt = uint(time.Now().Unix())
+		return tx.Create(&batch).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &batch, changes, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// PeoplePhotoRepository 人员照片的数据访问接口
+type PeoplePhotoRepository interface {
+	Save(photo model.PeoplePhoto) error
+	Delete(peopleId uint) error
+	FindByPeopleId(peopleId uint) (*model.PeoplePhoto, error)
+	FindAll() []*model.PeoplePhoto
+	FindByPeopleIds(peopleIds []uint) []*model.PeoplePhoto
+	FindOrphanIds() []uint
+	TotalSize() int64
+}
+
+// PeoplePhotoRepositoryImpl 人员照片的数据访问实现
+type PeoplePhotoRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewPeoplePhotoRepositoryImpl 创建并返回一个新的 PeoplePhotoRepositoryImpl 实例
+func NewPeoplePhotoRepositoryImpl(Db *gorm.DB) PeoplePhotoRepository {
+	return &PeoplePhotoRepositoryImpl{Db: Db}
+}
+
+// Save 新增或替换人员照片
+func (r *PeoplePhotoRepositoryImpl) Save(photo model.PeoplePhoto) error {
+	return r.Db.Save(&photo).Error
+}
+
+// Delete 删除人员照片
+func (r *PeoplePhotoRepositoryImpl) Delete(peopleId uint) error {
+	return r.Db.Delete(&model.PeoplePhoto{}, peopleId).Error
+}
+
+// FindByPeopleId 查询人员照片
+func (r *PeoplePhotoRepositoryImpl) FindByPeopleId(peopleId uint) (*model.PeoplePhoto, error) {
+	var photo model.PeoplePhoto
+	result := r.Db.First(&photo, peopleId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &photo, nil
+}
+
+// FindAll 查询所有人员照片
+func (r *PeoplePhotoRepositoryImpl) FindAll() []*model.PeoplePhoto {
+	var photos []*model.PeoplePhoto
+	result := r.Db.Find(&photos)
+	utils.ErrorPanic(result.Error)
+	return photos
+}
+
+// FindByPeopleIds 根据人员 ID 列表查询照片
+func (r *PeoplePhotoRepositoryImpl) FindByPeopleIds(peopleIds []uint) []*model.PeoplePhoto {
+	var photos []*model.PeoplePhoto
+	if len(peopleIds) == 0 {
+		return photos
+	}
+	result := r.Db.Where("people_id IN ?", peopleIds).Find(&photos)
+	utils.ErrorPanic(result.Error)
+	return photos
+}
+
+// FindOrphanIds 查询人员已被删除的照片
+func (r *PeoplePhotoRepositoryImpl) FindOrphanIds() []uint {
+	var peopleIds []uint
+	result := r.Db.Model(&model.PeoplePhoto{}).
+		Where("people_id NOT IN (?)", r.Db.Model(&model.People{}).Select("id")).
+		Pluck("people_id", &peopleIds)
+	utils.ErrorPanic(result.Error)
+	return peopleIds
+}
+
+// TotalSize 返回所有照片占用的空间（字节）
+func (r *PeoplePhotoRepositoryImpl) TotalSize() int64 {
+	var size int64
+	result := r.Db.Model(&model.PeoplePhoto{}).Select("COALESCE(SUM(size), 0)").Scan(&size)
+	utils.ErrorPanic(result.Error)
+	return size
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// 每次增量更新索引处理的对象数
+const searchSyncBatch = 500
+
+// 全文检索索引的 docid 为对象 ID * 4 + 类型序号，删除和替换时直接按 docid 定位
+var searchKindIndex = map[string]int64{
+	model.SearchKindPeople:     1,
+	model.SearchKindCredential: 2,
+	model.SearchKindDepartment: 3,
+}
+
+// SearchHit 全文检索命中的对象
+type SearchHit struct {
+	Kind string // 对象类型
+	Ref  uint   // 对象 ID
+}
+
+// searchRow 全文检索索引中的一行
+type searchRow struct {
+	Docid int64
+}
+
+// SearchRepository 全文检索索引的数据访问接口
+type SearchRepository interface {
+	Sync() (int, error)
+	Match(expression string, limit int) []SearchHit
+	Like(term string, limit int) []SearchHit
+	Terms() []string
+}
+
+// SearchRepositoryImpl 全文检索索引的数据访问实现
+type SearchRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewSearchRepositoryImpl 创建并返回一个新的 SearchRepositoryImpl 实例
+func NewSearchRepositoryImpl(Db *gorm.DB) SearchRepository {
+	return &SearchRepositoryImpl{Db: Db}
+}
+
+// Sync 增量更新索引：重新索引触发器记录的人员、凭证和部门，已删除的对象从索引中移除，返回处理的对象数
+func (r *SearchRepositoryImpl) Sync() (int, error) {
+	total := 0
+	for {
+		count, err := r.syncBatch()
+		total += count
+		if err != nil || count < searchSyncBatch {
+			return total, err
+		}
+	}
+}
+
+// Match 使用 FTS4 查询表达式检索，按对象类型返回命中的对象
+func (r *SearchRepositoryImpl) Match(expression string, limit int) []SearchHit {
+	var rows []searchRow
+	result := r.Db.Raw("SELECT docid FROM "+model.SearchIndexTable+" WHERE "+model.SearchIndexTable+" MATCH ? LIMIT ?", expression, limit).Scan(&rows)
+	utils.ErrorPanic(result.Error)
+	return searchHits(rows)
+}
+
+// Like 模糊检索：名称或编号包含 term 的对象，用于全文检索无法匹配的中间片段（如卡号后几位）
+func (r *SearchRepositoryImpl) Like(term string, limit int) []SearchHit {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	pattern := "%" + replacer.Replace(term) + "%"
+
+	var rows []searchRow
+	result := r.Db.Raw("SELECT docid FROM "+model.SearchIndexTable+` WHERE title LIKE ? ESCAPE '\' OR code LIKE ? ESCAPE '\' LIMIT ?`, pattern, pattern, limit).Scan(&rows)
+	utils.ErrorPanic(result.Error)
+	return searchHits(rows)
+}
+
+// Terms 查询索引中的所有词
+func (r *SearchRepositoryImpl) Terms() []string {
+	var terms []string
+	result := r.Db.Raw("SELECT term FROM " + model.SearchTermsTable + " WHERE col = '*'").Scan(&terms)
+	utils.ErrorPanic(result.Error)
+	return terms
+}
+
+// syncBatch 在一个事务中重新索引一批待更新的对象，返回处理的对象数
+func (r *SearchRepositoryImpl) syncBatch() (int, error) {
+	count := 0
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		var dirties []model.SearchDirty
+		if err := tx.Limit(searchSyncBatch).Find(&dirties).Error; err != nil {
+			return err
+		}
+		count = len(dirties)
+
+		refs := map[string][]uint{}
+		for _, dirty := range dirties {
+			refs[dirty.Kind] = append(refs[dirty.Kind], dirty.Ref)
+		}
+
+		documents := map[int64][2]string{}
+		if ids := refs[model.SearchKindPeople]; len(ids) > 0 {
+			var peoples []*model.People
+			if err := tx.Find(&peoples, ids).Error; err != nil {
+				return err
+			}
+			for _, people := range peoples {
+				documents[searchDocid(model.SearchKindPeople, people.ID)] = [2]string{searchPeopleTitle(people), people.PeopleCode}
+			}
+		}
+		if ids := refs[model.SearchKindCredential]; len(ids) > 0 {
+			var credentials []*model.Credential
+			if err := tx.Find(&credentials, ids).Error; err != nil {
+				return err
+			}
+			for _, credential := range credentials {
+				documents[searchDocid(model.SearchKindCredential, credential.UniqueId)] = [2]string{"", credential.CardNo}
+			}
+		}
+		if ids := refs[model.SearchKindDepartment]; len(ids) > 0 {
+			var departments []*model.Department
+			if err := tx.Find(&departments, ids).Error; err != nil {
+				return err
+			}
+			for _, department := range departments {
+				documents[searchDocid(model.SearchKindDepartment, department.ID)] = [2]string{department.Name, ""}
+			}
+		}
+
+		for _, dirty := range dirties {
+			docid := searchDocid(dirty.Kind, dirty.Ref)
+			if err := tx.Exec("DELETE FROM "+model.SearchIndexTable+" WHERE docid = ?", docid).Error; err != nil {
+				return err
+			}
+			if document, ok := documents[docid]; ok {
+				err := tx.Exec("INSERT INTO "+model.SearchIndexTable+" (docid, title, code) VALUES (?, ?, ?)",
+					docid, SearchIndexText(document[0]), SearchIndexText(document[1])).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := tx.Delete(&dirty).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// searchPeopleTitle 返回人员的索引名称；中日韩姓名另外按姓在前的顺序索引，姓名连写时也可按短语匹配
+func searchPeopleTitle(people *model.People) string {
+	title := people.FirstName + " " + people.LastName
+	for _, r := range title {
+		if isSearchIdeograph(r) {
+			return title + " " + people.LastName + people.FirstName
+		}
+	}
+	return title
+}
+
+// searchDocid 返回对象在索引中的 docid
+func searchDocid(kind string, ref uint) int64 {
+	return int64(ref)*4 + searchKindIndex[kind]
+}
+
+// searchHits 将索引行转换为命中的对象
+func searchHits(rows []searchRow) []SearchHit {
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		for kind, index := range searchKindIndex {
+			if row.Docid%4 == index {
+				hits = append(hits, SearchHit{Kind: kind, Ref: uint(row.Docid / 4)})
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// SearchIndexText 返回写入索引的文本：在中日韩文字之间插入空格，使每个字成为一个词，姓名中任意连续的字都可检索
+func SearchIndexText(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		if isSearchIdeograph(r) {
+			builder.WriteRune(' ')
+			builder.WriteRune(r)
+			builder.WriteRune(' ')
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// isSearchIdeograph 判断字符是否为需要逐字索引的中日韩文字
+func isSearchIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
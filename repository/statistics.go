@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// StatisticsFilter 统计查询的时间范围，Start 包含、End 不包含，为 0 时不限制
+type StatisticsFilter struct {
+	Start uint
+	End   uint
+}
+
+// EventStatCount 按维度汇总的事件数
+type EventStatCount struct {
+	IBAddr     int
+	ReaderAddr int
+	OutputAddr int
+	Department string
+	IBName     string
+	ReaderName string
+	Weekday    uint
+	HourOfDay  uint
+	Granted    uint
+	Denied     uint
+	Alarm      uint
+	Total      uint
+}
+
+// DeniedCardCount 卡号被拒绝次数
+type DeniedCardCount struct {
+	CardNo     string
+	PeopleName string
+	Count      uint
+	LastTime   uint
+}
+
+// StatisticsRepository 事件统计的数据访问接口
+type StatisticsRepository interface {
+	Apply(hours []*model.EventStatHour, cards []*model.EventStatCard, cursorName string, lastMsgId uint) error
+	DeleteAll(cursorName string) error
+	CountBy(filter StatisticsFilter, fields []string, order string, limit int) []*EventStatCount
+	TopDeniedCards(filter StatisticsFilter, limit int) []*DeniedCardCount
+}
+
+// StatisticsRepositoryImpl 事件统计的数据访问实现
+type StatisticsRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewStatisticsRepositoryImpl 创建并返回一个新的 StatisticsRepositoryImpl 实例
+func NewStatisticsRepositoryImpl(Db *gorm.DB) StatisticsRepository {
+	return &StatisticsRepositoryImpl{Db: Db}
+}
+
+// Apply 在同一事务中累加一批统计数据并保存游标位置，保证事件不会被重复统计
+func (r *StatisticsRepositoryImpl) Apply(hours []*model.EventStatHour, cards []*model.EventStatCard, cursorName string, lastMsgId uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		for _, hour := range hours {
+			result := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{
+					{Name: "hour"}, {Name: "ib_addr"}, {Name: "reader_addr"},
+					{Name: "output_addr"}, {Name: "department"}, {Name: "event_type"},
+				},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"count":       gorm.Expr("red_event_stat_hour.count + ?", hour.Count),
+					"ib_name":     hour.IBName,
+					"reader_name": hour.ReaderName,
+				}),
+			}).Create(hour)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, card := range cards {
+			result := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "day"}, {Name: "card_no"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"count":       gorm.Expr("red_event_stat_card.count + ?", card.Count),
+					"people_name": card.PeopleName,
+					"last_time":   gorm.Expr("MAX(red_event_stat_card.last_time, ?)", card.LastTime),
+				}),
+			}).Create(card)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return NewEventCursorRepositoryImpl(tx).Save(cursorName, lastMsgId)
+	})
+}
+
+// DeleteAll 清空统计数据和游标，后台任务将从头重新汇总
+func (r *StatisticsRepositoryImpl) DeleteAll(cursorName string) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&model.EventStatHour{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&model.EventStatCard{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", cursorName).Delete(&model.EventCursor{}).Error
+	})
+}
+
+// CountBy 按指定字段分组汇总允许、拒绝、报警和总事件数
+// fields 为 red_event_stat_hour 的列名，order 为排序表达式，limit 为 0 时不限制条数
+func (r *StatisticsRepositoryImpl) CountBy(filter StatisticsFilter, fields []string, order string, limit int) []*EventStatCount {
+	selects := append([]string{}, fields...)
+	selects = append(selects,
+		"COALESCE(MAX(ib_name), '') AS ib_name",
+		"COALESCE(MAX(reader_name), '') AS reader_name",
+		fmt.Sprintf("COALESCE(SUM(CASE WHEN event_type = %d THEN count ELSE 0 END), 0) AS granted", model.EventTypeGranted),
+		fmt.Sprintf("COALESCE(SUM(CASE WHEN event_type = %d THEN count ELSE 0 END), 0) AS denied", model.EventTypeDenied),
+		fmt.Sprintf("COALESCE(SUM(CASE WHEN event_type = %d THEN count ELSE 0 END), 0) AS alarm", model.EventTypeAlarm),
+		"COALESCE(SUM(count), 0) AS total",
+	)
+
+	query := r.Db.Model(&model.EventStatHour{}).Select(strings.Join(selects, ", "))
+	query = applyStatisticsFilter(query, "hour", filter)
+	if len(fields) > 0 {
+		query = query.Group(strings.Join(fields, ", "))
+	}
+	if order != "" {
+		query = query.Order(order)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var counts []*EventStatCount
+	result := query.Scan(&counts)
+	utils.ErrorPanic(result.Error)
+	return counts
+}
+
+// TopDeniedCards 查询被拒绝次数最多的卡号
+func (r *StatisticsRepositoryImpl) TopDeniedCards(filter StatisticsFilter, limit int) []*DeniedCardCount {
+	query := r.Db.Model(&model.EventStatCard{}).
+		Select("card_no, MAX(people_name) AS people_name, SUM(count) AS count, MAX(last_time) AS last_time")
+	query = applyStatisticsFilter(query, "day", filter)
+
+	var counts []*DeniedCardCount
+	result := query.Group("card_no").Order("count DESC, card_no").Limit(limit).Scan(&counts)
+	utils.ErrorPanic(result.Error)
+	return counts
+}
+
+// applyStatisticsFilter 按时间范围过滤
+func applyStatisticsFilter(query *gorm.DB, column string, filter StatisticsFilter) *gorm.DB {
+	if filter.Start > 0 {
+		query = query.Where(column+" >= ?", filter.Start)
+	}
+	if filter.End > 0 {
+		query = query.Where(column+" < ?", filter.End)
+	}
+	return query
+}
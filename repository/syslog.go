@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// SyslogConfigRepository Syslog 转发配置的数据访问接口
+type SyslogConfigRepository interface {
+	Find() (*model.SyslogConfig, error)
+	Save(config model.SyslogConfig) (*model.SyslogConfig, error)
+}
+
+// SyslogConfigRepositoryImpl Syslog 转发配置的数据访问实现
+type SyslogConfigRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewSyslogConfigRepositoryImpl 创建并返回一个新的 SyslogConfigRepositoryImpl 实例
+func NewSyslogConfigRepositoryImpl(Db *gorm.DB) SyslogConfigRepository {
+	return &SyslogConfigRepositoryImpl{Db: Db}
+}
+
+// Find 查询 Syslog 转发配置，尚未配置时返回默认配置
+func (r *SyslogConfigRepositoryImpl) Find() (*model.SyslogConfig, error) {
+	var config model.SyslogConfig
+	result := r.Db.First(&config)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &model.SyslogConfig{
+			Protocol:      model.SyslogProtocolUDP,
+			Port:          514,
+			Format:        model.SyslogFormatRFC5424,
+			Facility:      16,
+			AppName:       "ownsa",
+			ForwardAccess: 1,
+			ForwardAlarm:  1,
+			ForwardAudit:  1,
+		}, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &config, nil
+}
+
+// Save 保存 Syslog 转发配置
+func (r *SyslogConfigRepositoryImpl) Save(config model.SyslogConfig) (*model.SyslogConfig, error) {
+	result := r.Db.Save(&config)
+	return &config, result.Error
+}
+
+// SyslogQueueRepository Syslog 待发送队列的数据访问接口
+type SyslogQueueRepository interface {
+	Save(queue model.SyslogQueue) error
+	FindFirst(limit int) []*model.SyslogQueue
+	DeleteUpTo(id uint)
+	DeleteAll()
+	Count() int64
+	Trim(max int64)
+}
+
+// SyslogQueueRepositoryImpl Syslog 待发送队列的数据访问实现
+type SyslogQueueRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewSyslogQueueRepositoryImpl 创建并返回一个新的 SyslogQueueRepositoryImpl 实例
+func NewSyslogQueueRepositoryImpl(Db *gorm.DB) SyslogQueueRepository {
+	return &SyslogQueueRepositoryImpl{Db: Db}
+}
+
+// Save 消息入队
+func (r *SyslogQueueRepositoryImpl) Save(queue model.SyslogQueue) error {
+	result := r.Db.Create(&queue)
+	return result.Error
+}
+
+// FindFirst 按入队顺序查询最早的消息
+func (r *SyslogQueueRepositoryImpl) FindFirst(limit int) []*model.SyslogQueue {
+	var queues []*model.SyslogQueue
+	result := r.Db.Order("id").Limit(limit).Find(&queues)
+	utils.ErrorPanic(result.Error)
+	return queues
+}
+
+// DeleteUpTo 删除 ID 不大于 id 的消息（已发送）
+func (r *SyslogQueueRepositoryImpl) DeleteUpTo(id uint) {
+	result := r.Db.Where("id <= ?", id).Delete(&model.SyslogQueue{})
+	utils.ErrorPanic(result.Error)
+}
+
+// DeleteAll 清空队列
+func (r *SyslogQueueRepositoryImpl) DeleteAll() {
+	result := r.Db.Where("1 = 1").Delete(&model.SyslogQueue{})
+	utils.ErrorPanic(result.Error)
+}
+
+// Count 查询队列中的消息数
+func (r *SyslogQueueRepositoryImpl) Count() int64 {
+	var count int64
+	result := r.Db.Model(&model.SyslogQueue{}).Count(&count)
+	utils.ErrorPanic(result.Error)
+	return count
+}
+
+// Trim 队列超过 max 条时删除最早的消息
+func (r *SyslogQueueRepositoryImpl) Trim(max int64) {
+	result := r.Db.Where("id <= (SELECT MAX(id) FROM red_syslog_queue) - ?", max).Delete(&model.SyslogQueue{})
+	utils.ErrorPanic(result.Error)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// 指静脉模板数量超出限制的错误
+var (
+	ErrVeinTemplatePeopleLimit = errors.New("vein template limit per people reached") // 人员的模板数已达上限
+	ErrVeinTemplateCapacity    = errors.New("vein template capacity reached")         // 设备的模板数已达容量
+)
+
+// VeinTemplateRepository 指静脉模板的数据访问接口
+type VeinTemplateRepository interface {
+	Save(template model.VeinTemplate, maxPerPeople int64, capacity int64) error
+	Delete(peopleId uint, fingerIndex uint) error
+	DeleteByPeopleId(peopleId uint) error
+	FindByPeopleId(peopleId uint) []*model.VeinTemplate
+	FindAll() []*model.VeinTemplate
+	Count() int64
+	CountPeople() int64
+}
+
+// VeinTemplateRepositoryImpl 指静脉模板的数据访问实现
+type VeinTemplateRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewVeinTemplateRepositoryImpl 创建并返回一个新的 VeinTemplateRepositoryImpl 实例
+func NewVeinTemplateRepositoryImpl(Db *gorm.DB) VeinTemplateRepository {
+	return &VeinTemplateRepositoryImpl{Db: Db}
+}
+
+// Save 在一个事务中新增或替换人员指定手指的模板
+// 新增模板时人员的模板数达到 maxPerPeople 或模板总数达到 capacity 时拒绝，替换已有模板不受限制
+func (r *VeinTemplateRepositoryImpl) Save(template model.VeinTemplate, maxPerPeople int64, capacity int64) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var existing model.VeinTemplate
+		err := tx.Where("people_id = ? AND finger_index = ?", template.PeopleId, template.FingerIndex).Limit(1).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing.ID == 0 {
+			var peopleCount, count int64
+			if err := tx.Model(&model.VeinTemplate{}).Where("people_id = ?", template.PeopleId).Count(&peopleCount).Error; err != nil {
+				return err
+			}
+			if peopleCount >= maxPerPeople {
+				return ErrVeinTemplatePeopleLimit
+			}
+			if err := tx.Model(&model.VeinTemplate{}).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= capacity {
+				return ErrVeinTemplateCapacity
+			}
+		}
+		template.ID = existing.ID
+		return tx.Save(&template).Error
+	})
+}
+
+// Delete 删除人员指定手指的模板
+func (r *VeinTemplateRepositoryImpl) Delete(peopleId uint, fingerIndex uint) error {
+	return r.Db.Where("people_id = ? AND finger_index = ?", peopleId, fingerIndex).Delete(&model.VeinTemplate{}).Error
+}
+
+// DeleteByPeopleId 删除人员的所有模板
+func (r *VeinTemplateRepositoryImpl) DeleteByPeopleId(peopleId uint) error {
+	return r.Db.Where("people_id = ?", peopleId).Delete(&model.VeinTemplate{}).Error
+}
+
+// FindByPeopleId 查询人员的模板
+func (r *VeinTemplateRepositoryImpl) FindByPeopleId(peopleId uint) []*model.VeinTemplate {
+	var templates []*model.VeinTemplate
+	result := r.Db.Where("people_id = ?", peopleId).Order("finger_index").Find(&templates)
+	utils.ErrorPanic(result.Error)
+	return templates
+}
+
+// FindAll 查询所有模板
+func (r *VeinTemplateRepositoryImpl) FindAll() []*model.VeinTemplate {
+	var templates []*model.VeinTemplate
+	result := r.Db.Order("people_id, finger_index").Find(&templates)
+	utils.ErrorPanic(result.Error)
+	return templates
+}
+
+// Count 返回模板总数
+func (r *VeinTemplateRepositoryImpl) Count() int64 {
+	var count int64
+	result := r.Db.Model(&model.VeinTemplate{}).Count(&count)
+	utils.ErrorPanic(result.Error)
+	return count
+}
+
+// CountPeople 返回登记了模板的人数
+func (r *VeinTemplateRepositoryImpl) CountPeople() int64 {
+	var count int64
+	result := r.Db.Model(&model.VeinTemplate{}).Distinct("people_id").Count(&count)
+	utils.ErrorPanic(result.Error)
+	return count
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// 访客人员编号前缀，后接访客 ID
+const visitorPeopleCodePrefix = "VISITOR-"
+
+// VisitorFilter 访客日志的查询条件
+type VisitorFilter struct {
+	Status  *uint  // 状态
+	Keyword string // 姓名、单位或电话包含的关键字
+	Start   uint   // 预约时间起始 UNIX时间戳
+	End     uint   // 预约时间结束 UNIX时间戳（不含）
+}
+
+// VisitorRepository 访客的数据访问接口
+type VisitorRepository interface {
+	Register(visitor *model.Visitor, accessGroupIds []uint) error
+	CheckIn(id uint, cardNo string) (*model.Visitor, error)
+	Revoke(id uint, status uint) (*model.Visitor, error)
+	FindById(id uint) (*model.Visitor, error)
+	FindAll(filter VisitorFilter, pg *utils.Pagination) []*model.Visitor
+	FindExpiredIds(now uint) []uint
+	CardNoExists(cardNo string) bool
+}
+
+// VisitorRepositoryImpl 访客的数据访问实现
+type VisitorRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewVisitorRepositoryImpl 创建并返回一个新的 VisitorRepositoryImpl 实例
+func NewVisitorRepositoryImpl(Db *gorm.DB) VisitorRepository {
+	return &VisitorRepositoryImpl{Db: Db}
+}
+
+// Register 在一个事务中保存访客，并为访客创建人员和临时凭证，凭证有效期为预约时间段
+func (r *VisitorRepositoryImpl) Register(visitor *model.Visitor, accessGroupIds []uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCardNoAvailable(tx, visitor.CardNo, 0); err != nil {
+			return err
+		}
+
+		visitor.Status = model.VisitorStatusRegistered
+		visitor.CreatedAt = uint(time.Now().Unix())
+		if err := tx.Create(visitor).Error; err != nil {
+			return err
+		}
+
+		people := model.People{
+			PeopleCode: fmt.Sprintf("%s%d", visitorPeopleCodePrefix, visitor.ID),
+			FirstName:  visitor.Name,
+		}
+		if err := tx.Create(&people).Error; err != nil {
+			return err
+		}
+		credential := model.Credential{PeopleId: people.ID, CardNo: visitor.CardNo}
+		if err := tx.Create(&credential).Error; err != nil {
+			return err
+		}
+		if err := addCredentialAccess(tx, credential.UniqueId, accessGroupIds); err != nil {
+			return err
+		}
+		if _, err := setCredentialValidity(tx, credential.UniqueId, visitor.ExpectedFrom, visitor.ExpectedUntil); err != nil {
+			return err
+		}
+
+		visitor.PeopleId = people.ID
+		visitor.UniqueId = credential.UniqueId
+		return tx.Model(visitor).Updates(map[string]interface{}{
+			"people_id": visitor.PeopleId,
+			"unique_id": visitor.UniqueId,
+		}).Error
+	})
+}
+
+// CheckIn 访客签到：临时凭证立即生效，cardNo 不为空时更换为现场发放的卡号
+func (r *VisitorRepositoryImpl) CheckIn(id uint, cardNo string) (*model.Visitor, error) {
+	var visitor model.Visitor
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&visitor, id).Error; err != nil {
+			return err
+		}
+		if visitor.Status != model.VisitorStatusRegistered {
+			return errors.New("visitor is not waiting for check-in")
+		}
+
+		if cardNo != "" && cardNo != visitor.CardNo {
+			if err := checkCardNoAvailable(tx, cardNo, visitor.UniqueId); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.Credential{}).Where("unique_id = ?", visitor.UniqueId).Update("card_no", cardNo).Error; err != nil {
+				return err
+			}
+			visitor.CardNo = cardNo
+		}
+
+		// 提前到访时从签到开始生效
+		if _, err := setCredentialValidity(tx, visitor.UniqueId, 0, visitor.ExpectedUntil); err != nil {
+			return err
+		}
+
+		visitor.Status = model.VisitorStatusCheckedIn
+		visitor.CheckedInAt = uint(time.Now().Unix())
+		return tx.Save(&visitor).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &visitor, nil
+}
+
+// Revoke 在一个事务中注销访客：删除访客的人员和临时凭证，并将访客记录更新为 status
+func (r *VisitorRepositoryImpl) Revoke(id uint, status uint) (*model.Visitor, error) {
+	var visitor model.Visitor
+	err := r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&visitor, id).Error; err != nil {
+			return err
+		}
+		if visitor.Status != model.VisitorStatusRegistered && visitor.Status != model.VisitorStatusCheckedIn {
+			return errors.New("visitor already checked out")
+		}
+		if status == model.VisitorStatusCancelled && visitor.Status != model.VisitorStatusRegistered {
+			return errors.New("visitor already checked in, check out instead")
+		}
+
+		if visitor.UniqueId > 0 {
+			if err := deleteCredential(tx, visitor.UniqueId); err != nil {
+				return err
+			}
+		}
+		if visitor.PeopleId > 0 {
+			if err := deletePeople(tx, visitor.PeopleId); err != nil {
+				return err
+			}
+		}
+
+		visitor.PeopleId = 0
+		visitor.UniqueId = 0
+		visitor.Status = status
+		visitor.CheckedOutAt = uint(time.Now().Unix())
+		return tx.Save(&visitor).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &visitor, nil
+}
+
+// FindById 根据 ID 查询访客
+func (r *VisitorRepositoryImpl) FindById(id uint) (*model.Visitor, error) {
+	var visitor model.Visitor
+	result := r.Db.First(&visitor, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &visitor, nil
+}
+
+// FindAll 分页查询访客日志，按预约时间倒序
+func (r *VisitorRepositoryImpl) FindAll(filter VisitorFilter, pg *utils.Pagination) []*model.Visitor {
+	query := r.Db.Model(&model.Visitor{})
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.Keyword != "" {
+		keyword := "%" + filter.Keyword + "%"
+		query = query.Where("(name LIKE ? OR company LIKE ? OR phone LIKE ?)", keyword, keyword, keyword)
+	}
+	if filter.Start > 0 {
+		query = query.Where("created_at >= ?", filter.Start)
+	}
+	if filter.End > 0 {
+		query = query.Where("created_at < ?", filter.End)
+	}
+
+	var visitors []*model.Visitor
+	result := query.Order("id desc").Scopes(pg.Paginate()).Find(&visitors)
+	utils.ErrorPanic(result.Error)
+	return visitors
+}
+
+// FindExpiredIds 查询超过预约结束时间仍未注销的访客
+func (r *VisitorRepositoryImpl) FindExpiredIds(now uint) []uint {
+	var ids []uint
+	result := r.Db.Model(&model.Visitor{}).
+		Where("status IN ? AND expected_until <= ?", []uint{model.VisitorStatusRegistered, model.VisitorStatusCheckedIn}, now).
+		Pluck("id", &ids)
+	utils.ErrorPanic(result.Error)
+	return ids
+}
+
+// CardNoExists 判断卡号是否已被使用
+func (r *VisitorRepositoryImpl) CardNoExists(cardNo string) bool {
+	return checkCardNoAvailable(r.Db, cardNo, 0) != nil
+}
+
+// checkCardNoAvailable 检查卡号未被除 uniqueId 外的凭证使用，且不在挂失黑名单中
+func checkCardNoAvailable(tx *gorm.DB, cardNo string, uniqueId uint) error {
+	var count int64
+	err := tx.Model(&model.Credential{}).Where("card_no = ? AND unique_id <> ?", cardNo, uniqueId).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("card " + cardNo + " is already in use")
+	}
+	err = tx.Model(&model.LostCard{}).Where("card_no = ?", cardNo).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("card " + cardNo + " is reported lost")
+	}
+	return nil
+}
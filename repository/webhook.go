@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// WebhookRepository Webhook 订阅的数据访问接口
+type WebhookRepository interface {
+	Save(webhook model.Webhook) (*model.Webhook, error)
+	Update(webhook model.Webhook) error
+	Delete(id uint)
+	FindById(id uint) (*model.Webhook, error)
+	FindAll() []*model.Webhook
+	FindEnabled() []*model.Webhook
+}
+
+// WebhookRepositoryImpl Webhook 订阅的数据访问实现
+type WebhookRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewWebhookRepositoryImpl 创建并返回一个新的 WebhookRepositoryImpl 实例
+func NewWebhookRepositoryImpl(Db *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImpl{Db: Db}
+}
+
+// Save 新增 Webhook 订阅
+func (r *WebhookRepositoryImpl) Save(webhook model.Webhook) (*model.Webhook, error) {
+	result := r.Db.Create(&webhook)
+	return &webhook, result.Error
+}
+
+// Update 更新 Webhook 订阅
+func (r *WebhookRepositoryImpl) Update(webhook model.Webhook) error {
+	result := r.Db.Save(&webhook)
+	return result.Error
+}
+
+// Delete 删除 Webhook 订阅
+func (r *WebhookRepositoryImpl) Delete(id uint) {
+	result := r.Db.Delete(&model.Webhook{}, id)
+	utils.ErrorPanic(result.Error)
+}
+
+// FindById 根据 ID 查询 Webhook 订阅
+func (r *WebhookRepositoryImpl) FindById(id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	result := r.Db.First(&webhook, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &webhook, nil
+}
+
+// FindAll 查询所有 Webhook 订阅
+func (r *WebhookRepositoryImpl) FindAll() []*model.Webhook {
+	var webhooks []*model.Webhook
+	result := r.Db.Order("id").Find(&webhooks)
+	utils.ErrorPanic(result.Error)
+	return webhooks
+}
+
+// FindEnabled 查询所有已启用的 Webhook 订阅
+func (r *WebhookRepositoryImpl) FindEnabled() []*model.Webhook {
+	var webhooks []*model.Webhook
+	result := r.Db.Where("enable = ?", 1).Order("id").Find(&webhooks)
+	utils.ErrorPanic(result.Error)
+	return webhooks
+}
+
+// WebhookDeliveryRepository Webhook 投递记录的数据访问接口
+type WebhookDeliveryRepository interface {
+	Save(delivery model.WebhookDelivery) (*model.WebhookDelivery, error)
+	Update(delivery model.WebhookDelivery) error
+	FindById(id uint) (*model.WebhookDelivery, error)
+	FindByWebhookId(webhookId uint, pg *utils.Pagination) []*model.WebhookDelivery
+	FindDue(now uint, limit int) []*model.WebhookDelivery
+	DeleteByWebhookId(webhookId uint)
+	DeleteBefore(before time.Time)
+}
+
+// WebhookDeliveryRepositoryImpl Webhook 投递记录的数据访问实现
+type WebhookDeliveryRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewWebhookDeliveryRepositoryImpl 创建并返回一个新的 WebhookDeliveryRepositoryImpl 实例
+func NewWebhookDeliveryRepositoryImpl(Db *gorm.DB) WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryImpl{Db: Db}
+}
+
+// Save 新增投递记录
+func (r *WebhookDeliveryRepositoryImpl) Save(delivery model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	result := r.Db.Create(&delivery)
+	return &delivery, result.Error
+}
+
+// Update 更新投递记录
+func (r *WebhookDeliveryRepositoryImpl) Update(delivery model.WebhookDelivery) error {
+	result := r.Db.Save(&delivery)
+	return result.Error
+}
+
+// FindById 根据 ID 查询投递记录
+func (r *WebhookDeliveryRepositoryImpl) FindById(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	result := r.Db.First(&delivery, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &delivery, nil
+}
+
+// FindByWebhookId 分页查询指定 Webhook 的投递记录，按时间倒序
+func (r *WebhookDeliveryRepositoryImpl) FindByWebhookId(webhookId uint, pg *utils.Pagination) []*model.WebhookDelivery {
+	var deliveries []*model.WebhookDelivery
+	result := r.Db.Where("webhook_id = ?", webhookId).Order("id desc").Scopes(pg.Paginate()).Find(&deliveries)
+	utils.ErrorPanic(result.Error)
+	return deliveries
+}
+
+// FindDue 查询到期需要投递的记录
+func (r *WebhookDeliveryRepositoryImpl) FindDue(now uint, limit int) []*model.WebhookDelivery {
+	var deliveries []*model.WebhookDelivery
+	result := r.Db.Where("status = ? AND next_attempt <= ?", model.WebhookDeliveryPending, now).
+		Order("id").Limit(limit).Find(&deliveries)
+	utils.ErrorPanic(result.Error)
+	return deliveries
+}
+
+// DeleteByWebhookId 删除指定 Webhook 的全部投递记录
+func (r *WebhookDeliveryRepositoryImpl) DeleteByWebhookId(webhookId uint) {
+	result := r.Db.Unscoped().Where("webhook_id = ?", webhookId).Delete(&model.WebhookDelivery{})
+	utils.ErrorPanic(result.Error)
+}
+
+// DeleteBefore 清理指定时间之前已结束（成功或失败）的投递记录
+func (r *WebhookDeliveryRepositoryImpl) DeleteBefore(before time.Time) {
+	result := r.Db.Unscoped().
+		Where("status <> ? AND updated_at < ?", model.WebhookDeliveryPending, before).
+		Delete(&model.WebhookDelivery{})
+	utils.ErrorPanic(result.Error)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// WiegandRuleRepository 韦根规则（控制器的 ValidWiegandRule 表）的数据访问接口
+type WiegandRuleRepository interface {
+	Save(rule model.ValidWiegandRule) (*model.ValidWiegandRule, error)
+	Update(rule model.ValidWiegandRule) error
+	Delete(id uint) error
+	FindById(id uint) (*model.ValidWiegandRule, error)
+	FindAll() []*model.ValidWiegandRule
+}
+
+// WiegandRuleRepositoryImpl 韦根规则的数据访问实现
+type WiegandRuleRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+// NewWiegandRuleRepositoryImpl 创建并返回一个新的 WiegandRuleRepositoryImpl 实例
+func NewWiegandRuleRepositoryImpl(Db *gorm.DB) WiegandRuleRepository {
+	return &WiegandRuleRepositoryImpl{Db: Db}
+}
+
+// Save 新增韦根规则
+func (r *WiegandRuleRepositoryImpl) Save(rule model.ValidWiegandRule) (*model.ValidWiegandRule, error) {
+	err := r.Db.Create(&rule).Error
+	return &rule, err
+}
+
+// Update 更新韦根规则
+func (r *WiegandRuleRepositoryImpl) Update(rule model.ValidWiegandRule) error {
+	return r.Db.Save(&rule).Error
+}
+
+// Delete 删除韦根规则
+func (r *WiegandRuleRepositoryImpl) Delete(id uint) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		var rule model.ValidWiegandRule
+		if err := tx.First(&rule, id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rule).Error
+	})
+}
+
+// FindById 根据 ID 查询韦根规则
+func (r *WiegandRuleRepositoryImpl) FindById(id uint) (*model.ValidWiegandRule, error) {
+	var rule model.ValidWiegandRule
+	result := r.Db.First(&rule, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rule, nil
+}
+
+// FindAll 查询所有韦根规则
+func (r *WiegandRuleRepositoryImpl) FindAll() []*model.ValidWiegandRule {
+	var rules []*model.ValidWiegandRule
+	result := r.Db.Order("id").Find(&rules)
+	utils.ErrorPanic(result.Error)
+	return rules
+}
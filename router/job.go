@@ -0,0 +1,29 @@
+package router
+
+import (
+	"log"
+	"os"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobScheduler 后台定时任务调度器（支持秒级精度），在 CreateHttpServ 中启动
+var JobScheduler *cron.Cron
+
+// CreateJobScheduler 创建后台定时任务调度器
+// 任务发生 panic 时记录日志并恢复，上一次未执行完的任务不会重复启动
+func CreateJobScheduler() {
+	logger := cron.PrintfLogger(log.New(os.Stdout, "job: ", log.LstdFlags))
+	JobScheduler = cron.New(
+		cron.WithSeconds(),
+		cron.WithChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)),
+	)
+}
+
+// AddJob 注册后台定时任务
+// spec 为 cron 表达式（秒 分 时 日 月 周）或 "@every 5s" 形式
+func AddJob(spec string, name string, job func()) {
+	if _, err := JobScheduler.AddFunc(spec, job); err != nil {
+		log.Printf("❌ 添加后台任务 %s 失败: %v", name, err)
+	}
+}
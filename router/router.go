@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/spf13/cast"
 
 	"hoyang/ownsa/controller"
 	"hoyang/ownsa/data/response"
@@ -25,12 +26,31 @@ import (
 
 // WebControllerGroup 用于定义各个控制器的集合
 type WebControllerGroup struct {
-	ControllerUserController   *controller.ControllerUserController   // 用户管理控制器
-	CredentialController       *controller.CredentialController       // 认证控制器
-	DeviceController           *controller.DeviceController           // 设备控制器
-	EventMessageDataController *controller.EventMessageDataController // 事件消息控制器
-	PeopleController           *controller.PeopleController           // 人员控制器
-	DepartmentController       *controller.DepartmentController       // 部门控制器
+	ControllerUserController     *controller.ControllerUserController     // 用户管理控制器
+	CredentialController         *controller.CredentialController         // 认证控制器
+	DeviceController             *controller.DeviceController             // 设备控制器
+	EventMessageDataController   *controller.EventMessageDataController   // 事件消息控制器
+	PeopleController             *controller.PeopleController             // 人员控制器
+	DepartmentController         *controller.DepartmentController         // 部门控制器
+	WebhookController            *controller.WebhookController            // Webhook 订阅控制器
+	MqttController               *controller.MqttController               // MQTT 客户端控制器
+	SyslogController             *controller.SyslogController             // Syslog 转发控制器
+	AuditLogController           *controller.AuditLogController           // 操作审计日志控制器
+	StatisticsController         *controller.StatisticsController         // 统计分析控制器
+	AttendanceController         *controller.AttendanceController         // 考勤控制器
+	OccupancyController          *controller.OccupancyController          // 区域人数统计控制器
+	BulkController               *controller.BulkController               // 批量操作控制器
+	CredentialValidityController *controller.CredentialValidityController // 凭证有效期控制器
+	VisitorController            *controller.VisitorController            // 访客管理控制器
+	PeoplePhotoController        *controller.PeoplePhotoController        // 人员照片控制器
+	SearchController             *controller.SearchController             // 全文检索控制器
+	WiegandController            *controller.WiegandController            // 韦根规则控制器
+	VeinTemplateController       *controller.VeinTemplateController       // 指静脉模板控制器
+	LostCardController           *controller.LostCardController           // 挂失卡控制器
+	EnrollmentController         *controller.EnrollmentController         // 刷卡登记控制器
+	DataQualityController        *controller.DataQualityController        // 数据质量报告控制器
+	CredentialUsageController    *controller.CredentialUsageController    // 凭证使用情况控制器
+	AuditMiddleware              gin.HandlerFunc                          // 操作审计中间件
 }
 
 var WebController *WebControllerGroup // WebControllerGroup 实例
@@ -75,12 +95,16 @@ func CreateHttpServ(confEnv *map[string]string, out *log.Logger) error {
 
 	// 配置数据库
 	database.SetupDatabase(confEnv, out)
+	database.MigrateExtension()
 
 	// 设置路由
 	routes := SetupRouter(server)
 
 	// 创建并注册控制器
-	CreateWebController()
+	CreateWebController(confEnv)
+
+	// 记录修改操作的审计日志，需在注册路由之前设置
+	routes.Use(WebController.AuditMiddleware)
 
 	// 注册各个控制器的路由
 	RegisterControllerUserRoutes(confEnv, routes, WebController.ControllerUserController)
@@ -89,6 +113,27 @@ func CreateHttpServ(confEnv *map[string]string, out *log.Logger) error {
 	RegisterEventMessageDataRoutes(confEnv, routes, WebController.EventMessageDataController)
 	RegisterCredentialRoutes(confEnv, routes, WebController.CredentialController)
 	RegisterDeviceRoutes(confEnv, routes, WebController.DeviceController)
+	RegisterWebhookRoutes(confEnv, routes, WebController.WebhookController)
+	RegisterMqttRoutes(confEnv, routes, WebController.MqttController)
+	RegisterSyslogRoutes(confEnv, routes, WebController.SyslogController)
+	RegisterAuditLogRoutes(confEnv, routes, WebController.AuditLogController)
+	RegisterStatisticsRoutes(confEnv, routes, WebController.StatisticsController)
+	RegisterAttendanceRoutes(confEnv, routes, WebController.AttendanceController)
+	RegisterOccupancyRoutes(confEnv, routes, WebController.OccupancyController)
+	RegisterBulkRoutes(confEnv, routes, WebController.BulkController)
+	RegisterCredentialValidityRoutes(confEnv, routes, WebController.CredentialValidityController)
+	RegisterVisitorRoutes(confEnv, routes, WebController.VisitorController)
+	RegisterPeoplePhotoRoutes(confEnv, routes, WebController.PeoplePhotoController)
+	RegisterSearchRoutes(confEnv, routes, WebController.SearchController)
+	RegisterWiegandRoutes(confEnv, routes, WebController.WiegandController)
+	RegisterVeinTemplateRoutes(confEnv, routes, WebController.VeinTemplateController)
+	RegisterLostCardRoutes(confEnv, routes, WebController.LostCardController)
+	RegisterEnrollmentRoutes(confEnv, routes, WebController.EnrollmentController)
+	RegisterDataQualityRoutes(confEnv, routes, WebController.DataQualityController)
+	RegisterCredentialUsageRoutes(confEnv, routes, WebController.CredentialUsageController)
+
+	// 启动后台定时任务
+	JobScheduler.Start()
 
 	// 配置服务地址，根据平台确定
 	servAddr := ":8080"
@@ -184,8 +229,16 @@ func SetupRouter(service *gin.Engine) *gin.Engine {
 	return service
 }
 
+// 后台同步事件消息的默认间隔（秒）
+const defaultEventSyncInterval = 5
+
+// 设备指静脉模板的默认容量
+const defaultVeinTemplateCapacity = 3000
+
 // CreateWebController 创建 Web 控制器实例
-func CreateWebController() {
+func CreateWebController(confEnv *map[string]string) {
+	// 后台定时任务调度器
+	CreateJobScheduler()
 	// 验证器
 	validate := validator.New()
 	// 创建各个数据仓库
@@ -203,7 +256,44 @@ func CreateWebController() {
 	outputPropRepository := repository.NewOutputPropRepositoryImpl(database.DB.DbConfig)
 	schedGroupRepository := repository.NewSchedGroupRepositoryImpl(database.DB.DbOtherGroup)
 	accessGroupRepository := repository.NewAccessGroupRepositoryImpl(database.DB.DbOtherGroup)
+	eventCursorRepository := repository.NewEventCursorRepositoryImpl(database.DB.DbEventMessage)
+	webhookRepository := repository.NewWebhookRepositoryImpl(database.DB.DbConfig)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepositoryImpl(database.DB.DbEventMessage)
+	mqttConfigRepository := repository.NewMqttConfigRepositoryImpl(database.DB.DbConfig)
+	syslogConfigRepository := repository.NewSyslogConfigRepositoryImpl(database.DB.DbConfig)
+	syslogQueueRepository := repository.NewSyslogQueueRepositoryImpl(database.DB.DbEventMessage)
+	auditLogRepository := repository.NewAuditLogRepositoryImpl(database.DB.DbEventMessage)
+	statisticsRepository := repository.NewStatisticsRepositoryImpl(database.DB.DbEventMessage)
+	peopleDirectoryRepository := repository.NewPeopleDirectoryRepositoryImpl(database.DB.DbCredential)
+	shiftRepository := repository.NewShiftRepositoryImpl(database.DB.DbConfig)
+	shiftAssignmentRepository := repository.NewShiftAssignmentRepositoryImpl(database.DB.DbConfig)
+	attendanceReaderRepository := repository.NewAttendanceReaderRepositoryImpl(database.DB.DbConfig)
+	attendanceRecordRepository := repository.NewAttendanceRecordRepositoryImpl(database.DB.DbEventMessage)
+	attendanceCorrectionRepository := repository.NewAttendanceCorrectionRepositoryImpl(database.DB.DbEventMessage)
+	areaRepository := repository.NewAreaRepositoryImpl(database.DB.DbConfig)
+	occupancyRepository := repository.NewOccupancyRepositoryImpl(database.DB.DbEventMessage)
+	peopleImportRepository := repository.NewPeopleImportRepositoryImpl(database.DB.DbCredential)
+	importBatchRepository := repository.NewImportBatchRepositoryImpl(database.DB.DbCredential)
+	bulkRepository := repository.NewBulkRepositoryImpl(database.DB.DbCredential)
+	groupDirectoryRepository := repository.NewGroupDirectoryRepositoryImpl(database.DB.DbOtherGroup)
+	credentialValidityRepository := repository.NewCredentialValidityRepositoryImpl(database.DB.DbCredential)
+	visitorRepository := repository.NewVisitorRepositoryImpl(database.DB.DbCredential)
+	peoplePhotoRepository := repository.NewPeoplePhotoRepositoryImpl(database.DB.DbCredential)
+	searchRepository := repository.NewSearchRepositoryImpl(database.DB.DbCredential)
+	departmentTreeRepository := repository.NewDepartmentTreeRepositoryImpl(database.DB.DbCredential)
+	wiegandRuleRepository := repository.NewWiegandRuleRepositoryImpl(database.DB.DbOtherGroup)
+	veinTemplateRepository := repository.NewVeinTemplateRepositoryImpl(database.DB.DbCredential)
+	lostCardRepository := repository.NewLostCardRepositoryImpl(database.DB.DbCredential)
+	enrollmentRepository := repository.NewEnrollmentRepositoryImpl(database.DB.DbCredential)
+	credentialUsageRepository := repository.NewCredentialUsageRepositoryImpl(database.DB.DbEventMessage)
 	// 创建各个服务实例
+	peoplePhotoService := service.NewPeoplePhotoServiceImpl(peoplePhotoRepository, peopleDirectoryRepository)
+	departmentTreeService := service.NewDepartmentTreeServiceImpl(
+		departmentTreeRepository,
+		peopleDirectoryRepository,
+		groupDirectoryRepository,
+		validate,
+	)
 	controllerUserService := service.NewControllerUserServiceImpl(
 		controllerUserRepository,
 		controllerPropRepository,
@@ -216,6 +306,25 @@ func CreateWebController() {
 		accessGroupRepository,
 		departmentRepository,
 		validate)
+	peopleImportService := service.NewPeopleImportServiceImpl(
+		peopleImportRepository,
+		importBatchRepository,
+		peopleDirectoryRepository,
+		groupDirectoryRepository,
+		credentialValidityRepository,
+		credentialUsageRepository,
+		peoplePhotoService,
+		departmentTreeService,
+	)
+	bulkService := service.NewBulkServiceImpl(
+		bulkRepository,
+		peopleDirectoryRepository,
+		groupDirectoryRepository,
+		credentialUsageRepository,
+		peoplePhotoService,
+		departmentTreeService,
+		validate,
+	)
 	departmentService := service.NewDepartmentServiceImpl(
 		departmentRepository,
 		validate)
@@ -242,15 +351,195 @@ func CreateWebController() {
 		outputPropRepository,
 		validate,
 	)
+	webhookService := service.NewWebhookServiceImpl(
+		webhookRepository,
+		webhookDeliveryRepository,
+		validate,
+	)
+	mqttService := service.NewMqttServiceImpl(mqttConfigRepository, validate)
+	statisticsService := service.NewStatisticsServiceImpl(statisticsRepository, eventCursorRepository)
+	attendanceService := service.NewAttendanceServiceImpl(
+		shiftRepository,
+		shiftAssignmentRepository,
+		attendanceReaderRepository,
+		attendanceRecordRepository,
+		attendanceCorrectionRepository,
+		peopleDirectoryRepository,
+		validate,
+	)
+	syslogService := service.NewSyslogServiceImpl(
+		syslogConfigRepository,
+		syslogQueueRepository,
+		validate,
+	)
+
+	// 韦根规则：事件卡号按控制器中位数相同的规则解码
+	wiegandService := service.NewWiegandServiceImpl(wiegandRuleRepository, eventCursorRepository, validate)
+
+	// 事件中心：分发新同步的事件消息，附带事件人员的照片和韦根解码结果
+	eventHub := service.NewEventHub(eventCursorRepository)
+	eventHub.SetPhotoResolver(peoplePhotoService.ThumbnailUrl)
+	eventHub.SetWiegandDecoder(wiegandService.DecodeEvent)
+	eventHub.Subscribe("webhook", webhookService.Enqueue)
+	eventHub.Subscribe("mqtt", mqttService.PublishEvent)
+	eventHub.Subscribe("syslog", syslogService.Enqueue)
+
+	// 区域人数统计：根据允许通行事件更新区域内人员，超员报警发布到事件中心
+	occupancyService := service.NewOccupancyServiceImpl(
+		areaRepository,
+		occupancyRepository,
+		peopleDirectoryRepository,
+		peoplePhotoService,
+		eventHub,
+		validate,
+	)
+	eventHub.Subscribe("occupancy", occupancyService.Track)
+
+	// 凭证有效期：超出有效期的凭证自动停用，启用或停用时发布到事件中心
+	credentialValidityService := service.NewCredentialValidityServiceImpl(
+		credentialValidityRepository,
+		peopleDirectoryRepository,
+		eventHub,
+		validate,
+	)
+
+	// 访客管理：签到、签离和过期注销发布到事件中心
+	visitorService := service.NewVisitorServiceImpl(
+		visitorRepository,
+		peopleDirectoryRepository,
+		groupDirectoryRepository,
+		eventHub,
+		validate,
+	)
+
+	// 挂失卡：黑名单中的卡再次刷卡时发布报警到事件中心
+	lostCardService := service.NewLostCardServiceImpl(
+		lostCardRepository,
+		peopleDirectoryRepository,
+		departmentTreeService,
+		eventHub,
+		validate,
+	)
+	eventHub.Subscribe("lost card", lostCardService.Check)
+
+	// 刷卡登记：登记会话的读卡器上刷的卡创建为凭证
+	enrollmentService := service.NewEnrollmentServiceImpl(
+		enrollmentRepository,
+		eventCursorRepository,
+		groupDirectoryRepository,
+		departmentTreeService,
+		controller.DataSync,
+		validate,
+	)
+	eventHub.Subscribe("enrollment", enrollmentService.Capture)
+
+	// 数据质量报告：重复卡号、没有凭证的人员、没有门禁组的凭证和不存在的引用
+	dataQualityService := service.NewDataQualityServiceImpl(
+		peopleDirectoryRepository,
+		groupDirectoryRepository,
+		departmentTreeRepository,
+		credentialValidityRepository,
+	)
+
+	// 凭证使用情况：汇总允许通行事件，查询和停用长期未使用的凭证
+	credentialUsageService := service.NewCredentialUsageServiceImpl(
+		credentialUsageRepository,
+		eventCursorRepository,
+		peopleDirectoryRepository,
+		credentialValidityRepository,
+		bulkRepository,
+		validate,
+	)
+
+	// 全文检索：人员、凭证和部门变化时由触发器记录，查询前和后台任务增量更新索引
+	searchService := service.NewSearchServiceImpl(
+		searchRepository,
+		peopleDirectoryRepository,
+		peoplePhotoService,
+		validate,
+	)
+
+	// 指静脉模板：使用 .env 中的密钥加密保存，VeinTemplateCapacity 为设备的模板容量
+	veinTemplateKey, err := service.LoadVeinTemplateKey()
+	utils.ErrorPanic(err)
+	veinTemplateCapacity := int64(defaultVeinTemplateCapacity)
+	if value, ok := (*confEnv)["VeinTemplateCapacity"]; ok {
+		veinTemplateCapacity = cast.ToInt64(value)
+	}
+	veinTemplateService := service.NewVeinTemplateServiceImpl(
+		veinTemplateRepository,
+		peopleDirectoryRepository,
+		veinTemplateKey,
+		veinTemplateCapacity,
+		validate,
+	)
+
+	// 操作审计：记录后发布到事件中心
+	auditLogService := service.NewAuditLogServiceImpl(auditLogRepository, eventHub)
+
+	// 注册后台任务
+	// 事件中心每 5 秒分发本地新事件；EventSyncInterval 为后台从后端同步事件的间隔（秒），为 0 时只在请求同步时读取
+	AddJob("@every 5s", "event hub", eventHub.Poll)
+	eventSyncInterval := defaultEventSyncInterval
+	if value, ok := (*confEnv)["EventSyncInterval"]; ok {
+		eventSyncInterval = cast.ToInt(value)
+	}
+	if eventSyncInterval > 0 {
+		AddJob(fmt.Sprintf("@every %ds", eventSyncInterval), "event sync", eventMessageDataService.Sync)
+	}
+	AddJob("@every 5s", "webhook deliver", webhookService.Deliver)
+	AddJob("@daily", "webhook purge", webhookService.Purge)
+	AddJob("@every 1s", "mqtt", mqttService.Tick)
+	AddJob("@every 2s", "syslog deliver", syslogService.Deliver)
+	AddJob("@every 30s", "statistics aggregate", statisticsService.Aggregate)
+	AddJob("@every 30s", "credential usage aggregate", credentialUsageService.Aggregate)
+	AddJob("@hourly", "credential usage track", credentialUsageService.Track)
+	AddJob("@every 10m", "attendance calculate", attendanceService.CalculateRecent)
+	AddJob("@every 1m", "credential validity", func() {
+		if credentialValidityService.Check() > 0 {
+			controller.DataSync()
+		}
+	})
+	AddJob("@daily", "people photo purge", peoplePhotoService.Purge)
+	AddJob("@every 1m", "visitor expire", func() {
+		if visitorService.Expire() > 0 {
+			controller.DataSync()
+		}
+	})
+	AddJob("@every 1m", "search index", searchService.Sync)
+	AddJob("@every 1m", "department access", func() {
+		if departmentTreeService.Reconcile() > 0 {
+			controller.DataSync()
+		}
+	})
 
 	WebController = &WebControllerGroup{}
 
 	WebController.ControllerUserController = controller.NewControllerUserController(controllerUserService)
 	WebController.EventMessageDataController = controller.NewEventMessageDataController(eventMessageDataService)
-	WebController.PeopleController = controller.NewPeopleController(peopleService)
-	WebController.DepartmentController = controller.NewDepartmentController(departmentService)
-	WebController.CredentialController = controller.NewCredentialController(credentialService)
+	WebController.PeopleController = controller.NewPeopleController(peopleService, peopleImportService)
+	WebController.DepartmentController = controller.NewDepartmentController(departmentService, departmentTreeService)
+	WebController.CredentialController = controller.NewCredentialController(credentialService, bulkService)
 	WebController.DeviceController = controller.NewDeviceController(deviceService, controllerUserService)
+	WebController.WebhookController = controller.NewWebhookController(webhookService)
+	WebController.MqttController = controller.NewMqttController(mqttService)
+	WebController.SyslogController = controller.NewSyslogController(syslogService)
+	WebController.AuditLogController = controller.NewAuditLogController(auditLogService)
+	WebController.StatisticsController = controller.NewStatisticsController(statisticsService, controllerUserService)
+	WebController.AttendanceController = controller.NewAttendanceController(attendanceService, controllerUserService)
+	WebController.OccupancyController = controller.NewOccupancyController(occupancyService)
+	WebController.BulkController = controller.NewBulkController(bulkService)
+	WebController.CredentialValidityController = controller.NewCredentialValidityController(credentialValidityService)
+	WebController.VisitorController = controller.NewVisitorController(visitorService)
+	WebController.PeoplePhotoController = controller.NewPeoplePhotoController(peoplePhotoService)
+	WebController.SearchController = controller.NewSearchController(searchService)
+	WebController.WiegandController = controller.NewWiegandController(wiegandService)
+	WebController.VeinTemplateController = controller.NewVeinTemplateController(veinTemplateService)
+	WebController.LostCardController = controller.NewLostCardController(lostCardService)
+	WebController.EnrollmentController = controller.NewEnrollmentController(enrollmentService)
+	WebController.DataQualityController = controller.NewDataQualityController(dataQualityService)
+	WebController.CredentialUsageController = controller.NewCredentialUsageController(credentialUsageService)
+	WebController.AuditMiddleware = middleware.AuditMiddleware(auditLogService.Record)
 }
 
 // 注册用户相关的路由
@@ -298,11 +587,19 @@ func RegisterDepartmentRoutes(confEnv *map[string]string, service *gin.Engine, d
 		departmentPrivateRouter.GET("", departmentController.FindAll)
 		// 根据 ID 获取部门信息
 		departmentPrivateRouter.GET("/:departmentId", departmentController.FindById)
+		// 获取部门树
+		departmentPrivateRouter.GET("/tree", departmentController.Tree)
 		// 创建部门
 		departmentPrivateRouter.POST("", departmentController.Create)
-		// // 更新部门信息
-		// departmentPrivateRouter.PATCH("/:departmentId", departmentController.Update)
-		// 删除部门
+		// 创建下级部门
+		departmentPrivateRouter.POST("/:departmentId/children", departmentController.CreateChild)
+		// 修改部门名称或上级部门
+		departmentPrivateRouter.PATCH("/:departmentId", departmentController.Update)
+		// 获取部门门禁组
+		departmentPrivateRouter.GET("/:departmentId/access", departmentController.FindAccess)
+		// 设置部门门禁组
+		departmentPrivateRouter.PUT("/:departmentId/access", departmentController.SetAccess)
+		// 删除部门，人员和下级部门转移到 reassignTo
 		departmentPrivateRouter.DELETE("/:departmentId", departmentController.Delete)
 	}
 }
@@ -325,8 +622,14 @@ func RegisterPeopleRoutes(confEnv *map[string]string, service *gin.Engine, peopl
 		peoplePrivateRouter.PATCH("/:peopleId", peopleController.Update)
 		// 删除人员
 		peoplePrivateRouter.DELETE("/:peopleId", peopleController.Delete)
-		// csv批量导入员工信息
+		// 上传 CSV/XLSX 文件批量导入员工信息
 		peoplePrivateRouter.POST("/import", peopleController.Import)
+		// 导出员工信息（CSV/XLSX），可修改后重新导入
+		peoplePrivateRouter.GET("/export", peopleController.Export)
+		// 获取导入批次
+		peoplePrivateRouter.GET("/import/batch", peopleController.FindAllImportBatches)
+		// 回滚导入批次
+		peoplePrivateRouter.POST("/import/batch/:batchId/rollback", peopleController.RollbackImportBatch)
 	}
 }
 
@@ -457,3 +760,409 @@ func RegisterDeviceRoutes(confEnv *map[string]string, service *gin.Engine, devic
 
 	}
 }
+
+// 注册 Webhook 订阅相关的路由
+func RegisterWebhookRoutes(confEnv *map[string]string, service *gin.Engine, webhookController *controller.WebhookController) {
+	router := service.Group("/api")
+	webhookPrivateRouter := router.Group("/webhook")
+
+	// 私有路由：需要身份验证
+	webhookPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取所有 Webhook 订阅
+		webhookPrivateRouter.GET("", webhookController.FindAll)
+		// 根据 ID 获取 Webhook 订阅
+		webhookPrivateRouter.GET("/:webhookId", webhookController.FindById)
+		// 创建 Webhook 订阅
+		webhookPrivateRouter.POST("", webhookController.Create)
+		// 更新 Webhook 订阅
+		webhookPrivateRouter.PATCH("/:webhookId", webhookController.Update)
+		// 删除 Webhook 订阅
+		webhookPrivateRouter.DELETE("/:webhookId", webhookController.Delete)
+		// 发送测试消息
+		webhookPrivateRouter.POST("/:webhookId/test", webhookController.Test)
+		// 获取投递记录
+		webhookPrivateRouter.GET("/:webhookId/delivery", webhookController.FindDeliveries)
+		// 重新投递
+		webhookPrivateRouter.POST("/delivery/:deliveryId/retry", webhookController.Retry)
+	}
+}
+
+// 注册 MQTT 客户端相关的路由
+func RegisterMqttRoutes(confEnv *map[string]string, service *gin.Engine, mqttController *controller.MqttController) {
+	router := service.Group("/api")
+	mqttPrivateRouter := router.Group("/mqtt")
+
+	// 私有路由：需要身份验证
+	mqttPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取 MQTT 配置
+		mqttPrivateRouter.GET("", mqttController.FindConfig)
+		// 更新 MQTT 配置
+		mqttPrivateRouter.PATCH("", mqttController.UpdateConfig)
+		// 获取连接状态
+		mqttPrivateRouter.GET("/status", mqttController.Status)
+		// 发布测试消息
+		mqttPrivateRouter.POST("/test", mqttController.Test)
+	}
+}
+
+// 注册 Syslog 转发相关的路由
+func RegisterSyslogRoutes(confEnv *map[string]string, service *gin.Engine, syslogController *controller.SyslogController) {
+	router := service.Group("/api")
+	syslogPrivateRouter := router.Group("/syslog")
+
+	// 私有路由：需要身份验证
+	syslogPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取 Syslog 转发配置
+		syslogPrivateRouter.GET("", syslogController.FindConfig)
+		// 更新 Syslog 转发配置
+		syslogPrivateRouter.PATCH("", syslogController.UpdateConfig)
+		// 获取转发状态
+		syslogPrivateRouter.GET("/status", syslogController.Status)
+		// 发送测试消息
+		syslogPrivateRouter.POST("/test", syslogController.Test)
+	}
+}
+
+// 注册操作审计日志相关的路由
+func RegisterAuditLogRoutes(confEnv *map[string]string, service *gin.Engine, auditLogController *controller.AuditLogController) {
+	router := service.Group("/api")
+	auditLogPrivateRouter := router.Group("/audit")
+
+	// 私有路由：需要身份验证
+	auditLogPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 分页获取操作审计日志
+		auditLogPrivateRouter.GET("", auditLogController.FindAll)
+	}
+}
+
+// 注册统计分析相关的路由
+func RegisterStatisticsRoutes(confEnv *map[string]string, service *gin.Engine, statisticsController *controller.StatisticsController) {
+	router := service.Group("/api")
+	statisticsPrivateRouter := router.Group("/statistics")
+
+	// 私有路由：需要身份验证
+	statisticsPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 事件数汇总
+		statisticsPrivateRouter.GET("/summary", statisticsController.Summary)
+		// 按门汇总
+		statisticsPrivateRouter.GET("/door", statisticsController.CountByDoor)
+		// 最繁忙的门
+		statisticsPrivateRouter.GET("/door/top", statisticsController.BusiestDoors)
+		// 按读卡器汇总
+		statisticsPrivateRouter.GET("/reader", statisticsController.CountByReader)
+		// 按部门汇总
+		statisticsPrivateRouter.GET("/department", statisticsController.CountByDepartment)
+		// 小时、星期热力图
+		statisticsPrivateRouter.GET("/heatmap", statisticsController.Heatmap)
+		// 拒绝次数最多的卡
+		statisticsPrivateRouter.GET("/card/denied", statisticsController.TopDeniedCards)
+		// 重新汇总
+		statisticsPrivateRouter.POST("/rebuild", statisticsController.Rebuild)
+	}
+}
+
+// 注册考勤相关的路由
+func RegisterAttendanceRoutes(confEnv *map[string]string, service *gin.Engine, attendanceController *controller.AttendanceController) {
+	router := service.Group("/api")
+	attendancePrivateRouter := router.Group("/attendance")
+
+	// 私有路由：需要身份验证
+	attendancePrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取所有班次
+		attendancePrivateRouter.GET("/shift", attendanceController.FindAllShifts)
+		// 创建班次
+		attendancePrivateRouter.POST("/shift", attendanceController.CreateShift)
+		// 更新班次
+		attendancePrivateRouter.PATCH("/shift/:shiftId", attendanceController.UpdateShift)
+		// 删除班次
+		attendancePrivateRouter.DELETE("/shift/:shiftId", attendanceController.DeleteShift)
+		// 获取所有班次分配
+		attendancePrivateRouter.GET("/assignment", attendanceController.FindAllAssignments)
+		// 分配班次
+		attendancePrivateRouter.POST("/assignment", attendanceController.CreateAssignment)
+		// 删除班次分配
+		attendancePrivateRouter.DELETE("/assignment/:assignmentId", attendanceController.DeleteAssignment)
+		// 获取考勤读卡器
+		attendancePrivateRouter.GET("/reader", attendanceController.FindReaders)
+		// 设置考勤读卡器
+		attendancePrivateRouter.PUT("/reader", attendanceController.UpdateReaders)
+		// 获取每日考勤结果
+		attendancePrivateRouter.GET("/record", attendanceController.FindRecords)
+		// 重新计算考勤
+		attendancePrivateRouter.POST("/calculate", attendanceController.Calculate)
+		// 获取人工更正记录
+		attendancePrivateRouter.GET("/correction", attendanceController.FindCorrections)
+		// 人工更正考勤
+		attendancePrivateRouter.POST("/correction", attendanceController.CreateCorrection)
+		// 月度考勤汇总
+		attendancePrivateRouter.GET("/report", attendanceController.MonthlyReport)
+		// 导出月度考勤汇总
+		attendancePrivateRouter.GET("/report/export", attendanceController.ExportMonthlyReport)
+	}
+}
+
+// 注册区域人数统计相关的路由
+func RegisterOccupancyRoutes(confEnv *map[string]string, service *gin.Engine, occupancyController *controller.OccupancyController) {
+	router := service.Group("/api")
+	areaPrivateRouter := router.Group("/area")
+	occupancyPrivateRouter := router.Group("/occupancy")
+
+	// 私有路由：需要身份验证
+	areaPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取所有区域
+		areaPrivateRouter.GET("", occupancyController.FindAllAreas)
+		// 创建区域
+		areaPrivateRouter.POST("", occupancyController.CreateArea)
+		// 更新区域
+		areaPrivateRouter.PATCH("/:areaId", occupancyController.UpdateArea)
+		// 删除区域
+		areaPrivateRouter.DELETE("/:areaId", occupancyController.DeleteArea)
+	}
+
+	occupancyPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 各区域当前人数
+		occupancyPrivateRouter.GET("", occupancyController.Summary)
+		// 紧急疏散点名名单
+		occupancyPrivateRouter.GET("/muster", occupancyController.Muster)
+		// 导出紧急疏散点名名单
+		occupancyPrivateRouter.GET("/muster/export", occupancyController.ExportMuster)
+		// 清空区域内人员
+		occupancyPrivateRouter.POST("/reset", occupancyController.Reset)
+		// 人员标记为已离开
+		occupancyPrivateRouter.DELETE("/:peopleId", occupancyController.Remove)
+	}
+}
+
+// 注册批量操作相关的路由
+func RegisterBulkRoutes(confEnv *map[string]string, service *gin.Engine, bulkController *controller.BulkController) {
+	router := service.Group("/api")
+	bulkPrivateRouter := router.Group("/bulk")
+
+	// 私有路由：需要身份验证
+	bulkPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 人员批量操作
+		bulkPrivateRouter.POST("/people", bulkController.People)
+		// 凭证批量操作
+		bulkPrivateRouter.POST("/credential", bulkController.Credentials)
+	}
+}
+
+// 注册凭证有效期相关的路由
+func RegisterCredentialValidityRoutes(confEnv *map[string]string, service *gin.Engine, credentialValidityController *controller.CredentialValidityController) {
+	router := service.Group("/api")
+	credentialPrivateRouter := router.Group("/credential")
+	peoplePrivateRouter := router.Group("/people")
+
+	// 私有路由：需要身份验证
+	credentialPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 即将到期的凭证
+		credentialPrivateRouter.GET("/expiring", credentialValidityController.Expiring)
+		// 设置凭证有效期
+		credentialPrivateRouter.PATCH("/:credentialId/validity", credentialValidityController.UpdateCredential)
+	}
+
+	peoplePrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 设置人员有效期
+		peoplePrivateRouter.PATCH("/:peopleId/validity", credentialValidityController.UpdatePeople)
+	}
+}
+
+// 注册访客管理相关的路由
+func RegisterVisitorRoutes(confEnv *map[string]string, service *gin.Engine, visitorController *controller.VisitorController) {
+	router := service.Group("/api")
+	visitorPrivateRouter := router.Group("/visitor")
+
+	// 私有路由：需要身份验证
+	visitorPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 访客日志
+		visitorPrivateRouter.GET("", visitorController.FindAll)
+		// 根据 ID 获取访客
+		visitorPrivateRouter.GET("/:visitorId", visitorController.FindById)
+		// 预约访客
+		visitorPrivateRouter.POST("", visitorController.Create)
+		// 访客签到
+		visitorPrivateRouter.POST("/:visitorId/checkin", visitorController.CheckIn)
+		// 访客签离
+		visitorPrivateRouter.POST("/:visitorId/checkout", visitorController.CheckOut)
+		// 取消预约
+		visitorPrivateRouter.DELETE("/:visitorId", visitorController.Cancel)
+	}
+}
+
+// 注册人员照片相关的路由
+func RegisterPeoplePhotoRoutes(confEnv *map[string]string, service *gin.Engine, peoplePhotoController *controller.PeoplePhotoController) {
+	router := service.Group("/api")
+	peoplePrivateRouter := router.Group("/people")
+
+	// 私有路由：需要身份验证
+	peoplePrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 查询人员照片地址
+		peoplePrivateRouter.GET("/photo", peoplePhotoController.FindAll)
+		// 照片存储空间使用情况
+		peoplePrivateRouter.GET("/photo/usage", peoplePhotoController.Usage)
+		// 按人员编号批量上传照片（ZIP）
+		peoplePrivateRouter.POST("/photo/zip", peoplePhotoController.UploadZip)
+		// 上传人员照片
+		peoplePrivateRouter.POST("/:peopleId/photo", peoplePhotoController.Upload)
+		// 删除人员照片
+		peoplePrivateRouter.DELETE("/:peopleId/photo", peoplePhotoController.Delete)
+	}
+}
+
+// 注册全文检索相关的路由
+func RegisterSearchRoutes(confEnv *map[string]string, service *gin.Engine, searchController *controller.SearchController) {
+	router := service.Group("/api")
+	searchPrivateRouter := router.Group("/search")
+
+	// 私有路由：需要身份验证
+	searchPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 检索人员、凭证和部门
+		searchPrivateRouter.GET("", searchController.Search)
+	}
+}
+
+// 注册韦根规则相关的路由
+func RegisterWiegandRoutes(confEnv *map[string]string, service *gin.Engine, wiegandController *controller.WiegandController) {
+	router := service.Group("/api")
+	wiegandPrivateRouter := router.Group("/wiegand")
+
+	// 私有路由：需要身份验证
+	wiegandPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 获取控制器中的所有韦根规则
+		wiegandPrivateRouter.GET("", wiegandController.FindAll)
+		// 创建韦根规则
+		wiegandPrivateRouter.POST("", wiegandController.Create)
+		// 获取预置韦根规则
+		wiegandPrivateRouter.GET("/preset", wiegandController.FindPresets)
+		// 解码原始韦根数据
+		wiegandPrivateRouter.POST("/decode", wiegandController.Decode)
+		// 解码事件中的韦根数据
+		wiegandPrivateRouter.GET("/event", wiegandController.DecodeEvents)
+		// 根据 ID 获取韦根规则
+		wiegandPrivateRouter.GET("/:ruleId", wiegandController.FindById)
+		// 修改韦根规则
+		wiegandPrivateRouter.PUT("/:ruleId", wiegandController.Update)
+		// 删除韦根规则
+		wiegandPrivateRouter.DELETE("/:ruleId", wiegandController.Delete)
+	}
+}
+
+// 注册指静脉模板相关的路由
+func RegisterVeinTemplateRoutes(confEnv *map[string]string, service *gin.Engine, veinTemplateController *controller.VeinTemplateController) {
+	router := service.Group("/api")
+	peoplePrivateRouter := router.Group("/people")
+	veinPrivateRouter := router.Group("/vein")
+
+	// 私有路由：需要身份验证
+	peoplePrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 查询人员的模板
+		peoplePrivateRouter.GET("/:peopleId/vein", veinTemplateController.FindByPeopleId)
+		// 登记或替换模板
+		peoplePrivateRouter.PUT("/:peopleId/vein/:fingerIndex", veinTemplateController.Enroll)
+		// 删除模板
+		peoplePrivateRouter.DELETE("/:peopleId/vein/:fingerIndex", veinTemplateController.Delete)
+		// 删除人员的所有模板
+		peoplePrivateRouter.DELETE("/:peopleId/vein", veinTemplateController.DeleteByPeopleId)
+	}
+
+	veinPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 模板数量和容量
+		veinPrivateRouter.GET("/usage", veinTemplateController.Usage)
+		// 导出模板
+		veinPrivateRouter.POST("/export", veinTemplateController.Export)
+		// 导入模板
+		veinPrivateRouter.POST("/import", veinTemplateController.Import)
+	}
+}
+
+// 注册挂失卡相关的路由
+func RegisterLostCardRoutes(confEnv *map[string]string, service *gin.Engine, lostCardController *controller.LostCardController) {
+	router := service.Group("/api")
+	credentialPrivateRouter := router.Group("/credential")
+
+	// 私有路由：需要身份验证
+	credentialPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 查询挂失卡黑名单
+		credentialPrivateRouter.GET("/lost", lostCardController.FindAll)
+		// 挂失凭证
+		credentialPrivateRouter.POST("/:credentialId/lost", lostCardController.Report)
+		// 补发新卡
+		credentialPrivateRouter.POST("/lost/:lostCardId/replace", lostCardController.Replace)
+		// 移出黑名单
+		credentialPrivateRouter.DELETE("/lost/:lostCardId", lostCardController.Delete)
+	}
+}
+
+// 注册刷卡登记相关的路由
+func RegisterEnrollmentRoutes(confEnv *map[string]string, service *gin.Engine, enrollmentController *controller.EnrollmentController) {
+	router := service.Group("/api")
+	enrollmentPrivateRouter := router.Group("/enrollment")
+	eventPrivateRouter := router.Group("/event")
+
+	// 私有路由：需要身份验证
+	enrollmentPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 开始刷卡登记
+		enrollmentPrivateRouter.POST("", enrollmentController.Start)
+		// 查询最近的刷卡登记会话
+		enrollmentPrivateRouter.GET("", enrollmentController.FindAll)
+		// 查询刷卡登记会话
+		enrollmentPrivateRouter.GET("/:sessionId", enrollmentController.FindById)
+		// 取消刷卡登记
+		enrollmentPrivateRouter.DELETE("/:sessionId", enrollmentController.Cancel)
+	}
+
+	eventPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 从未注册卡事件创建凭证
+		eventPrivateRouter.POST("/:messageId/credential", enrollmentController.CreateFromEvent)
+	}
+}
+
+// 注册数据质量报告相关的路由
+func RegisterDataQualityRoutes(confEnv *map[string]string, service *gin.Engine, dataQualityController *controller.DataQualityController) {
+	router := service.Group("/api")
+	dataQualityPrivateRouter := router.Group("/dataQuality")
+
+	// 私有路由：需要身份验证
+	dataQualityPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 生成数据质量报告
+		dataQualityPrivateRouter.GET("", dataQualityController.Report)
+	}
+}
+
+// 注册凭证使用情况相关的路由
+func RegisterCredentialUsageRoutes(confEnv *map[string]string, service *gin.Engine, credentialUsageController *controller.CredentialUsageController) {
+	router := service.Group("/api")
+	credentialPrivateRouter := router.Group("/credential")
+
+	// 私有路由：需要身份验证
+	credentialPrivateRouter.Use(middleware.TokenAuthMiddleware(confEnv))
+	{
+		// 查询所有凭证的使用情况
+		credentialPrivateRouter.GET("/usage", credentialUsageController.FindAll)
+		// 查询长期未使用的凭证
+		credentialPrivateRouter.GET("/stale", credentialUsageController.Stale)
+		// 停用长期未使用的凭证
+		credentialPrivateRouter.POST("/stale/disable", credentialUsageController.DisableStale)
+	}
+}
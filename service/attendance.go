@@ -0,0 +1,672 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 班次前后参与计算的刷卡时间范围，跨天班次的次日刷卡也计入当天考勤
+const attendanceWindow = 6 * time.Hour
+
+// AttendanceService 考勤的业务接口
+type AttendanceService interface {
+	CreateShift(req request.CreateShiftRequest) response.ShiftResponse
+	UpdateShift(req request.UpdateShiftRequest)
+	DeleteShift(shiftId uint)
+	FindAllShifts() []response.ShiftResponse
+	CreateAssignment(req request.CreateShiftAssignmentRequest) response.ShiftAssignmentResponse
+	DeleteAssignment(assignmentId uint)
+	FindAllAssignments() []response.ShiftAssignmentResponse
+	FindReaders() []response.AttendanceReaderResponse
+	UpdateReaders(req request.UpdateAttendanceReadersRequest)
+	Calculate(date string)
+	CalculateRecent()
+	FindRecords(date string, peopleId uint) []response.AttendanceRecordResponse
+	CreateCorrection(req request.CreateAttendanceCorrectionRequest, userId uint) response.AttendanceCorrectionResponse
+	FindCorrections(month string, peopleId uint) []response.AttendanceCorrectionResponse
+	MonthlyReport(month string) []response.AttendanceReportResponse
+	ExportMonthlyReport(month string) []byte
+}
+
+// AttendanceServiceImpl 考勤的业务实现
+// 根据班次分配和考勤读卡器的允许通行记录，计算每人每天的首次、最后刷卡时间以及迟到、早退、缺勤
+type AttendanceServiceImpl struct {
+	ShiftRepository                repository.ShiftRepository
+	ShiftAssignmentRepository      repository.ShiftAssignmentRepository
+	AttendanceReaderRepository     repository.AttendanceReaderRepository
+	AttendanceRecordRepository     repository.AttendanceRecordRepository
+	AttendanceCorrectionRepository repository.AttendanceCorrectionRepository
+	PeopleDirectoryRepository      repository.PeopleDirectoryRepository
+	Validate                       *validator.Validate
+}
+
+// NewAttendanceServiceImpl 创建并返回一个新的 AttendanceServiceImpl 实例
+func NewAttendanceServiceImpl(
+	shiftRepository repository.ShiftRepository,
+	shiftAssignmentRepository repository.ShiftAssignmentRepository,
+	attendanceReaderRepository repository.AttendanceReaderRepository,
+	attendanceRecordRepository repository.AttendanceRecordRepository,
+	attendanceCorrectionRepository repository.AttendanceCorrectionRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	validate *validator.Validate,
+) AttendanceService {
+	return &AttendanceServiceImpl{
+		ShiftRepository:                shiftRepository,
+		ShiftAssignmentRepository:      shiftAssignmentRepository,
+		AttendanceReaderRepository:     attendanceReaderRepository,
+		AttendanceRecordRepository:     attendanceRecordRepository,
+		AttendanceCorrectionRepository: attendanceCorrectionRepository,
+		PeopleDirectoryRepository:      peopleDirectoryRepository,
+		Validate:                       validate,
+	}
+}
+
+// CreateShift 创建班次
+func (s *AttendanceServiceImpl) CreateShift(req request.CreateShiftRequest) response.ShiftResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	shift, err := s.ShiftRepository.Save(model.Shift{
+		Name:       req.Name,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		LateGrace:  req.LateGrace,
+		EarlyGrace: req.EarlyGrace,
+		WorkDays:   joinUintList(req.WorkDays),
+	})
+	utils.ErrorPanic(err)
+
+	return toShiftResponse(shift)
+}
+
+// UpdateShift 更新班次
+func (s *AttendanceServiceImpl) UpdateShift(req request.UpdateShiftRequest) {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	shift, err := s.ShiftRepository.FindById(req.ID)
+	utils.ErrorPanic(err)
+
+	if req.Name != nil {
+		shift.Name = *req.Name
+	}
+	if req.StartTime != nil {
+		shift.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		shift.EndTime = *req.EndTime
+	}
+	if req.LateGrace != nil {
+		shift.LateGrace = *req.LateGrace
+	}
+	if req.EarlyGrace != nil {
+		shift.EarlyGrace = *req.EarlyGrace
+	}
+	if req.WorkDays != nil {
+		shift.WorkDays = joinUintList(*req.WorkDays)
+	}
+
+	err = s.ShiftRepository.Update(*shift)
+	utils.ErrorPanic(err)
+}
+
+// DeleteShift 删除班次及其分配，已计算的考勤结果保留
+func (s *AttendanceServiceImpl) DeleteShift(shiftId uint) {
+	s.ShiftRepository.Delete(shiftId)
+}
+
+// FindAllShifts 查询所有班次
+func (s *AttendanceServiceImpl) FindAllShifts() []response.ShiftResponse {
+	shifts := s.ShiftRepository.FindAll()
+
+	shiftResponses := make([]response.ShiftResponse, 0, len(shifts))
+	for _, shift := range shifts {
+		shiftResponses = append(shiftResponses, toShiftResponse(shift))
+	}
+	return shiftResponses
+}
+
+// CreateAssignment 将班次分配给人员或部门，已有分配时替换
+func (s *AttendanceServiceImpl) CreateAssignment(req request.CreateShiftAssignmentRequest) response.ShiftAssignmentResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	_, err = s.ShiftRepository.FindById(req.ShiftID)
+	utils.ErrorPanic(err)
+
+	assignment, err := s.ShiftAssignmentRepository.Save(model.ShiftAssignment{
+		ShiftID:      req.ShiftID,
+		PeopleId:     req.PeopleId,
+		DepartmentId: req.DepartmentId,
+	})
+	utils.ErrorPanic(err)
+
+	for _, assignmentResponse := range s.FindAllAssignments() {
+		if assignmentResponse.ID == assignment.ID {
+			return assignmentResponse
+		}
+	}
+	return response.ShiftAssignmentResponse{ID: assignment.ID, ShiftID: assignment.ShiftID}
+}
+
+// DeleteAssignment 删除班次分配
+func (s *AttendanceServiceImpl) DeleteAssignment(assignmentId uint) {
+	s.ShiftAssignmentRepository.Delete(assignmentId)
+}
+
+// FindAllAssignments 查询所有班次分配
+func (s *AttendanceServiceImpl) FindAllAssignments() []response.ShiftAssignmentResponse {
+	assignments := s.ShiftAssignmentRepository.FindAll()
+	shifts := s.shiftMap()
+
+	var peopleIds []uint
+	for _, assignment := range assignments {
+		if assignment.PeopleId > 0 {
+			peopleIds = append(peopleIds, assignment.PeopleId)
+		}
+	}
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindPeopleByIds(peopleIds) {
+		peoples[people.ID] = people
+	}
+	departments := s.departmentNames()
+
+	assignmentResponses := make([]response.ShiftAssignmentResponse, 0, len(assignments))
+	for _, assignment := range assignments {
+		assignmentResponse := response.ShiftAssignmentResponse{
+			ID:             assignment.ID,
+			ShiftID:        assignment.ShiftID,
+			PeopleId:       assignment.PeopleId,
+			DepartmentId:   assignment.DepartmentId,
+			DepartmentName: departments[assignment.DepartmentId],
+		}
+		if shift, ok := shifts[assignment.ShiftID]; ok {
+			assignmentResponse.ShiftName = shift.Name
+		}
+		if people, ok := peoples[assignment.PeopleId]; ok {
+			assignmentResponse.PeopleName = peopleFullName(people)
+		}
+		assignmentResponses = append(assignmentResponses, assignmentResponse)
+	}
+	return assignmentResponses
+}
+
+// FindReaders 查询考勤读卡器
+func (s *AttendanceServiceImpl) FindReaders() []response.AttendanceReaderResponse {
+	readers := s.AttendanceReaderRepository.FindAll()
+
+	readerResponses := make([]response.AttendanceReaderResponse, 0, len(readers))
+	for _, reader := range readers {
+		readerResponses = append(readerResponses, response.AttendanceReaderResponse{
+			IBAddr:     reader.IBAddr,
+			ReaderAddr: reader.ReaderAddr,
+		})
+	}
+	return readerResponses
+}
+
+// UpdateReaders 设置考勤读卡器
+func (s *AttendanceServiceImpl) UpdateReaders(req request.UpdateAttendanceReadersRequest) {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	readers := make([]model.AttendanceReader, 0, len(req.Readers))
+	for _, reader := range req.Readers {
+		readers = append(readers, model.AttendanceReader{IBAddr: reader.IBAddr, ReaderAddr: reader.ReaderAddr})
+	}
+
+	err = s.AttendanceReaderRepository.Replace(readers)
+	utils.ErrorPanic(err)
+}
+
+// Calculate 计算指定日期（2006-01-02）所有已分配班次人员的考勤结果
+func (s *AttendanceServiceImpl) Calculate(date string) {
+	day, err := time.ParseInLocation(time.DateOnly, date, time.Local)
+	utils.ErrorPanic(err)
+
+	shifts := s.shiftMap()
+	peopleShifts := s.resolvePeopleShifts(shifts)
+	if len(peopleShifts) == 0 {
+		err = s.AttendanceRecordRepository.SaveDay(date, nil)
+		utils.ErrorPanic(err)
+		return
+	}
+
+	// 查询所有班次时间范围内的通行记录
+	windowStart, windowEnd := day.Add(-attendanceWindow), day.AddDate(0, 0, 2).Add(attendanceWindow)
+	punches := s.collectPunches(windowStart, windowEnd)
+
+	// 同一人员同一天以最后一次更正为准
+	corrections := map[uint]*model.AttendanceCorrection{}
+	for _, correction := range s.AttendanceCorrectionRepository.FindByDateRange(date, date, 0) {
+		corrections[correction.PeopleId] = correction
+	}
+
+	now := time.Now()
+	var records []*model.AttendanceRecord
+	for peopleId, shift := range peopleShifts {
+		start, end := ShiftPeriod(shift, day)
+		var firstIn, lastOut uint
+		for _, punch := range punches[peopleId] {
+			if punch.Before(start.Add(-attendanceWindow)) || !punch.Before(end.Add(attendanceWindow)) {
+				continue
+			}
+			if firstIn == 0 {
+				firstIn = uint(punch.Unix())
+			}
+			lastOut = uint(punch.Unix())
+		}
+		// 只有一次刷卡时视为未打下班卡
+		if lastOut == firstIn {
+			lastOut = 0
+		}
+
+		var excused bool
+		correction, corrected := corrections[peopleId]
+		if corrected {
+			if correction.FirstIn > 0 {
+				firstIn = correction.FirstIn
+			}
+			if correction.LastOut > 0 {
+				lastOut = correction.LastOut
+			}
+			excused = correction.Excused == 1
+		}
+
+		record := EvaluateAttendance(shift, day, firstIn, lastOut, excused, now)
+		if record.WorkDay == 0 && firstIn == 0 && !corrected {
+			continue
+		}
+		record.PeopleId = peopleId
+		record.Date = date
+		record.Corrected = boolToUint(corrected)
+		record.UpdatedAt = uint(now.Unix())
+		records = append(records, record)
+	}
+
+	err = s.AttendanceRecordRepository.SaveDay(date, records)
+	utils.ErrorPanic(err)
+}
+
+// CalculateRecent 后台任务：重新计算昨天和今天的考勤结果
+func (s *AttendanceServiceImpl) CalculateRecent() {
+	now := time.Now()
+	s.Calculate(now.AddDate(0, 0, -1).Format(time.DateOnly))
+	s.Calculate(now.Format(time.DateOnly))
+}
+
+// FindRecords 查询指定日期的考勤结果，peopleId 为 0 时查询所有人员
+func (s *AttendanceServiceImpl) FindRecords(date string, peopleId uint) []response.AttendanceRecordResponse {
+	_, err := time.ParseInLocation(time.DateOnly, date, time.Local)
+	utils.ErrorPanic(err)
+
+	records := s.AttendanceRecordRepository.FindByDateRange(date, date, peopleId)
+	peoples := s.peopleMap()
+	departments := s.departmentNames()
+	shifts := s.shiftMap()
+
+	recordResponses := make([]response.AttendanceRecordResponse, 0, len(records))
+	for _, record := range records {
+		recordResponse := response.AttendanceRecordResponse{
+			PeopleId:     record.PeopleId,
+			Date:         record.Date,
+			ShiftID:      record.ShiftID,
+			WorkDay:      record.WorkDay == 1,
+			FirstIn:      record.FirstIn,
+			LastOut:      record.LastOut,
+			Late:         record.Late == 1,
+			LateMinutes:  record.LateMinutes,
+			EarlyLeave:   record.EarlyLeave == 1,
+			EarlyMinutes: record.EarlyMinutes,
+			Absent:       record.Absent == 1,
+			Excused:      record.Excused == 1,
+			Corrected:    record.Corrected == 1,
+		}
+		if people, ok := peoples[record.PeopleId]; ok {
+			recordResponse.PeopleCode = people.PeopleCode
+			recordResponse.PeopleName = peopleFullName(people)
+			recordResponse.Department = departments[people.DepartmentID]
+		}
+		if shift, ok := shifts[record.ShiftID]; ok {
+			recordResponse.ShiftName = shift.Name
+		}
+		recordResponses = append(recordResponses, recordResponse)
+	}
+	return recordResponses
+}
+
+// CreateCorrection 人工更正考勤并重新计算当天的考勤结果
+func (s *AttendanceServiceImpl) CreateCorrection(req request.CreateAttendanceCorrectionRequest, userId uint) response.AttendanceCorrectionResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	if req.FirstIn == "" && req.LastOut == "" && !req.Excused {
+		panic(errors.New("first_in, last_out or excused is required"))
+	}
+
+	correction := model.AttendanceCorrection{
+		PeopleId: req.PeopleId,
+		Date:     req.Date,
+		Excused:  boolToUint(req.Excused),
+		Reason:   req.Reason,
+		UserId:   userId,
+	}
+	if req.FirstIn != "" {
+		correction.FirstIn = uint(ParseAccessTime(req.FirstIn).Unix())
+	}
+	if req.LastOut != "" {
+		correction.LastOut = uint(ParseAccessTime(req.LastOut).Unix())
+	}
+	if correction.FirstIn > 0 && correction.LastOut > 0 && correction.LastOut < correction.FirstIn {
+		panic(errors.New("last_out must not be earlier than first_in"))
+	}
+
+	newCorrection, err := s.AttendanceCorrectionRepository.Save(correction)
+	utils.ErrorPanic(err)
+
+	s.Calculate(req.Date)
+
+	return toAttendanceCorrectionResponse(newCorrection)
+}
+
+// FindCorrections 查询指定月份（2006-01）的更正记录，peopleId 为 0 时查询所有人员
+func (s *AttendanceServiceImpl) FindCorrections(month string, peopleId uint) []response.AttendanceCorrectionResponse {
+	from, to := attendanceMonthRange(month)
+	corrections := s.AttendanceCorrectionRepository.FindByDateRange(from, to, peopleId)
+
+	correctionResponses := make([]response.AttendanceCorrectionResponse, 0, len(corrections))
+	for _, correction := range corrections {
+		correctionResponses = append(correctionResponses, toAttendanceCorrectionResponse(correction))
+	}
+	return correctionResponses
+}
+
+// MonthlyReport 汇总指定月份（2006-01）每人的考勤结果
+func (s *AttendanceServiceImpl) MonthlyReport(month string) []response.AttendanceReportResponse {
+	from, to := attendanceMonthRange(month)
+	records := s.AttendanceRecordRepository.FindByDateRange(from, to, 0)
+	peoples := s.peopleMap()
+	departments := s.departmentNames()
+
+	reports := map[uint]*response.AttendanceReportResponse{}
+	var peopleIds []uint
+	for _, record := range records {
+		report, ok := reports[record.PeopleId]
+		if !ok {
+			report = &response.AttendanceReportResponse{PeopleId: record.PeopleId}
+			if people, ok := peoples[record.PeopleId]; ok {
+				report.PeopleCode = people.PeopleCode
+				report.PeopleName = peopleFullName(people)
+				report.Department = departments[people.DepartmentID]
+			}
+			reports[record.PeopleId] = report
+			peopleIds = append(peopleIds, record.PeopleId)
+		}
+
+		if record.WorkDay == 1 {
+			report.WorkDays++
+		}
+		if record.FirstIn > 0 || record.LastOut > 0 {
+			report.PresentDays++
+		}
+		if record.Late == 1 {
+			report.LateCount++
+			report.LateMinutes += record.LateMinutes
+		}
+		if record.EarlyLeave == 1 {
+			report.EarlyCount++
+			report.EarlyMinutes += record.EarlyMinutes
+		}
+		if record.Absent == 1 {
+			report.AbsentDays++
+		}
+		if record.Excused == 1 {
+			report.ExcusedDays++
+		}
+	}
+
+	slices.Sort(peopleIds)
+	reportResponses := make([]response.AttendanceReportResponse, 0, len(peopleIds))
+	for _, peopleId := range peopleIds {
+		reportResponses = append(reportResponses, *reports[peopleId])
+	}
+	return reportResponses
+}
+
+// ExportMonthlyReport 导出月度考勤汇总 CSV（UTF-8 BOM，便于 Excel 直接打开）
+func (s *AttendanceServiceImpl) ExportMonthlyReport(month string) []byte {
+	reports := s.MonthlyReport(month)
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"工号", "姓名", "部门", "应出勤天数", "实际出勤天数", "迟到次数", "迟到分钟", "早退次数", "早退分钟", "缺勤天数", "免除考勤天数"})
+	for _, report := range reports {
+		writer.Write([]string{
+			report.PeopleCode,
+			report.PeopleName,
+			report.Department,
+			strconv.FormatUint(uint64(report.WorkDays), 10),
+			strconv.FormatUint(uint64(report.PresentDays), 10),
+			strconv.FormatUint(uint64(report.LateCount), 10),
+			strconv.FormatUint(uint64(report.LateMinutes), 10),
+			strconv.FormatUint(uint64(report.EarlyCount), 10),
+			strconv.FormatUint(uint64(report.EarlyMinutes), 10),
+			strconv.FormatUint(uint64(report.AbsentDays), 10),
+			strconv.FormatUint(uint64(report.ExcusedDays), 10),
+		})
+	}
+	writer.Flush()
+	utils.ErrorPanic(writer.Error())
+
+	return buf.Bytes()
+}
+
+// resolvePeopleShifts 返回每个人员适用的班次，人员的分配优先于所属部门的分配
+func (s *AttendanceServiceImpl) resolvePeopleShifts(shifts map[uint]*model.Shift) map[uint]*model.Shift {
+	peopleAssignments := map[uint]uint{}
+	departmentAssignments := map[uint]uint{}
+	for _, assignment := range s.ShiftAssignmentRepository.FindAll() {
+		if assignment.PeopleId > 0 {
+			peopleAssignments[assignment.PeopleId] = assignment.ShiftID
+		} else {
+			departmentAssignments[assignment.DepartmentId] = assignment.ShiftID
+		}
+	}
+
+	peopleShifts := map[uint]*model.Shift{}
+	if len(peopleAssignments) == 0 && len(departmentAssignments) == 0 {
+		return peopleShifts
+	}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		shiftId, ok := peopleAssignments[people.ID]
+		if !ok {
+			shiftId, ok = departmentAssignments[people.DepartmentID]
+		}
+		if shift, exists := shifts[shiftId]; ok && exists {
+			peopleShifts[people.ID] = shift
+		}
+	}
+	return peopleShifts
+}
+
+// collectPunches 查询时间段内考勤读卡器的允许通行记录，按人员分组
+// 事件中没有人员 ID 时按卡号匹配凭证所属人员
+func (s *AttendanceServiceImpl) collectPunches(start time.Time, end time.Time) map[uint][]time.Time {
+	type readerKey struct {
+		ibAddr     int
+		readerAddr int
+	}
+	readers := map[readerKey]bool{}
+	for _, reader := range s.AttendanceReaderRepository.FindAll() {
+		readers[readerKey{reader.IBAddr, reader.ReaderAddr}] = true
+	}
+
+	var cardPeoples map[string]uint
+	punches := map[uint][]time.Time{}
+	events := s.AttendanceRecordRepository.FindEvents(start.Format(time.DateTime), end.Format(time.DateTime))
+	for _, event := range events {
+		if event.EventType != model.EventTypeGranted {
+			continue
+		}
+		if len(readers) > 0 && !readers[readerKey{event.IBAddr, event.ReaderAddr}] {
+			continue
+		}
+
+		peopleId := event.PeopleId
+		if peopleId == 0 && event.CardNo != "" {
+			if cardPeoples == nil {
+				cardPeoples = map[string]uint{}
+				for _, credential := range s.PeopleDirectoryRepository.FindAllCredentials() {
+					cardPeoples[credential.CardNo] = credential.PeopleId
+				}
+			}
+			peopleId = cardPeoples[event.CardNo]
+		}
+		if peopleId == 0 {
+			continue
+		}
+		punches[peopleId] = append(punches[peopleId], ParseAccessTime(event.AccessTime))
+	}
+	return punches
+}
+
+// shiftMap 返回所有班次，键为班次 ID
+func (s *AttendanceServiceImpl) shiftMap() map[uint]*model.Shift {
+	shifts := map[uint]*model.Shift{}
+	for _, shift := range s.ShiftRepository.FindAll() {
+		shifts[shift.ID] = shift
+	}
+	return shifts
+}
+
+// peopleMap 返回所有人员，键为人员 ID
+func (s *AttendanceServiceImpl) peopleMap() map[uint]*model.People {
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peoples[people.ID] = people
+	}
+	return peoples
+}
+
+// departmentNames 返回所有部门名称，键为部门 ID
+func (s *AttendanceServiceImpl) departmentNames() map[uint]string {
+	departments := map[uint]string{}
+	for _, department := range s.PeopleDirectoryRepository.FindAllDepartments() {
+		departments[department.ID] = department.Name
+	}
+	return departments
+}
+
+// ShiftPeriod 返回班次在指定日期的上班、下班时间，下班时间不晚于上班时间时为次日下班
+func ShiftPeriod(shift *model.Shift, day time.Time) (time.Time, time.Time) {
+	start := shiftClock(day, shift.StartTime)
+	end := shiftClock(day, shift.EndTime)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// EvaluateAttendance 根据首次、最后刷卡时间计算考勤结果
+// 班次未结束时不判定早退和缺勤；免除考勤时不标记迟到、早退和缺勤
+func EvaluateAttendance(shift *model.Shift, day time.Time, firstIn uint, lastOut uint, excused bool, now time.Time) *model.AttendanceRecord {
+	start, end := ShiftPeriod(shift, day)
+	workDay := slices.Contains(splitUintList(shift.WorkDays), uint(day.Weekday()))
+
+	record := &model.AttendanceRecord{
+		ShiftID: shift.ID,
+		WorkDay: boolToUint(workDay),
+		FirstIn: firstIn,
+		LastOut: lastOut,
+		Excused: boolToUint(excused),
+	}
+	if !workDay || excused {
+		return record
+	}
+
+	finished := !now.Before(end)
+	if firstIn == 0 {
+		record.Absent = boolToUint(finished)
+		return record
+	}
+
+	lateLimit := start.Add(time.Duration(shift.LateGrace) * time.Minute)
+	if in := time.Unix(int64(firstIn), 0); in.After(lateLimit) {
+		record.Late = 1
+		record.LateMinutes = uint(in.Sub(start).Minutes())
+	}
+
+	if finished {
+		earlyLimit := end.Add(-time.Duration(shift.EarlyGrace) * time.Minute)
+		if lastOut == 0 {
+			record.EarlyLeave = 1
+		} else if out := time.Unix(int64(lastOut), 0); out.Before(earlyLimit) {
+			record.EarlyLeave = 1
+			record.EarlyMinutes = uint(end.Sub(out).Minutes())
+		}
+	}
+	return record
+}
+
+// shiftClock 返回指定日期的 HH:MM 时刻
+func shiftClock(day time.Time, clock string) time.Time {
+	hour, minute, _ := strings.Cut(clock, ":")
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+// attendanceMonthRange 返回月份（2006-01）的首尾日期
+func attendanceMonthRange(month string) (string, string) {
+	first, err := time.ParseInLocation("2006-01", month, time.Local)
+	utils.ErrorPanic(err)
+	last := first.AddDate(0, 1, -1)
+	return first.Format(time.DateOnly), last.Format(time.DateOnly)
+}
+
+// peopleFullName 返回人员姓名，与事件消息中的 fullname 拼接方式一致
+func peopleFullName(people *model.People) string {
+	return strings.TrimSpace(people.FirstName + people.LastName)
+}
+
+// toShiftResponse 将班次转换为响应结构
+func toShiftResponse(shift *model.Shift) response.ShiftResponse {
+	return response.ShiftResponse{
+		ID:         shift.ID,
+		Name:       shift.Name,
+		StartTime:  shift.StartTime,
+		EndTime:    shift.EndTime,
+		LateGrace:  shift.LateGrace,
+		EarlyGrace: shift.EarlyGrace,
+		WorkDays:   splitUintList(shift.WorkDays),
+	}
+}
+
+// toAttendanceCorrectionResponse 将更正记录转换为响应结构
+func toAttendanceCorrectionResponse(correction *model.AttendanceCorrection) response.AttendanceCorrectionResponse {
+	return response.AttendanceCorrectionResponse{
+		ID:        correction.ID,
+		PeopleId:  correction.PeopleId,
+		Date:      correction.Date,
+		FirstIn:   correction.FirstIn,
+		LastOut:   correction.LastOut,
+		Excused:   correction.Excused == 1,
+		Reason:    correction.Reason,
+		UserId:    correction.UserId,
+		CreatedAt: uint(correction.CreatedAt.Unix()),
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"log"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// AuditLogService 操作审计日志的业务接口
+type AuditLogService interface {
+	Record(auditLog model.AuditLog)
+	FindAll(pg *utils.Pagination) []response.AuditLogResponse
+}
+
+// AuditLogServiceImpl 操作审计日志的业务实现
+// 记录的审计日志同时以 audit.operation 主题发布到事件中心
+type AuditLogServiceImpl struct {
+	AuditLogRepository repository.AuditLogRepository
+	EventHub           *EventHub
+}
+
+// NewAuditLogServiceImpl 创建并返回一个新的 AuditLogServiceImpl 实例
+func NewAuditLogServiceImpl(auditLogRepository repository.AuditLogRepository, eventHub *EventHub) AuditLogService {
+	return &AuditLogServiceImpl{
+		AuditLogRepository: auditLogRepository,
+		EventHub:           eventHub,
+	}
+}
+
+// Record 保存审计日志并发布到事件中心，失败时只记录日志，不影响请求本身
+func (s *AuditLogServiceImpl) Record(auditLog model.AuditLog) {
+	newAuditLog, err := s.AuditLogRepository.Save(auditLog)
+	if err != nil {
+		log.Printf("audit log: save failed: %v", err)
+		return
+	}
+
+	s.EventHub.Publish(NewHubMessage("audit.operation", toAuditLogResponse(newAuditLog)))
+}
+
+// FindAll 分页查询操作审计日志
+func (s *AuditLogServiceImpl) FindAll(pg *utils.Pagination) []response.AuditLogResponse {
+	auditLogs := s.AuditLogRepository.FindAll(pg)
+
+	auditLogResponses := make([]response.AuditLogResponse, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		auditLogResponses = append(auditLogResponses, toAuditLogResponse(auditLog))
+	}
+	return auditLogResponses
+}
+
+// toAuditLogResponse 将审计日志转换为响应结构
+func toAuditLogResponse(auditLog *model.AuditLog) response.AuditLogResponse {
+	return response.AuditLogResponse{
+		ID:        auditLog.ID,
+		UserId:    auditLog.UserId,
+		Method:    auditLog.Method,
+		Path:      auditLog.Path,
+		Target:    auditLog.Target,
+		ClientIP:  auditLog.ClientIP,
+		Status:    auditLog.Status,
+		Success:   auditLog.Success == 1,
+		Message:   auditLog.Message,
+		CreatedAt: auditLog.CreatedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// BackendResponse 后端接口的通用响应
+type BackendResponse struct {
+	RetCode int             `json:"retcode"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+// BackendPost 向后端服务发送表单 POST 请求并返回响应体
+// 后端基础地址读取自 .env 的 BackendBaseURL，与控制器层的同步、开门等请求一致
+func BackendPost(path string, data url.Values) ([]byte, error) {
+	confEnv, err := godotenv.Read() // .env in project root path.
+	if err != nil {
+		return nil, err
+	}
+
+	body := ""
+	if data != nil {
+		body = data.Encode()
+	}
+
+	req, err := http.NewRequest("POST", confEnv["BackendBaseURL"]+path, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return respBody, fmt.Errorf("backend %s: %s", path, resp.Status)
+	}
+	return respBody, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// BulkService 人员和凭证批量操作的业务接口
+type BulkService interface {
+	People(req request.BulkRequest) response.BulkResponse
+	Credentials(req request.BulkRequest) response.BulkResponse
+	SuspendAccess(uniqueId uint)
+}
+
+// BulkServiceImpl 人员和凭证批量操作的业务实现
+// 所有对象在一个事务中处理，每个对象的结果单独返回
+type BulkServiceImpl struct {
+	BulkRepository            repository.BulkRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	GroupDirectoryRepository  repository.GroupDirectoryRepository
+	CredentialUsageRepository repository.CredentialUsageRepository
+	PeoplePhotoService        PeoplePhotoService
+	DepartmentTreeService     DepartmentTreeService
+	Validate                  *validator.Validate
+}
+
+// NewBulkServiceImpl 创建并返回一个新的 BulkServiceImpl 实例
+func NewBulkServiceImpl(
+	bulkRepository repository.BulkRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	credentialUsageRepository repository.CredentialUsageRepository,
+	peoplePhotoService PeoplePhotoService,
+	departmentTreeService DepartmentTreeService,
+	validate *validator.Validate,
+) BulkService {
+	return &BulkServiceImpl{
+		BulkRepository:            bulkRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		GroupDirectoryRepository:  groupDirectoryRepository,
+		CredentialUsageRepository: credentialUsageRepository,
+		PeoplePhotoService:        peoplePhotoService,
+		DepartmentTreeService:     departmentTreeService,
+		Validate:                  validate,
+	}
+}
+
+// People 对人员执行批量操作，门禁组和启用、停用作用于人员的所有凭证
+func (s *BulkServiceImpl) People(req request.BulkRequest) response.BulkResponse {
+	operation := s.validate(req)
+
+	ids := req.Ids
+	if len(ids) == 0 {
+		var err error
+		ids, err = s.BulkRepository.FindPeopleIds(s.filter(req.Filter))
+		utils.ErrorPanic(err)
+	}
+
+	results, err := s.BulkRepository.ApplyPeople(ids, operation, req.Atomic)
+	if operation.Action == repository.BulkActionDelete {
+		s.PeoplePhotoService.Purge()
+		trackCredentialUsage(s.CredentialUsageRepository, s.PeopleDirectoryRepository)
+	}
+	// 调整部门后立即同步继承的门禁组，不等待后台任务
+	if operation.Action == repository.BulkActionMoveDepartment {
+		s.DepartmentTreeService.Reconcile()
+	}
+	return toBulkResponse(req, results, err)
+}
+
+// Credentials 对凭证执行批量操作，筛选条件选择满足条件的人员的所有凭证
+func (s *BulkServiceImpl) Credentials(req request.BulkRequest) response.BulkResponse {
+	operation := s.validate(req)
+	if operation.Action == repository.BulkActionMoveDepartment {
+		panic("move_department is only supported for people")
+	}
+
+	ids := req.Ids
+	if len(ids) == 0 {
+		var err error
+		ids, err = s.BulkRepository.FindCredentialIds(s.filter(req.Filter))
+		utils.ErrorPanic(err)
+	}
+
+	results, err := s.BulkRepository.ApplyCredentials(ids, operation, req.Atomic)
+	if operation.Action == repository.BulkActionDelete {
+		trackCredentialUsage(s.CredentialUsageRepository, s.PeopleDirectoryRepository)
+	}
+	return toBulkResponse(req, results, err)
+}
+
+// SuspendAccess 凭证已停用时将直接写入的门禁组移到停用期间保存的门禁组，启用时恢复
+func (s *BulkServiceImpl) SuspendAccess(uniqueId uint) {
+	_, err := s.BulkRepository.SuspendAccess(uniqueId)
+	utils.ErrorPanic(err)
+}
+
+// validate 校验请求并检查门禁组和部门是否存在
+func (s *BulkServiceImpl) validate(req request.BulkRequest) repository.BulkOperation {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	if len(req.Ids) == 0 {
+		filter := req.Filter
+		if filter.DepartmentId == 0 && filter.AccessGroupId == 0 && filter.Keyword == "" {
+			panic("filter must contain at least one condition")
+		}
+	}
+
+	if len(req.AccessGroupIds) > 0 {
+		groupIds := map[uint]bool{}
+		for _, accessGroup := range s.GroupDirectoryRepository.FindAllAccessGroups() {
+			groupIds[accessGroup.GroupId] = true
+		}
+		for _, groupId := range req.AccessGroupIds {
+			if !groupIds[groupId] {
+				panic("access group " + strconv.FormatUint(uint64(groupId), 10) + " not found")
+			}
+		}
+	}
+
+	if req.Action == repository.BulkActionMoveDepartment && req.DepartmentId > 0 {
+		found := false
+		for _, department := range s.PeopleDirectoryRepository.FindAllDepartments() {
+			if department.ID == req.DepartmentId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic("department " + strconv.FormatUint(uint64(req.DepartmentId), 10) + " not found")
+		}
+	}
+
+	operation := repository.BulkOperation{
+		Action:         req.Action,
+		AccessGroupIds: req.AccessGroupIds,
+		DepartmentId:   req.DepartmentId,
+	}
+	if req.Action == repository.BulkActionExtendValidity {
+		validUntil, err := ParseValidityTime(req.ValidUntil, true)
+		utils.ErrorPanic(err)
+		if validUntil <= uint(time.Now().Unix()) {
+			panic("valid_until must be in the future")
+		}
+		operation.ValidUntil = validUntil
+	}
+	return operation
+}
+
+// filter 将请求中的筛选条件转换为查询条件
+func (s *BulkServiceImpl) filter(filter *request.BulkFilterRequest) repository.BulkFilter {
+	return repository.BulkFilter{
+		DepartmentId:  filter.DepartmentId,
+		AccessGroupId: filter.AccessGroupId,
+		Keyword:       filter.Keyword,
+	}
+}
+
+// toBulkResponse 汇总批量操作结果，atomic 模式下失败时所有对象均未生效
+func toBulkResponse(req request.BulkRequest, results []repository.BulkItemResult, err error) response.BulkResponse {
+	bulkResponse := response.BulkResponse{
+		Action:     req.Action,
+		Total:      len(results),
+		RolledBack: err != nil,
+		Items:      make([]response.BulkItemResponse, 0, len(results)),
+	}
+	if err != nil && !req.Atomic {
+		utils.ErrorPanic(err)
+	}
+
+	for _, result := range results {
+		item := response.BulkItemResponse{
+			ID:      result.ID,
+			Success: result.Success && err == nil,
+			Message: result.Message,
+		}
+		if result.Success && err != nil {
+			item.Message = "rolled back"
+		}
+		if item.Success {
+			bulkResponse.Succeeded++
+		} else {
+			bulkResponse.Failed++
+		}
+		bulkResponse.Items = append(bulkResponse.Items, item)
+	}
+	return bulkResponse
+}
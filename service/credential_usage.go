@@ -0,0 +1,255 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+const (
+	credentialUsageCursorName = "credential_usage" // 凭证使用情况汇总游标名称
+	credentialStaleDays       = 90                 // 未使用凭证报告的默认天数
+)
+
+// CredentialUsageService 凭证使用情况的业务接口
+type CredentialUsageService interface {
+	Aggregate()
+	Track()
+	FindAll() []response.CredentialUsageResponse
+	Stale(days uint) []response.CredentialUsageResponse
+	DisableStale(req request.DisableStaleCredentialRequest) response.BulkResponse
+}
+
+// CredentialUsageServiceImpl 凭证使用情况的业务实现
+// 后台任务按事件消息 ID 增量汇总允许通行事件，记录每个凭证最近一次通行的时间、门和通行次数
+type CredentialUsageServiceImpl struct {
+	CredentialUsageRepository    repository.CredentialUsageRepository
+	EventCursorRepository        repository.EventCursorRepository
+	PeopleDirectoryRepository    repository.PeopleDirectoryRepository
+	CredentialValidityRepository repository.CredentialValidityRepository
+	BulkRepository               repository.BulkRepository
+	Validate                     *validator.Validate
+}
+
+// NewCredentialUsageServiceImpl 创建并返回一个新的 CredentialUsageServiceImpl 实例
+func NewCredentialUsageServiceImpl(
+	credentialUsageRepository repository.CredentialUsageRepository,
+	eventCursorRepository repository.EventCursorRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	credentialValidityRepository repository.CredentialValidityRepository,
+	bulkRepository repository.BulkRepository,
+	validate *validator.Validate,
+) CredentialUsageService {
+	return &CredentialUsageServiceImpl{
+		CredentialUsageRepository:    credentialUsageRepository,
+		EventCursorRepository:        eventCursorRepository,
+		PeopleDirectoryRepository:    peopleDirectoryRepository,
+		CredentialValidityRepository: credentialValidityRepository,
+		BulkRepository:               bulkRepository,
+		Validate:                     validate,
+	}
+}
+
+// Aggregate 后台任务：汇总游标之后的新事件，首次运行时或消息 ID 重新编号后从第一条事件开始汇总
+func (s *CredentialUsageServiceImpl) Aggregate() {
+	lastMsgId, _ := loadEventCursor(s.EventCursorRepository, credentialUsageCursorName)
+
+	// 事件中没有凭证 ID 时按卡号查找凭证
+	cardCredentials := map[string]uint{}
+	for _, credential := range s.PeopleDirectoryRepository.FindAllCredentials() {
+		if credential.CardNo != "" {
+			cardCredentials[credential.CardNo] = credential.UniqueId
+		}
+	}
+
+	for i := 0; i < statisticsMaxBatches; i++ {
+		events := s.EventCursorRepository.FindEventsAfter(lastMsgId, statisticsBatchSize)
+		if len(events) == 0 {
+			return
+		}
+
+		lastMsgId = events[len(events)-1].MsgId
+		err := s.CredentialUsageRepository.Apply(AggregateCredentialUsage(events, cardCredentials), credentialUsageCursorName, lastMsgId)
+		utils.ErrorPanic(err)
+
+		if len(events) < statisticsBatchSize {
+			return
+		}
+	}
+}
+
+// Track 后台任务：新凭证从现在开始跟踪，已删除凭证的记录一并删除
+func (s *CredentialUsageServiceImpl) Track() {
+	trackCredentialUsage(s.CredentialUsageRepository, s.PeopleDirectoryRepository)
+}
+
+// trackCredentialUsage 为新凭证添加使用情况记录并删除已删除凭证的记录
+// 使用情况在事件消息数据库中，无法与凭证在同一事务中删除，删除凭证的操作完成后调用
+func trackCredentialUsage(credentialUsageRepository repository.CredentialUsageRepository, peopleDirectoryRepository repository.PeopleDirectoryRepository) {
+	credentials := map[uint]bool{}
+	for _, credential := range peopleDirectoryRepository.FindAllCredentials() {
+		credentials[credential.UniqueId] = true
+	}
+
+	var removedIds []uint
+	for _, usage := range credentialUsageRepository.FindAll() {
+		if credentials[usage.UniqueId] {
+			delete(credentials, usage.UniqueId)
+		} else {
+			removedIds = append(removedIds, usage.UniqueId)
+		}
+	}
+	uniqueIds := make([]uint, 0, len(credentials))
+	for uniqueId := range credentials {
+		uniqueIds = append(uniqueIds, uniqueId)
+	}
+	slices.Sort(uniqueIds)
+
+	if len(uniqueIds) > 0 || len(removedIds) > 0 {
+		err := credentialUsageRepository.Track(uniqueIds, removedIds, uint(time.Now().Unix()))
+		utils.ErrorPanic(err)
+	}
+}
+
+// FindAll 查询所有凭证的使用情况
+func (s *CredentialUsageServiceImpl) FindAll() []response.CredentialUsageResponse {
+	return s.usages(0)
+}
+
+// Stale 查询超过 days 天未使用的凭证，已停用的凭证不包括在内；days 为 0 时使用默认天数
+func (s *CredentialUsageServiceImpl) Stale(days uint) []response.CredentialUsageResponse {
+	if days == 0 {
+		days = credentialStaleDays
+	}
+	return s.usages(days)
+}
+
+// DisableStale 停用超过指定天数未使用的凭证，门禁组保存在凭证状态中，启用时恢复
+// 指定凭证时只停用其中仍未使用的凭证
+func (s *CredentialUsageServiceImpl) DisableStale(req request.DisableStaleCredentialRequest) response.BulkResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	var uniqueIds []uint
+	for _, usage := range s.usages(req.Days) {
+		if len(req.UniqueIds) == 0 || slices.Contains(req.UniqueIds, usage.UniqueId) {
+			uniqueIds = append(uniqueIds, usage.UniqueId)
+		}
+	}
+
+	operation := repository.BulkOperation{Action: repository.BulkActionDisable, Reason: model.CredentialDisabledStale}
+	results, err := s.BulkRepository.ApplyCredentials(uniqueIds, operation, req.Atomic)
+	return toBulkResponse(request.BulkRequest{Action: repository.BulkActionDisable, Atomic: req.Atomic}, results, err)
+}
+
+// usages 汇总凭证的使用情况，staleDays 大于 0 时只返回超过该天数未使用且未停用的凭证
+func (s *CredentialUsageServiceImpl) usages(staleDays uint) []response.CredentialUsageResponse {
+	s.Track()
+
+	now := time.Now()
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peoples[people.ID] = people
+	}
+	disabled := map[uint]bool{}
+	for _, state := range s.CredentialValidityRepository.FindAllStates() {
+		disabled[state.UniqueId] = state.Disabled == 1
+	}
+	usages := map[uint]*model.CredentialUsage{}
+	for _, usage := range s.CredentialUsageRepository.FindAll() {
+		usages[usage.UniqueId] = usage
+	}
+
+	usageResponses := []response.CredentialUsageResponse{}
+	for _, credential := range s.PeopleDirectoryRepository.FindAllCredentials() {
+		usage, ok := usages[credential.UniqueId]
+		if !ok {
+			continue
+		}
+		idleDays := CredentialIdleDays(usage, now)
+		if staleDays > 0 && (idleDays < staleDays || disabled[credential.UniqueId]) {
+			continue
+		}
+
+		usageResponse := response.CredentialUsageResponse{
+			UniqueId:     credential.UniqueId,
+			CardNo:       credential.CardNo,
+			PeopleId:     credential.PeopleId,
+			UseCount:     usage.UseCount,
+			LastUsedAt:   usage.LastUsedAt,
+			IBAddr:       usage.IBAddr,
+			ReaderAddr:   usage.ReaderAddr,
+			OutputAddr:   usage.OutputAddr,
+			IBName:       usage.IBName,
+			ReaderName:   usage.ReaderName,
+			TrackedSince: usage.TrackedSince,
+			IdleDays:     idleDays,
+		}
+		if people, ok := peoples[credential.PeopleId]; ok {
+			usageResponse.PeopleCode = people.PeopleCode
+			usageResponse.PeopleName = peopleFullName(people)
+		}
+		usageResponses = append(usageResponses, usageResponse)
+	}
+	return usageResponses
+}
+
+// AggregateCredentialUsage 将一批事件消息中的允许通行事件按凭证汇总，记录最近一次通行的门
+// 事件中没有凭证 ID 时通过 cardCredentials（卡号到凭证 ID）按卡号查找凭证
+func AggregateCredentialUsage(events []*model.EventMessageData, cardCredentials map[string]uint) []*model.CredentialUsage {
+	usageMap := map[uint]*model.CredentialUsage{}
+	var usages []*model.CredentialUsage
+
+	for _, event := range events {
+		if event.EventType != model.EventTypeGranted {
+			continue
+		}
+		uniqueId := event.UniqueId
+		if uniqueId == 0 && event.CardNo != "" {
+			uniqueId = cardCredentials[event.CardNo]
+		}
+		if uniqueId == 0 {
+			continue
+		}
+		accessTime := uint(ParseAccessTime(event.AccessTime).Unix())
+
+		usage, ok := usageMap[uniqueId]
+		if !ok {
+			usage = &model.CredentialUsage{UniqueId: uniqueId, TrackedSince: accessTime}
+			usageMap[uniqueId] = usage
+			usages = append(usages, usage)
+		}
+		usage.UseCount++
+		if accessTime >= usage.LastUsedAt {
+			usage.LastUsedAt = accessTime
+			usage.IBAddr = event.IBAddr
+			usage.ReaderAddr = event.ReaderAddr
+			usage.OutputAddr = event.OutputAddr
+			usage.IBName = event.IBName
+			usage.ReaderName = event.ReaderName
+		}
+		if accessTime < usage.TrackedSince {
+			usage.TrackedSince = accessTime
+		}
+	}
+	return usages
+}
+
+// CredentialIdleDays 返回凭证未使用的整天数，从最近一次通行时间计算，未使用过的凭证从开始跟踪时计算
+func CredentialIdleDays(usage *model.CredentialUsage, now time.Time) uint {
+	since := usage.LastUsedAt
+	if since == 0 {
+		since = usage.TrackedSince
+	}
+	if since == 0 || int64(since) >= now.Unix() {
+		return 0
+	}
+	return uint((now.Unix() - int64(since)) / 86400)
+}
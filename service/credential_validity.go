@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 凭证因有效期被启用或停用时推送的主题
+var credentialValidityTopics = map[string]string{
+	repository.ValidityStatusActive:  "system.credential_activated",
+	repository.ValidityStatusPending: "system.credential_pending",
+	repository.ValidityStatusExpired: "system.credential_expired",
+}
+
+// CredentialValidityService 凭证有效期的业务接口
+type CredentialValidityService interface {
+	SetCredentialValidity(req request.UpdateValidityRequest) response.ValidityResponse
+	SetPeopleValidity(req request.UpdateValidityRequest) response.ValidityResponse
+	Expiring(days int) []response.ExpiringCredentialResponse
+	Check() int
+}
+
+// CredentialValidityServiceImpl 凭证有效期的业务实现
+// 凭证和人员都可设置有效期，凭证实际有效期取两者交集；超出有效期的凭证自动停用，回到有效期内时自动启用
+type CredentialValidityServiceImpl struct {
+	CredentialValidityRepository repository.CredentialValidityRepository
+	PeopleDirectoryRepository    repository.PeopleDirectoryRepository
+	EventHub                     *EventHub
+	Validate                     *validator.Validate
+}
+
+// NewCredentialValidityServiceImpl 创建并返回一个新的 CredentialValidityServiceImpl 实例
+func NewCredentialValidityServiceImpl(
+	credentialValidityRepository repository.CredentialValidityRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	eventHub *EventHub,
+	validate *validator.Validate,
+) CredentialValidityService {
+	return &CredentialValidityServiceImpl{
+		CredentialValidityRepository: credentialValidityRepository,
+		PeopleDirectoryRepository:    peopleDirectoryRepository,
+		EventHub:                     eventHub,
+		Validate:                     validate,
+	}
+}
+
+// SetCredentialValidity 设置凭证有效期，立即生效
+func (s *CredentialValidityServiceImpl) SetCredentialValidity(req request.UpdateValidityRequest) response.ValidityResponse {
+	validFrom, validUntil := s.validate(req)
+	changed, err := s.CredentialValidityRepository.SetCredentialValidity(req.ID, validFrom, validUntil)
+	utils.ErrorPanic(err)
+	return response.ValidityResponse{ValidFrom: validFrom, ValidUntil: validUntil, Changed: changed}
+}
+
+// SetPeopleValidity 设置人员有效期，作用于该人员的所有凭证，立即生效
+func (s *CredentialValidityServiceImpl) SetPeopleValidity(req request.UpdateValidityRequest) response.ValidityResponse {
+	validFrom, validUntil := s.validate(req)
+	changed, err := s.CredentialValidityRepository.SetPeopleValidity(req.ID, validFrom, validUntil)
+	utils.ErrorPanic(err)
+	return response.ValidityResponse{ValidFrom: validFrom, ValidUntil: validUntil, Changed: changed}
+}
+
+// Expiring 查询 days 天内到期且当前仍有效的凭证，按失效时间排序
+func (s *CredentialValidityServiceImpl) Expiring(days int) []response.ExpiringCredentialResponse {
+	if days <= 0 {
+		days = 30
+	}
+	now := time.Now()
+	limit := uint(now.AddDate(0, 0, days).Unix())
+
+	states, peopleValidity := s.validityMaps()
+	credentials := s.PeopleDirectoryRepository.FindAllCredentials()
+
+	expiring := []response.ExpiringCredentialResponse{}
+	var peopleIds []uint
+	for _, credential := range credentials {
+		state := states[credential.UniqueId]
+		if state.Disabled == 1 {
+			continue
+		}
+		validity := peopleValidity[credential.PeopleId]
+		validFrom, validUntil := repository.EffectiveValidity(state.ValidFrom, state.ValidUntil, validity.ValidFrom, validity.ValidUntil)
+		if validUntil == 0 || validUntil > limit || repository.ValidityStatus(validFrom, validUntil, uint(now.Unix())) != repository.ValidityStatusActive {
+			continue
+		}
+		expiring = append(expiring, response.ExpiringCredentialResponse{
+			UniqueId:   credential.UniqueId,
+			CardNo:     credential.CardNo,
+			PeopleId:   credential.PeopleId,
+			ValidFrom:  validFrom,
+			ValidUntil: validUntil,
+			DaysLeft:   int((validUntil - uint(now.Unix())) / 86400),
+		})
+		peopleIds = append(peopleIds, credential.PeopleId)
+	}
+
+	departments := map[uint]string{}
+	for _, department := range s.PeopleDirectoryRepository.FindAllDepartments() {
+		departments[department.ID] = department.Name
+	}
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindPeopleByIds(peopleIds) {
+		peoples[people.ID] = people
+	}
+	for i := range expiring {
+		if people, ok := peoples[expiring[i].PeopleId]; ok {
+			expiring[i].PeopleCode = people.PeopleCode
+			expiring[i].PeopleName = peopleFullName(people)
+			expiring[i].Department = departments[people.DepartmentID]
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ValidUntil < expiring[j].ValidUntil
+	})
+	return expiring
+}
+
+// Check 后台任务：停用超出有效期的凭证，启用回到有效期内的凭证并推送事件，返回被启用或停用的凭证数
+func (s *CredentialValidityServiceImpl) Check() int {
+	now := uint(time.Now().Unix())
+	states, peopleValidity := s.validityMaps()
+
+	var credentials []*model.Credential
+	for _, credential := range s.PeopleDirectoryRepository.FindAllCredentials() {
+		state := states[credential.UniqueId]
+		validity := peopleValidity[credential.PeopleId]
+		validFrom, validUntil := repository.EffectiveValidity(state.ValidFrom, state.ValidUntil, validity.ValidFrom, validity.ValidUntil)
+		if repository.ValidityChangeNeeded(&state, repository.ValidityStatus(validFrom, validUntil, now)) {
+			credentials = append(credentials, credential)
+		}
+	}
+	if len(credentials) == 0 {
+		return 0
+	}
+
+	peopleIds := make([]uint, 0, len(credentials))
+	for _, credential := range credentials {
+		peopleIds = append(peopleIds, credential.PeopleId)
+	}
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindPeopleByIds(peopleIds) {
+		peoples[people.ID] = people
+	}
+
+	changed := 0
+	for _, credential := range credentials {
+		status, ok, err := s.CredentialValidityRepository.Evaluate(credential.UniqueId)
+		if err != nil {
+			log.Printf("credential validity: credential %d: %v", credential.UniqueId, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		changed++
+
+		state := states[credential.UniqueId]
+		validity := peopleValidity[credential.PeopleId]
+		event := response.CredentialValidityEventResponse{
+			UniqueId: credential.UniqueId,
+			CardNo:   credential.CardNo,
+			PeopleId: credential.PeopleId,
+			Status:   status,
+		}
+		event.ValidFrom, event.ValidUntil = repository.EffectiveValidity(state.ValidFrom, state.ValidUntil, validity.ValidFrom, validity.ValidUntil)
+		if people, ok := peoples[credential.PeopleId]; ok {
+			event.PeopleCode = people.PeopleCode
+			event.PeopleName = peopleFullName(people)
+		}
+		s.EventHub.Publish(NewHubMessage(credentialValidityTopics[status], event))
+	}
+	return changed
+}
+
+// validate 校验请求并解析有效期，失效时间必须晚于生效时间
+func (s *CredentialValidityServiceImpl) validate(req request.UpdateValidityRequest) (uint, uint) {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	validFrom, err := ParseValidityTime(req.ValidFrom, false)
+	utils.ErrorPanic(err)
+	validUntil, err := ParseValidityTime(req.ValidUntil, true)
+	utils.ErrorPanic(err)
+	if validFrom > 0 && validUntil > 0 && validUntil <= validFrom {
+		panic("valid_until must be later than valid_from")
+	}
+	return validFrom, validUntil
+}
+
+// validityMaps 查询凭证状态和人员有效期，没有记录的凭证和人员为零值（不限制）
+func (s *CredentialValidityServiceImpl) validityMaps() (map[uint]model.CredentialState, map[uint]model.PeopleValidity) {
+	states := map[uint]model.CredentialState{}
+	for _, state := range s.CredentialValidityRepository.FindAllStates() {
+		states[state.UniqueId] = *state
+	}
+	peopleValidity := map[uint]model.PeopleValidity{}
+	for _, validity := range s.CredentialValidityRepository.FindAllPeopleValidity() {
+		peopleValidity[validity.PeopleId] = *validity
+	}
+	return states, peopleValidity
+}
+
+// ParseValidityTime 解析本地时间 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS，为空返回 0（不限制）
+// 失效时间（end 为 true）只有日期时取次日零点，即当天仍有效
+func ParseValidityTime(s string, end bool) (uint, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if t, err := time.ParseInLocation(time.DateTime, s, time.Local); err == nil {
+		return uint(t.Unix()), nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return 0, errors.New("invalid time " + s + ", expected YYYY-MM-DD or YYYY-MM-DD HH:MM:SS")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return uint(t.Unix()), nil
+}
+
+// FormatValidityTime 将有效期格式化为 ParseValidityTime 可解析的本地时间，0 返回空字符串
+// 失效时间（end 为 true）为零点时输出前一天的日期
+func FormatValidityTime(unix uint, end bool) string {
+	if unix == 0 {
+		return ""
+	}
+	t := time.Unix(int64(unix), 0).In(time.Local)
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+		return t.Format(time.DateTime)
+	}
+	if end {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+)
+
+// DataQualityService 数据质量报告的业务接口
+type DataQualityService interface {
+	Report() response.DataQualityResponse
+}
+
+// DataQualityServiceImpl 数据质量报告的业务实现
+// 门禁组在其他数据库中，无法用一条查询关联，报告在内存中汇总
+type DataQualityServiceImpl struct {
+	PeopleDirectoryRepository    repository.PeopleDirectoryRepository
+	GroupDirectoryRepository     repository.GroupDirectoryRepository
+	DepartmentTreeRepository     repository.DepartmentTreeRepository
+	CredentialValidityRepository repository.CredentialValidityRepository
+}
+
+// NewDataQualityServiceImpl 创建并返回一个新的 DataQualityServiceImpl 实例
+func NewDataQualityServiceImpl(
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	departmentTreeRepository repository.DepartmentTreeRepository,
+	credentialValidityRepository repository.CredentialValidityRepository,
+) DataQualityService {
+	return &DataQualityServiceImpl{
+		PeopleDirectoryRepository:    peopleDirectoryRepository,
+		GroupDirectoryRepository:     groupDirectoryRepository,
+		DepartmentTreeRepository:     departmentTreeRepository,
+		CredentialValidityRepository: credentialValidityRepository,
+	}
+}
+
+// DataQualitySource 生成数据质量报告所需的数据
+type DataQualitySource struct {
+	Peoples            []*model.People
+	Departments        []*model.Department
+	Credentials        []*model.Credential
+	CredentialAccesses []*model.CredentialAccess
+	DepartmentAccesses []*model.DepartmentAccess
+	AccessGroups       []*model.AccessGroup
+	States             []*model.CredentialState
+}
+
+// Report 生成数据质量报告
+func (s *DataQualityServiceImpl) Report() response.DataQualityResponse {
+	report := BuildDataQualityReport(DataQualitySource{
+		Peoples:            s.PeopleDirectoryRepository.FindAllPeople(),
+		Departments:        s.PeopleDirectoryRepository.FindAllDepartments(),
+		Credentials:        s.PeopleDirectoryRepository.FindAllCredentials(),
+		CredentialAccesses: s.PeopleDirectoryRepository.FindAllCredentialAccesses(),
+		DepartmentAccesses: s.DepartmentTreeRepository.FindAllAccess(),
+		AccessGroups:       s.GroupDirectoryRepository.FindAllAccessGroups(),
+		States:             s.CredentialValidityRepository.FindAllStates(),
+	})
+	report.GeneratedAt = uint(time.Now().Unix())
+	return report
+}
+
+// BuildDataQualityReport 汇总重复卡号、没有凭证的人员、没有门禁组的凭证和不存在的引用
+// 已停用的凭证门禁组暂存在凭证状态中，不视为没有门禁组；部门 ID 为 0 表示未分配部门
+func BuildDataQualityReport(source DataQualitySource) response.DataQualityResponse {
+	report := response.DataQualityResponse{
+		DuplicateCards:           []response.DuplicateCardResponse{},
+		PeopleWithoutCredential:  []response.DataQualityPeopleResponse{},
+		CredentialsWithoutAccess: []response.DataQualityCredentialResponse{},
+		MissingReferences:        []response.MissingReferenceResponse{},
+	}
+
+	peoples := map[uint]*model.People{}
+	for _, people := range source.Peoples {
+		peoples[people.ID] = people
+	}
+	departments := map[uint]bool{}
+	for _, department := range source.Departments {
+		departments[department.ID] = true
+	}
+	accessGroups := map[uint]bool{}
+	for _, accessGroup := range source.AccessGroups {
+		accessGroups[accessGroup.GroupId] = true
+	}
+	disabled := map[uint]bool{}
+	for _, state := range source.States {
+		disabled[state.UniqueId] = state.Disabled == 1
+	}
+	withAccess := map[uint]bool{}
+	for _, access := range source.CredentialAccesses {
+		withAccess[access.UniqueId] = true
+	}
+
+	toCredential := func(credential *model.Credential) response.DataQualityCredentialResponse {
+		credentialResponse := response.DataQualityCredentialResponse{
+			UniqueId: credential.UniqueId,
+			CardNo:   credential.CardNo,
+			PeopleId: credential.PeopleId,
+		}
+		if people, ok := peoples[credential.PeopleId]; ok {
+			credentialResponse.PeopleCode = people.PeopleCode
+			credentialResponse.PeopleName = peopleFullName(people)
+		}
+		return credentialResponse
+	}
+	addMissing := func(kind string, refId uint, missingKind string, missingId uint) {
+		report.MissingReferences = append(report.MissingReferences, response.MissingReferenceResponse{
+			Kind:        kind,
+			RefId:       refId,
+			MissingKind: missingKind,
+			MissingId:   missingId,
+		})
+	}
+
+	// 人员：没有凭证或部门不存在
+	withCredential := map[uint]bool{}
+	for _, credential := range source.Credentials {
+		withCredential[credential.PeopleId] = true
+	}
+	for _, people := range source.Peoples {
+		if !withCredential[people.ID] {
+			report.PeopleWithoutCredential = append(report.PeopleWithoutCredential, response.DataQualityPeopleResponse{
+				PeopleId:     people.ID,
+				PeopleCode:   people.PeopleCode,
+				PeopleName:   peopleFullName(people),
+				DepartmentId: people.DepartmentID,
+			})
+		}
+		if people.DepartmentID != 0 && !departments[people.DepartmentID] {
+			addMissing("people", people.ID, "department", people.DepartmentID)
+		}
+	}
+
+	// 凭证：重复卡号、没有门禁组或人员不存在
+	cards := map[string][]*model.Credential{}
+	var cardNos []string
+	for _, credential := range source.Credentials {
+		if credential.CardNo != "" {
+			if len(cards[credential.CardNo]) == 0 {
+				cardNos = append(cardNos, credential.CardNo)
+			}
+			cards[credential.CardNo] = append(cards[credential.CardNo], credential)
+		}
+		if !withAccess[credential.UniqueId] && !disabled[credential.UniqueId] {
+			report.CredentialsWithoutAccess = append(report.CredentialsWithoutAccess, toCredential(credential))
+		}
+		if _, ok := peoples[credential.PeopleId]; !ok {
+			addMissing("credential", credential.UniqueId, "people", credential.PeopleId)
+		}
+	}
+	sort.Strings(cardNos)
+	for _, cardNo := range cardNos {
+		if len(cards[cardNo]) < 2 {
+			continue
+		}
+		duplicate := response.DuplicateCardResponse{CardNo: cardNo}
+		for _, credential := range cards[cardNo] {
+			duplicate.Credentials = append(duplicate.Credentials, toCredential(credential))
+		}
+		report.DuplicateCards = append(report.DuplicateCards, duplicate)
+	}
+
+	// 门禁组：凭证或部门分配了不存在的门禁组
+	for _, access := range source.CredentialAccesses {
+		if !accessGroups[access.AccessGroupId] {
+			addMissing("credential_access", access.UniqueId, "access_group", access.AccessGroupId)
+		}
+	}
+	for _, access := range source.DepartmentAccesses {
+		if !departments[access.DepartmentId] {
+			addMissing("department_access", access.DepartmentId, "department", access.DepartmentId)
+		}
+		if !accessGroups[access.AccessGroupId] {
+			addMissing("department_access", access.DepartmentId, "access_group", access.AccessGroupId)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// DepartmentTreeService 部门层级和部门门禁组的业务接口
+type DepartmentTreeService interface {
+	Tree() []response.DepartmentTreeResponse
+	CreateChild(req request.CreateSubDepartmentRequest) response.DepartmentTreeResponse
+	Update(req request.UpdateDepartmentNodeRequest) response.DepartmentTreeResponse
+	FindAccess(id uint) response.DepartmentAccessResponse
+	SetAccess(req request.SetDepartmentAccessRequest) response.DepartmentAccessResponse
+	Delete(id uint, reassignTo uint)
+	Reconcile() int
+}
+
+// DepartmentTreeServiceImpl 部门层级和部门门禁组的业务实现
+// 部门可以有上级部门；分配给部门的门禁组由部门及其下级部门的人员的凭证继承，部门结构或人员部门变化后同步
+type DepartmentTreeServiceImpl struct {
+	DepartmentTreeRepository  repository.DepartmentTreeRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	GroupDirectoryRepository  repository.GroupDirectoryRepository
+	Validate                  *validator.Validate
+}
+
+// NewDepartmentTreeServiceImpl 创建并返回一个新的 DepartmentTreeServiceImpl 实例
+func NewDepartmentTreeServiceImpl(
+	departmentTreeRepository repository.DepartmentTreeRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	validate *validator.Validate,
+) DepartmentTreeService {
+	return &DepartmentTreeServiceImpl{
+		DepartmentTreeRepository:  departmentTreeRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		GroupDirectoryRepository:  groupDirectoryRepository,
+		Validate:                  validate,
+	}
+}
+
+// Tree 查询部门树，同级部门按名称排序
+func (s *DepartmentTreeServiceImpl) Tree() []response.DepartmentTreeResponse {
+	parents := s.parents()
+	departmentGroups := s.departmentGroups()
+
+	names := map[uint]string{}
+	for _, department := range s.PeopleDirectoryRepository.FindAllDepartments() {
+		names[department.ID] = department.Name
+	}
+	peopleCounts := map[uint]int{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peopleCounts[people.DepartmentID]++
+	}
+
+	// 上级部门不存在的部门作为顶级部门
+	children := map[uint][]uint{}
+	for id := range names {
+		parentId := parents[id]
+		if _, ok := names[parentId]; !ok {
+			parentId = 0
+		}
+		children[parentId] = append(children[parentId], id)
+	}
+	for _, ids := range children {
+		sort.Slice(ids, func(i, j int) bool {
+			if names[ids[i]] != names[ids[j]] {
+				return names[ids[i]] < names[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+	}
+
+	var build func(id uint, parentId uint, path string, inherited []uint) response.DepartmentTreeResponse
+	build = func(id uint, parentId uint, path string, inherited []uint) response.DepartmentTreeResponse {
+		node := response.DepartmentTreeResponse{
+			ID:                      id,
+			Name:                    names[id],
+			ParentId:                parentId,
+			Path:                    path + names[id],
+			PeopleCount:             peopleCounts[id],
+			TotalPeopleCount:        peopleCounts[id],
+			AccessGroupIds:          departmentGroups[id],
+			InheritedAccessGroupIds: inherited,
+			Children:                []response.DepartmentTreeResponse{},
+		}
+		if node.AccessGroupIds == nil {
+			node.AccessGroupIds = []uint{}
+		}
+
+		childInherited := slices.Clone(inherited)
+		for _, groupId := range node.AccessGroupIds {
+			if !slices.Contains(childInherited, groupId) {
+				childInherited = append(childInherited, groupId)
+			}
+		}
+		for _, childId := range children[id] {
+			child := build(childId, id, node.Path+"/", childInherited)
+			node.TotalPeopleCount += child.TotalPeopleCount
+			node.Children = append(node.Children, child)
+		}
+		return node
+	}
+
+	tree := []response.DepartmentTreeResponse{}
+	for _, id := range children[0] {
+		tree = append(tree, build(id, 0, "", []uint{}))
+	}
+	return tree
+}
+
+// CreateChild 创建下级部门
+func (s *DepartmentTreeServiceImpl) CreateChild(req request.CreateSubDepartmentRequest) response.DepartmentTreeResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	department, err := s.DepartmentTreeRepository.CreateChild(req.ParentId, req.Name)
+	utils.ErrorPanic(err)
+	return s.findNode(department.ID)
+}
+
+// Update 修改部门名称或移动到其他上级部门，移动后同步继承的门禁组
+func (s *DepartmentTreeServiceImpl) Update(req request.UpdateDepartmentNodeRequest) response.DepartmentTreeResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	err = s.DepartmentTreeRepository.Update(req.ID, req.Name, req.ParentId)
+	utils.ErrorPanic(err)
+	if req.ParentId != nil {
+		s.Reconcile()
+	}
+	return s.findNode(req.ID)
+}
+
+// FindAccess 查询部门的门禁组
+func (s *DepartmentTreeServiceImpl) FindAccess(id uint) response.DepartmentAccessResponse {
+	node := s.findNode(id)
+	return response.DepartmentAccessResponse{
+		DepartmentId:            id,
+		AccessGroupIds:          node.AccessGroupIds,
+		InheritedAccessGroupIds: node.InheritedAccessGroupIds,
+	}
+}
+
+// SetAccess 替换分配给部门的门禁组，并同步部门及其下级部门人员的凭证
+func (s *DepartmentTreeServiceImpl) SetAccess(req request.SetDepartmentAccessRequest) response.DepartmentAccessResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	groupIds := s.accessGroupIds()
+	accessGroupIds := []uint{}
+	for _, groupId := range req.AccessGroupIds {
+		if !slices.Contains(groupIds, groupId) {
+			panic("access group " + strconv.FormatUint(uint64(groupId), 10) + " not found")
+		}
+		if !slices.Contains(accessGroupIds, groupId) {
+			accessGroupIds = append(accessGroupIds, groupId)
+		}
+	}
+
+	err = s.DepartmentTreeRepository.SetAccess(req.ID, accessGroupIds)
+	utils.ErrorPanic(err)
+
+	accessResponse := s.FindAccess(req.ID)
+	accessResponse.Changed = s.Reconcile()
+	return accessResponse
+}
+
+// Delete 删除部门，部门有人员或下级部门时需指定转移到的部门 reassignTo，否则拒绝删除
+func (s *DepartmentTreeServiceImpl) Delete(id uint, reassignTo uint) {
+	err := s.DepartmentTreeRepository.Delete(id, reassignTo)
+	utils.ErrorPanic(err)
+	s.Reconcile()
+}
+
+// Reconcile 后台任务：同步凭证继承的部门门禁组，返回门禁组有变化的凭证数
+func (s *DepartmentTreeServiceImpl) Reconcile() int {
+	changed, err := s.DepartmentTreeRepository.Reconcile(s.accessGroupIds())
+	if err != nil {
+		log.Printf("department access: %v", err)
+	}
+	return changed
+}
+
+// findNode 在部门树中查找部门，不含下级部门
+func (s *DepartmentTreeServiceImpl) findNode(id uint) response.DepartmentTreeResponse {
+	var find func(nodes []response.DepartmentTreeResponse) *response.DepartmentTreeResponse
+	find = func(nodes []response.DepartmentTreeResponse) *response.DepartmentTreeResponse {
+		for i := range nodes {
+			if nodes[i].ID == id {
+				return &nodes[i]
+			}
+			if node := find(nodes[i].Children); node != nil {
+				return node
+			}
+		}
+		return nil
+	}
+
+	node := find(s.Tree())
+	if node == nil {
+		panic("department " + strconv.FormatUint(uint64(id), 10) + " not found")
+	}
+	node.Children = []response.DepartmentTreeResponse{}
+	return *node
+}
+
+// parents 查询所有部门的上级部门
+func (s *DepartmentTreeServiceImpl) parents() map[uint]uint {
+	parents := map[uint]uint{}
+	for _, node := range s.DepartmentTreeRepository.FindAllNodes() {
+		parents[node.DepartmentId] = node.ParentId
+	}
+	return parents
+}
+
+// departmentGroups 查询分配给各部门的门禁组
+func (s *DepartmentTreeServiceImpl) departmentGroups() map[uint][]uint {
+	departmentGroups := map[uint][]uint{}
+	for _, access := range s.DepartmentTreeRepository.FindAllAccess() {
+		departmentGroups[access.DepartmentId] = append(departmentGroups[access.DepartmentId], access.AccessGroupId)
+	}
+	return departmentGroups
+}
+
+// accessGroupIds 查询所有门禁组的编号
+func (s *DepartmentTreeServiceImpl) accessGroupIds() []uint {
+	var groupIds []uint
+	for _, accessGroup := range s.GroupDirectoryRepository.FindAllAccessGroups() {
+		groupIds = append(groupIds, accessGroup.GroupId)
+	}
+	return groupIds
+}
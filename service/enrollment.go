@@ -0,0 +1,271 @@
+package service
+
+import (
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 刷卡登记会话状态
+const (
+	EnrollmentWaiting   = "waiting"   // 等待刷卡
+	EnrollmentCompleted = "completed" // 已登记
+	EnrollmentFailed    = "failed"    // 登记失败，如卡号已被使用
+	EnrollmentExpired   = "expired"   // 已超时
+	EnrollmentCancelled = "cancelled" // 已取消
+)
+
+// 刷卡登记会话的时间限制
+const (
+	enrollmentDefaultTimeout = 60 * time.Second // 默认等待刷卡时间
+	enrollmentRetention      = time.Hour        // 结束的会话保留时间
+	enrollmentEventDelay     = 10 * time.Second // 事件同步延迟，超时后继续等待该时间以接收超时前的刷卡事件
+)
+
+// EnrollmentService 刷卡登记凭证的业务接口
+type EnrollmentService interface {
+	Start(req request.StartEnrollmentRequest) response.EnrollmentResponse
+	FindAll() []response.EnrollmentResponse
+	FindById(id uint) response.EnrollmentResponse
+	Cancel(id uint)
+	Capture(msg *HubMessage)
+	CreateFromEvent(req request.CreateCredentialFromEventRequest) response.EnrolledCredentialResponse
+}
+
+// enrollmentSession 刷卡登记会话，保存在内存中，服务重启后丢失
+type enrollmentSession struct {
+	response.EnrollmentResponse
+	accessGroupIds []uint
+	startedAt      time.Time
+	expiresAt      time.Time
+}
+
+// EnrollmentServiceImpl 刷卡登记凭证的业务实现
+// 订阅事件中心的刷卡事件，在会话的读卡器上刷的卡号登记为会话人员的凭证
+type EnrollmentServiceImpl struct {
+	EnrollmentRepository     repository.EnrollmentRepository
+	EventCursorRepository    repository.EventCursorRepository
+	GroupDirectoryRepository repository.GroupDirectoryRepository
+	DepartmentTreeService    DepartmentTreeService
+	DataSync                 func() // 凭证创建后同步数据到后端
+	Validate                 *validator.Validate
+
+	mu       sync.Mutex
+	nextId   uint
+	sessions []*enrollmentSession
+}
+
+// NewEnrollmentServiceImpl 创建并返回一个新的 EnrollmentServiceImpl 实例
+func NewEnrollmentServiceImpl(
+	enrollmentRepository repository.EnrollmentRepository,
+	eventCursorRepository repository.EventCursorRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	departmentTreeService DepartmentTreeService,
+	dataSync func(),
+	validate *validator.Validate,
+) EnrollmentService {
+	return &EnrollmentServiceImpl{
+		EnrollmentRepository:     enrollmentRepository,
+		EventCursorRepository:    eventCursorRepository,
+		GroupDirectoryRepository: groupDirectoryRepository,
+		DepartmentTreeService:    departmentTreeService,
+		DataSync:                 dataSync,
+		Validate:                 validate,
+	}
+}
+
+// Start 开始刷卡登记，同一读卡器同时只能有一个等待刷卡的会话
+func (s *EnrollmentServiceImpl) Start(req request.StartEnrollmentRequest) response.EnrollmentResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+	s.checkAccessGroups(req.AccessGroupIds)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+	for _, session := range s.sessions {
+		if session.Status == EnrollmentWaiting && session.IBAddr == req.IBAddr && session.ReaderAddr == req.ReaderAddr {
+			panic("reader already has a waiting enrollment session " + strconv.FormatUint(uint64(session.ID), 10))
+		}
+	}
+
+	timeout := enrollmentDefaultTimeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+	s.nextId++
+	session := &enrollmentSession{
+		EnrollmentResponse: response.EnrollmentResponse{
+			ID:         s.nextId,
+			IBAddr:     req.IBAddr,
+			ReaderAddr: req.ReaderAddr,
+			PeopleId:   req.PeopleId,
+			Status:     EnrollmentWaiting,
+			StartedAt:  uint(now.Unix()),
+			ExpiresAt:  uint(now.Add(timeout).Unix()),
+		},
+		accessGroupIds: req.AccessGroupIds,
+		startedAt:      now.Truncate(time.Second),
+		expiresAt:      now.Add(timeout),
+	}
+	s.sessions = append(s.sessions, session)
+	return session.EnrollmentResponse
+}
+
+// FindAll 查询最近的刷卡登记会话，最新的在前
+func (s *EnrollmentServiceImpl) FindAll() []response.EnrollmentResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(time.Now())
+	sessions := []response.EnrollmentResponse{}
+	for i := len(s.sessions) - 1; i >= 0; i-- {
+		sessions = append(sessions, s.sessions[i].EnrollmentResponse)
+	}
+	return sessions
+}
+
+// FindById 查询刷卡登记会话，供界面轮询结果
+func (s *EnrollmentServiceImpl) FindById(id uint) response.EnrollmentResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(time.Now())
+	return s.find(id).EnrollmentResponse
+}
+
+// Cancel 取消等待刷卡的会话
+func (s *EnrollmentServiceImpl) Cancel(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.find(id)
+	if session.Status == EnrollmentWaiting {
+		session.Status = EnrollmentCancelled
+	}
+}
+
+// Capture 事件中心订阅处理：会话读卡器上刷的卡登记为会话人员的凭证，卡号已被使用或已挂失时会话失败
+func (s *EnrollmentServiceImpl) Capture(msg *HubMessage) {
+	event := msg.Event
+	if event == nil || event.CardNo == "" || event.EventType == model.EventTypeSystem {
+		return
+	}
+
+	s.mu.Lock()
+	s.expire(time.Now())
+	var session *enrollmentSession
+	for _, candidate := range s.sessions {
+		if candidate.Status == EnrollmentWaiting && candidate.IBAddr == event.IBAddr && candidate.ReaderAddr == event.ReaderAddr &&
+			!msg.Time.Before(candidate.startedAt) && msg.Time.Before(candidate.expiresAt) {
+			session = candidate
+			break
+		}
+	}
+	if session == nil {
+		s.mu.Unlock()
+		return
+	}
+	session.CardNo = event.CardNo
+	session.MsgId = event.MsgId
+	session.Status = EnrollmentFailed
+	s.mu.Unlock()
+
+	credential, err := s.EnrollmentRepository.CreateCredential(session.PeopleId, event.CardNo, session.accessGroupIds)
+
+	s.mu.Lock()
+	if err != nil {
+		session.Message = err.Error()
+	} else {
+		session.Status = EnrollmentCompleted
+		session.UniqueId = credential.UniqueId
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("enrollment: session %d card %s: %v", session.ID, event.CardNo, err)
+		return
+	}
+	log.Printf("enrollment: session %d card %s enrolled as credential %d", session.ID, event.CardNo, credential.UniqueId)
+	s.enrolled()
+}
+
+// CreateFromEvent 将未注册卡的刷卡事件中的卡号登记为人员的凭证
+func (s *EnrollmentServiceImpl) CreateFromEvent(req request.CreateCredentialFromEventRequest) response.EnrolledCredentialResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+	s.checkAccessGroups(req.AccessGroupIds)
+
+	events := s.EventCursorRepository.FindEventsByMsgIds([]uint{req.MsgId})
+	if len(events) == 0 {
+		panic("event " + strconv.FormatUint(uint64(req.MsgId), 10) + " not found")
+	}
+	event := events[0]
+	if event.EventType != model.EventTypeDenied || event.UniqueId != 0 || event.CardNo == "" {
+		panic("event " + strconv.FormatUint(uint64(req.MsgId), 10) + " is not an unknown card event")
+	}
+
+	credential, err := s.EnrollmentRepository.CreateCredential(req.PeopleId, event.CardNo, req.AccessGroupIds)
+	utils.ErrorPanic(err)
+	s.enrolled()
+
+	return response.EnrolledCredentialResponse{
+		UniqueId: credential.UniqueId,
+		PeopleId: credential.PeopleId,
+		CardNo:   credential.CardNo,
+		MsgId:    event.MsgId,
+	}
+}
+
+// enrolled 凭证创建后同步部门门禁组并同步数据到后端
+func (s *EnrollmentServiceImpl) enrolled() {
+	s.DepartmentTreeService.Reconcile()
+	if s.DataSync != nil {
+		s.DataSync()
+	}
+}
+
+// checkAccessGroups 检查门禁组存在
+func (s *EnrollmentServiceImpl) checkAccessGroups(accessGroupIds []uint) {
+	var groupIds []uint
+	for _, accessGroup := range s.GroupDirectoryRepository.FindAllAccessGroups() {
+		groupIds = append(groupIds, accessGroup.GroupId)
+	}
+	for _, groupId := range accessGroupIds {
+		if !slices.Contains(groupIds, groupId) {
+			panic("access group " + strconv.FormatUint(uint64(groupId), 10) + " not found")
+		}
+	}
+}
+
+// find 查询会话，调用方需持有锁
+func (s *EnrollmentServiceImpl) find(id uint) *enrollmentSession {
+	for _, session := range s.sessions {
+		if session.ID == id {
+			return session
+		}
+	}
+	panic("enrollment session " + strconv.FormatUint(uint64(id), 10) + " not found")
+}
+
+// expire 将超时的会话标记为已超时，并清理结束较久的会话，调用方需持有锁
+func (s *EnrollmentServiceImpl) expire(now time.Time) {
+	s.sessions = slices.DeleteFunc(s.sessions, func(session *enrollmentSession) bool {
+		if session.Status == EnrollmentWaiting && !now.Before(session.expiresAt.Add(enrollmentEventDelay)) {
+			session.Status = EnrollmentExpired
+		}
+		return session.Status != EnrollmentWaiting && now.Sub(session.expiresAt) > enrollmentRetention
+	})
+}
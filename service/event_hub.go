@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 事件中心游标名称
+const eventHubCursorName = "event_hub"
+
+// 每次从事件消息数据库读取的最大条数
+const eventHubBatchSize = 200
+
+// HubMessage 事件中心分发的消息
+// 来源于同步到本地的事件消息（Event 不为空），或由各业务模块直接发布的报警、审计等消息
+type HubMessage struct {
+	Topic    string                          `json:"topic"`               // 主题，如 access.granted、alarm.event
+	Category string                          `json:"category"`            // 类别，取主题第一段
+	Time     time.Time                       `json:"time"`                // 发生时间
+	MsgId    uint                            `json:"msgid,omitempty"`     // 事件消息 ID，非事件消息时为 0
+	Event    *model.EventMessageData         `json:"event,omitempty"`     // 原始事件消息
+	Data     interface{}                     `json:"data,omitempty"`      // 附加数据
+	PhotoUrl string                          `json:"photo_url,omitempty"` // 事件人员的照片缩略图
+	Wiegand  *response.WiegandDecodeResponse `json:"wiegand,omitempty"`   // 事件卡号按韦根格式解码的设备码和卡号
+}
+
+// NewHubMessage 创建一条非事件消息来源的消息，类别由主题推导
+func NewHubMessage(topic string, data interface{}) *HubMessage {
+	return &HubMessage{
+		Topic:    topic,
+		Category: topicCategory(topic),
+		Time:     time.Now(),
+		Data:     data,
+	}
+}
+
+// newEventHubMessage 将事件消息转换为事件中心消息
+func newEventHubMessage(event *model.EventMessageData) *HubMessage {
+	topic := model.EventTopic(event.EventType)
+	return &HubMessage{
+		Topic:    topic,
+		Category: topicCategory(topic),
+		Time:     ParseAccessTime(event.AccessTime),
+		MsgId:    event.MsgId,
+		Event:    event,
+	}
+}
+
+// topicCategory 返回主题的类别（第一段）
+func topicCategory(topic string) string {
+	category, _, _ := strings.Cut(topic, ".")
+	return category
+}
+
+// ParseAccessTime 解析事件消息中的本地时间字符串，解析失败时返回当前时间
+func ParseAccessTime(accessTime string) time.Time {
+	t, err := time.ParseInLocation(time.DateTime, accessTime, time.Local)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// HubHandler 事件中心的订阅处理函数
+type HubHandler func(msg *HubMessage)
+
+// hubSubscriber 事件中心的订阅者
+type hubSubscriber struct {
+	name    string
+	handler HubHandler
+}
+
+// EventHub 事件中心，将新同步的事件消息和业务模块发布的消息分发给各订阅者
+// 已分发的位置记录在事件消息数据库的游标表中，重启后从上次位置继续
+type EventHub struct {
+	mu                    sync.RWMutex
+	pollMu                sync.Mutex
+	subscribers           []hubSubscriber
+	eventCursorRepository repository.EventCursorRepository
+	photoUrl              func(peopleId uint) string                                          // 人员照片地址，为 nil 时事件消息不带照片
+	wiegandDecoder        func(event *model.EventMessageData) *response.WiegandDecodeResponse // 韦根解码，为 nil 时事件消息不带解码结果
+}
+
+// NewEventHub 创建并返回一个新的 EventHub 实例
+func NewEventHub(eventCursorRepository repository.EventCursorRepository) *EventHub {
+	return &EventHub{
+		eventCursorRepository: eventCursorRepository,
+	}
+}
+
+// Subscribe 注册订阅者，订阅者按注册顺序接收消息
+func (hub *EventHub) Subscribe(name string, handler HubHandler) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.subscribers = append(hub.subscribers, hubSubscriber{name: name, handler: handler})
+}
+
+// SetPhotoResolver 设置人员照片地址的查询函数，事件消息附带事件人员的照片
+func (hub *EventHub) SetPhotoResolver(resolver func(peopleId uint) string) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.photoUrl = resolver
+}
+
+// SetWiegandDecoder 设置事件卡号的韦根解码函数，事件消息附带解码出的设备码和卡号
+func (hub *EventHub) SetWiegandDecoder(decoder func(event *model.EventMessageData) *response.WiegandDecodeResponse) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.wiegandDecoder = decoder
+}
+
+// Publish 将消息分发给所有订阅者，单个订阅者出错不影响其他订阅者
+func (hub *EventHub) Publish(msg *HubMessage) {
+	hub.mu.RLock()
+	subscribers := hub.subscribers
+	hub.mu.RUnlock()
+
+	for _, subscriber := range subscribers {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Printf("event hub: subscriber %s failed on %s: %v", subscriber.name, msg.Topic, err)
+				}
+			}()
+			subscriber.handler(msg)
+		}()
+	}
+}
+
+// Poll 读取游标之后的新事件消息并分发，首次运行时从当前最新消息开始，避免重放历史事件
+// 事件消息表中的最大 ID 小于游标时视为消息 ID 重新编号，从头分发
+func (hub *EventHub) Poll() {
+	hub.pollMu.Lock()
+	defer hub.pollMu.Unlock()
+
+	lastMsgId, found := loadEventCursor(hub.eventCursorRepository, eventHubCursorName)
+	if !found {
+		err := hub.eventCursorRepository.Save(eventHubCursorName, hub.eventCursorRepository.LastMsgId())
+		utils.ErrorPanic(err)
+		return
+	}
+	for {
+		events := hub.eventCursorRepository.FindEventsAfter(lastMsgId, eventHubBatchSize)
+		if len(events) == 0 {
+			break
+		}
+
+		hub.mu.RLock()
+		photoUrl := hub.photoUrl
+		wiegandDecoder := hub.wiegandDecoder
+		hub.mu.RUnlock()
+
+		for _, event := range events {
+			msg := newEventHubMessage(event)
+			if photoUrl != nil && event.PeopleId > 0 {
+				msg.PhotoUrl = photoUrl(event.PeopleId)
+			}
+			if wiegandDecoder != nil {
+				msg.Wiegand = wiegandDecoder(event)
+			}
+			hub.Publish(msg)
+			lastMsgId = event.MsgId
+		}
+
+		err := hub.eventCursorRepository.Save(eventHubCursorName, lastMsgId)
+		utils.ErrorPanic(err)
+
+		if len(events) < eventHubBatchSize {
+			break
+		}
+	}
+}
+
+// loadEventCursor 读取事件消费者的游标，游标不存在时 found 为 false
+// 后端重置或数据库被替换后消息 ID 重新从小编号开始，事件消息表中的最大 ID 小于游标时游标回到起点，否则新事件永远不会被处理
+func loadEventCursor(eventCursorRepository repository.EventCursorRepository, name string) (lastMsgId uint, found bool) {
+	cursor, err := eventCursorRepository.FindByName(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false
+	}
+	utils.ErrorPanic(err)
+
+	lastMsgId = cursor.LastMsgId
+	if maxMsgId := eventCursorRepository.LastMsgId(); maxMsgId < lastMsgId {
+		log.Printf("%s: message id restarted (cursor %d, latest %d), resetting cursor", name, lastMsgId, maxMsgId)
+		lastMsgId = 0
+		err = eventCursorRepository.Save(name, lastMsgId)
+		utils.ErrorPanic(err)
+	}
+	return lastMsgId, true
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 挂失卡刷卡报警的事件中心主题
+const lostCardAlarmTopic = "alarm.lost_card"
+
+// LostCardService 挂失卡的业务接口
+type LostCardService interface {
+	FindAll() []response.LostCardResponse
+	Report(req request.ReportLostCardRequest) response.LostCardResponse
+	Replace(req request.ReplaceLostCardRequest) response.LostCardResponse
+	Delete(id uint)
+	Check(msg *HubMessage)
+}
+
+// LostCardServiceImpl 挂失卡的业务实现
+// 订阅事件中心的事件消息，黑名单中的卡号再次出现时发布高优先级报警
+type LostCardServiceImpl struct {
+	LostCardRepository        repository.LostCardRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	DepartmentTreeService     DepartmentTreeService
+	EventHub                  *EventHub
+	Validate                  *validator.Validate
+
+	mu        sync.Mutex
+	blocklist map[string]*model.LostCard // 卡号对应的挂失卡，为 nil 时重新加载
+}
+
+// NewLostCardServiceImpl 创建并返回一个新的 LostCardServiceImpl 实例
+func NewLostCardServiceImpl(
+	lostCardRepository repository.LostCardRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	departmentTreeService DepartmentTreeService,
+	eventHub *EventHub,
+	validate *validator.Validate,
+) LostCardService {
+	return &LostCardServiceImpl{
+		LostCardRepository:        lostCardRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		DepartmentTreeService:     departmentTreeService,
+		EventHub:                  eventHub,
+		Validate:                  validate,
+	}
+}
+
+// FindAll 查询黑名单中的所有挂失卡
+func (s *LostCardServiceImpl) FindAll() []response.LostCardResponse {
+	peoples := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peoples[people.ID] = people
+	}
+
+	lostCards := []response.LostCardResponse{}
+	for _, lostCard := range s.LostCardRepository.FindAll() {
+		lostCards = append(lostCards, toLostCardResponse(lostCard, peoples[lostCard.PeopleId]))
+	}
+	return lostCards
+}
+
+// Report 挂失凭证，卡号加入黑名单，原凭证删除
+func (s *LostCardServiceImpl) Report(req request.ReportLostCardRequest) response.LostCardResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	lostCard, err := s.LostCardRepository.Report(req.UniqueId, req.Reason, req.Note)
+	utils.ErrorPanic(err)
+	s.invalidate()
+	return toLostCardResponse(lostCard, s.findPeople(lostCard.PeopleId))
+}
+
+// Replace 补发新卡，恢复原凭证的门禁组和有效期，部门继承的门禁组随后同步
+func (s *LostCardServiceImpl) Replace(req request.ReplaceLostCardRequest) response.LostCardResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	_, err = s.LostCardRepository.Replace(req.ID, req.CardNo)
+	utils.ErrorPanic(err)
+	s.DepartmentTreeService.Reconcile()
+
+	lostCard, err := s.LostCardRepository.FindById(req.ID)
+	utils.ErrorPanic(err)
+	return toLostCardResponse(lostCard, s.findPeople(lostCard.PeopleId))
+}
+
+// Delete 将卡号移出黑名单
+func (s *LostCardServiceImpl) Delete(id uint) {
+	err := s.LostCardRepository.Delete(id)
+	utils.ErrorPanic(err)
+	s.invalidate()
+}
+
+// Check 事件中心订阅处理：黑名单中的卡号再次刷卡时发布报警并记录
+func (s *LostCardServiceImpl) Check(msg *HubMessage) {
+	event := msg.Event
+	if event == nil || event.CardNo == "" {
+		return
+	}
+
+	s.mu.Lock()
+	s.load()
+	lostCard := s.blocklist[event.CardNo]
+	s.mu.Unlock()
+	if lostCard == nil {
+		return
+	}
+
+	if err := s.LostCardRepository.RecordSeen(lostCard.ID, uint(msg.Time.Unix())); err != nil {
+		log.Printf("lost card: %v", err)
+	}
+
+	alarm := response.LostCardAlarmResponse{
+		Priority:   "high",
+		LostCardId: lostCard.ID,
+		CardNo:     lostCard.CardNo,
+		Reason:     lostCard.Reason,
+		PeopleId:   lostCard.PeopleId,
+		MsgId:      event.MsgId,
+		AccessTime: event.AccessTime,
+		IBAddr:     event.IBAddr,
+		ReaderAddr: event.ReaderAddr,
+		IBName:     event.IBName,
+		ReaderName: event.ReaderName,
+	}
+	if people := s.findPeople(lostCard.PeopleId); people != nil {
+		alarm.PeopleCode = people.PeopleCode
+		alarm.PeopleName = peopleFullName(people)
+	}
+	log.Printf("lost card: card %s presented at %s %s", lostCard.CardNo, event.IBName, event.ReaderName)
+
+	alarmMsg := NewHubMessage(lostCardAlarmTopic, alarm)
+	alarmMsg.Time = msg.Time
+	alarmMsg.MsgId = event.MsgId
+	s.EventHub.Publish(alarmMsg)
+}
+
+// invalidate 黑名单变更后，下次处理事件时重新加载
+func (s *LostCardServiceImpl) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocklist = nil
+}
+
+// load 加载黑名单，调用方需持有锁
+func (s *LostCardServiceImpl) load() {
+	if s.blocklist != nil {
+		return
+	}
+
+	blocklist := map[string]*model.LostCard{}
+	for _, lostCard := range s.LostCardRepository.FindAll() {
+		if _, ok := blocklist[lostCard.CardNo]; !ok {
+			blocklist[lostCard.CardNo] = lostCard
+		}
+	}
+	s.blocklist = blocklist
+}
+
+// findPeople 查询人员，不存在时返回 nil
+func (s *LostCardServiceImpl) findPeople(peopleId uint) *model.People {
+	peoples := s.PeopleDirectoryRepository.FindPeopleByIds([]uint{peopleId})
+	if len(peoples) == 0 {
+		return nil
+	}
+	return peoples[0]
+}
+
+// toLostCardResponse 将挂失卡转换为响应
+func toLostCardResponse(lostCard *model.LostCard, people *model.People) response.LostCardResponse {
+	lostCardResponse := response.LostCardResponse{
+		ID:             lostCard.ID,
+		CardNo:         lostCard.CardNo,
+		PeopleId:       lostCard.PeopleId,
+		UniqueId:       lostCard.UniqueId,
+		Reason:         lostCard.Reason,
+		Note:           lostCard.Note,
+		AccessGroupIds: []uint{},
+		ReportedAt:     lostCard.ReportedAt,
+		ReplacedBy:     lostCard.ReplacedBy,
+		LastSeenAt:     lostCard.LastSeenAt,
+		SeenCount:      lostCard.SeenCount,
+	}
+	if lostCard.AccessGroups != "" {
+		_ = json.Unmarshal([]byte(lostCard.AccessGroups), &lostCardResponse.AccessGroupIds)
+	}
+	if people != nil {
+		lostCardResponse.PeopleCode = people.PeopleCode
+		lostCardResponse.PeopleName = peopleFullName(people)
+	}
+	return lostCardResponse
+}
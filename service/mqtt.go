@@ -0,0 +1,499 @@
+package service
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 离线时缓存的最大消息条数，超出后丢弃最早的消息
+const mqttBufferSize = 500
+
+// MqttService MQTT 客户端的业务接口
+type MqttService interface {
+	FindConfig() response.MqttConfigResponse
+	UpdateConfig(req request.UpdateMqttConfigRequest) response.MqttConfigResponse
+	Status() response.MqttStatusResponse
+	Test()
+	PublishEvent(msg *HubMessage)
+	Tick()
+}
+
+// mqttOutbound 等待发布的消息
+type mqttOutbound struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// mqttCommand 命令主题的消息内容
+type mqttCommand struct {
+	Token      string `json:"token"`      // 认证令牌，需与配置的 CommandToken 一致
+	RequestId  string `json:"request_id"` // 请求 ID，原样返回在结果中
+	IBAddr     *uint  `json:"ibaddr"`     // 接口板地址
+	OutputAddr *uint  `json:"outputaddr"` // 输出地址
+	Mode       *uint  `json:"mode"`       // 模式
+}
+
+// mqttCommandResult 命令执行结果
+type mqttCommandResult struct {
+	RequestId string          `json:"request_id"`
+	Command   string          `json:"command"`
+	Success   bool            `json:"success"`
+	Message   string          `json:"message,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+}
+
+// MqttServiceImpl MQTT 客户端的业务实现
+// 发布事件消息和设备状态变化，接收开门、输出控制命令；断线期间的消息缓存在内存中，重连后补发
+type MqttServiceImpl struct {
+	MqttConfigRepository repository.MqttConfigRepository
+	Validate             *validator.Validate
+
+	mu             sync.Mutex
+	started        bool
+	config         *model.MqttConfig
+	client         mqtt.Client
+	buffer         []mqttOutbound
+	dropped        uint
+	lastError      string
+	lastStatusPoll time.Time
+	deviceStatus   map[string]string
+}
+
+// NewMqttServiceImpl 创建并返回一个新的 MqttServiceImpl 实例
+func NewMqttServiceImpl(mqttConfigRepository repository.MqttConfigRepository, validate *validator.Validate) MqttService {
+	return &MqttServiceImpl{
+		MqttConfigRepository: mqttConfigRepository,
+		Validate:             validate,
+		deviceStatus:         map[string]string{},
+	}
+}
+
+// FindConfig 查询 MQTT 配置
+func (s *MqttServiceImpl) FindConfig() response.MqttConfigResponse {
+	config, err := s.MqttConfigRepository.Find()
+	utils.ErrorPanic(err)
+
+	return toMqttConfigResponse(config)
+}
+
+// UpdateConfig 更新 MQTT 配置并按新配置重新连接
+func (s *MqttServiceImpl) UpdateConfig(req request.UpdateMqttConfigRequest) response.MqttConfigResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	config, err := s.MqttConfigRepository.Find()
+	utils.ErrorPanic(err)
+
+	if req.Enable != nil {
+		config.Enable = boolToUint(*req.Enable)
+	}
+	if req.BrokerURL != nil {
+		config.BrokerURL = *req.BrokerURL
+	}
+	if req.ClientId != nil {
+		config.ClientId = *req.ClientId
+	}
+	if req.Username != nil {
+		config.Username = *req.Username
+	}
+	if req.Password != nil {
+		config.Password = *req.Password
+	}
+	if req.TopicPrefix != nil {
+		config.TopicPrefix = strings.Trim(*req.TopicPrefix, "/")
+	}
+	if req.QoS != nil {
+		config.QoS = *req.QoS
+	}
+	if req.CACert != nil {
+		config.CACert = *req.CACert
+	}
+	if req.InsecureSkipVerify != nil {
+		config.InsecureSkipVerify = boolToUint(*req.InsecureSkipVerify)
+	}
+	if req.CommandToken != nil {
+		config.CommandToken = *req.CommandToken
+	}
+	if req.PublishEvents != nil {
+		config.PublishEvents = boolToUint(*req.PublishEvents)
+	}
+	if req.PublishStatus != nil {
+		config.PublishStatus = boolToUint(*req.PublishStatus)
+	}
+	if req.StatusInterval != nil {
+		config.StatusInterval = *req.StatusInterval
+	}
+
+	if config.Enable == 1 && config.BrokerURL == "" {
+		panic("broker_url is required when mqtt is enabled")
+	}
+	if _, err := mqttTLSConfig(config); err != nil {
+		panic(err)
+	}
+
+	newConfig, err := s.MqttConfigRepository.Save(*config)
+	utils.ErrorPanic(err)
+
+	s.mu.Lock()
+	s.started = true
+	s.connect(newConfig)
+	s.mu.Unlock()
+
+	return toMqttConfigResponse(newConfig)
+}
+
+// Status 查询 MQTT 连接状态
+func (s *MqttServiceImpl) Status() response.MqttStatusResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := response.MqttStatusResponse{
+		Buffered:  len(s.buffer),
+		Dropped:   s.dropped,
+		LastError: s.lastError,
+	}
+	if s.config != nil {
+		status.Enable = s.config.Enable == 1
+	}
+	if s.client != nil {
+		status.Connected = s.client.IsConnectionOpen()
+	}
+	return status
+}
+
+// Test 发布一条测试消息到 <prefix>/test
+func (s *MqttServiceImpl) Test() {
+	s.mu.Lock()
+	enabled := s.client != nil
+	s.mu.Unlock()
+	if !enabled {
+		panic("mqtt is not enabled")
+	}
+
+	payload, err := json.Marshal(NewHubMessage("mqtt.test", nil))
+	utils.ErrorPanic(err)
+
+	s.publish("test", payload, false)
+}
+
+// PublishEvent 事件中心订阅函数，将消息发布到 <prefix>/event/<类别>/<名称>
+func (s *MqttServiceImpl) PublishEvent(msg *HubMessage) {
+	s.mu.Lock()
+	publishEvents := s.config != nil && s.config.PublishEvents == 1
+	s.mu.Unlock()
+	if !publishEvents {
+		return
+	}
+
+	payload, err := json.Marshal(msg)
+	utils.ErrorPanic(err)
+
+	s.publish("event/"+strings.ReplaceAll(msg.Topic, ".", "/"), payload, false)
+}
+
+// Tick 后台任务：首次运行时按配置建立连接，之后按间隔轮询并发布设备状态变化
+func (s *MqttServiceImpl) Tick() {
+	s.mu.Lock()
+	if !s.started {
+		s.started = true
+		config, err := s.MqttConfigRepository.Find()
+		if err != nil {
+			s.mu.Unlock()
+			panic(err)
+		}
+		s.connect(config)
+	}
+
+	config := s.config
+	if config == nil || config.Enable == 0 || config.PublishStatus == 0 ||
+		time.Since(s.lastStatusPoll) < time.Duration(config.StatusInterval)*time.Second {
+		s.mu.Unlock()
+		return
+	}
+	s.lastStatusPoll = time.Now()
+	s.mu.Unlock()
+
+	s.pollDeviceStatus()
+}
+
+// connect 按配置重新创建客户端，调用方需持有 s.mu
+func (s *MqttServiceImpl) connect(config *model.MqttConfig) {
+	if s.client != nil {
+		s.client.Disconnect(250)
+		s.client = nil
+	}
+	s.config = config
+	s.deviceStatus = map[string]string{}
+	s.lastError = ""
+
+	if config.Enable == 0 || config.BrokerURL == "" {
+		return
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.BrokerURL).
+		SetClientID(config.ClientId).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetWill(mqttTopic(config, "online"), "offline", byte(config.QoS), true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10 * time.Second).
+		SetOnConnectHandler(s.onConnect).
+		SetConnectionLostHandler(s.onConnectionLost)
+
+	tlsConfig, err := mqttTLSConfig(config)
+	if err != nil {
+		s.lastError = err.Error()
+		return
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	log.Printf("mqtt: connecting to %s", config.BrokerURL)
+	s.client = mqtt.NewClient(opts)
+	// 开启 ConnectRetry 后，Connect 在后台重试直到连接成功，这里不等待结果
+	s.client.Connect()
+}
+
+// onConnect 连接成功：发布在线状态、订阅命令主题并补发离线期间缓存的消息
+func (s *MqttServiceImpl) onConnect(client mqtt.Client) {
+	s.mu.Lock()
+	config := s.config
+	buffer := s.buffer
+	s.buffer = nil
+	s.lastError = ""
+	s.mu.Unlock()
+
+	log.Printf("mqtt: connected, flushing %d buffered messages", len(buffer))
+
+	qos := byte(config.QoS)
+	client.Publish(mqttTopic(config, "online"), qos, true, "online")
+	client.Subscribe(mqttTopic(config, "command/+"), qos, s.onCommand)
+
+	for _, outbound := range buffer {
+		client.Publish(outbound.topic, qos, outbound.retained, outbound.payload)
+	}
+}
+
+// onConnectionLost 连接断开，客户端会自动重连
+func (s *MqttServiceImpl) onConnectionLost(client mqtt.Client, err error) {
+	log.Printf("mqtt: connection lost: %v", err)
+
+	s.mu.Lock()
+	s.lastError = err.Error()
+	s.mu.Unlock()
+}
+
+// onCommand 处理 <prefix>/command/<name> 主题的命令，结果发布到 <prefix>/command/<name>/result
+func (s *MqttServiceImpl) onCommand(client mqtt.Client, message mqtt.Message) {
+	name := path.Base(message.Topic())
+	result := mqttCommandResult{Command: name}
+
+	var cmd mqttCommand
+	if err := json.Unmarshal(message.Payload(), &cmd); err != nil {
+		result.Message = "invalid payload"
+	} else {
+		result.RequestId = cmd.RequestId
+		if !s.authorized(cmd.Token) {
+			result.Message = "unauthorized"
+		} else {
+			log.Printf("mqtt: command %s request_id=%s", name, cmd.RequestId)
+			result.Result, result.Success, result.Message = executeMqttCommand(name, cmd)
+		}
+	}
+
+	payload, err := json.Marshal(result)
+	utils.ErrorPanic(err)
+
+	s.publish("command/"+name+"/result", payload, false)
+}
+
+// authorized 校验命令令牌，未配置令牌时拒绝所有命令
+func (s *MqttServiceImpl) authorized(token string) bool {
+	s.mu.Lock()
+	expected := ""
+	if s.config != nil {
+		expected = s.config.CommandToken
+	}
+	s.mu.Unlock()
+
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// publish 发布消息，未连接时写入离线缓存
+func (s *MqttServiceImpl) publish(suffix string, payload []byte, retained bool) {
+	s.mu.Lock()
+	client, config := s.client, s.config
+	if client == nil || config == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	topic := mqttTopic(config, suffix)
+	if !client.IsConnectionOpen() {
+		if len(s.buffer) >= mqttBufferSize {
+			s.buffer = s.buffer[1:]
+			s.dropped++
+		}
+		s.buffer = append(s.buffer, mqttOutbound{topic: topic, payload: payload, retained: retained})
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	client.Publish(topic, byte(config.QoS), retained, payload)
+}
+
+// pollDeviceStatus 轮询后端设备状态，将发生变化的接口板状态发布到 <prefix>/status/<ibaddr>（保留消息）
+func (s *MqttServiceImpl) pollDeviceStatus() {
+	body, err := BackendPost("api/statussync", nil)
+	if err != nil {
+		log.Printf("mqtt: status sync failed: %v", err)
+		return
+	}
+
+	var backendResponse BackendResponse
+	utils.ErrorPanic(json.Unmarshal(body, &backendResponse))
+
+	var boards []map[string]interface{}
+	utils.ErrorPanic(json.Unmarshal(backendResponse.Content, &boards))
+
+	for _, board := range boards {
+		ibaddr := strconv.Itoa(int(toFloat(board["ibaddr"])))
+		payload, err := json.Marshal(board)
+		utils.ErrorPanic(err)
+
+		s.mu.Lock()
+		changed := s.deviceStatus[ibaddr] != string(payload)
+		s.deviceStatus[ibaddr] = string(payload)
+		s.mu.Unlock()
+
+		if changed {
+			s.publish("status/"+ibaddr, payload, true)
+		}
+	}
+}
+
+// executeMqttCommand 执行命令：door_open 远程开门，output 设置输出状态
+func executeMqttCommand(name string, cmd mqttCommand) (json.RawMessage, bool, string) {
+	if cmd.IBAddr == nil || cmd.OutputAddr == nil {
+		return nil, false, "ibaddr and outputaddr are required"
+	}
+
+	var mode uint
+	switch name {
+	case "door_open":
+		if cmd.Mode != nil {
+			mode = *cmd.Mode
+		}
+	case "output":
+		if cmd.Mode == nil {
+			return nil, false, "mode is required"
+		}
+		mode = *cmd.Mode
+	default:
+		return nil, false, "unknown command"
+	}
+
+	data := url.Values{}
+	data.Set("ibaddr", strconv.FormatUint(uint64(*cmd.IBAddr), 10))
+	data.Set("outputaddr", strconv.FormatUint(uint64(*cmd.OutputAddr), 10))
+	data.Set("mode", strconv.FormatUint(uint64(mode), 10))
+
+	body, err := BackendPost("api/dooropen", data)
+	if err != nil {
+		return nil, false, err.Error()
+	}
+	if !json.Valid(body) {
+		return nil, true, string(body)
+	}
+	return body, true, ""
+}
+
+// mqttTopic 拼接带前缀的主题
+func mqttTopic(config *model.MqttConfig, suffix string) string {
+	if config.TopicPrefix == "" {
+		return suffix
+	}
+	return config.TopicPrefix + "/" + suffix
+}
+
+// mqttTLSConfig 根据服务器地址协议生成 TLS 配置，非 TLS 地址返回 nil
+func mqttTLSConfig(config *model.MqttConfig) (*tls.Config, error) {
+	if config.BrokerURL == "" {
+		return nil, nil
+	}
+	brokerURL, err := url.Parse(config.BrokerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch brokerURL.Scheme {
+	case "tcp", "mqtt", "ws":
+		return nil, nil
+	case "ssl", "tls", "mqtts", "tcps", "wss":
+	default:
+		return nil, errors.New("unsupported broker scheme: " + brokerURL.Scheme)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify == 1,
+	}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("invalid CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// toFloat 将 JSON 解析出的数字转换为 float64
+func toFloat(v interface{}) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return 0
+}
+
+// toMqttConfigResponse 将 MQTT 配置转换为响应结构，不返回密码和命令令牌
+func toMqttConfigResponse(config *model.MqttConfig) response.MqttConfigResponse {
+	return response.MqttConfigResponse{
+		Enable:             config.Enable == 1,
+		BrokerURL:          config.BrokerURL,
+		ClientId:           config.ClientId,
+		Username:           config.Username,
+		HasPassword:        config.Password != "",
+		TopicPrefix:        config.TopicPrefix,
+		QoS:                config.QoS,
+		CACert:             config.CACert,
+		InsecureSkipVerify: config.InsecureSkipVerify == 1,
+		HasCommandToken:    config.CommandToken != "",
+		PublishEvents:      config.PublishEvents == 1,
+		PublishStatus:      config.PublishStatus == 1,
+		StatusInterval:     config.StatusInterval,
+	}
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 区域超员报警的事件中心主题
+const occupancyAlarmTopic = "alarm.occupancy"
+
+// OccupancyService 区域人数统计与紧急疏散点名的业务接口
+type OccupancyService interface {
+	CreateArea(req request.CreateAreaRequest) response.AreaResponse
+	UpdateArea(req request.UpdateAreaRequest)
+	DeleteArea(areaId uint)
+	FindAllAreas() []response.AreaResponse
+	Track(msg *HubMessage)
+	Summary() []response.AreaOccupancyResponse
+	Muster(areaId uint) []response.OccupantResponse
+	ExportMuster(areaId uint) []byte
+	Remove(peopleId uint)
+	Reset(areaId uint)
+}
+
+// readerKey 读卡器的唯一标识
+type readerKey struct {
+	IBAddr     int
+	ReaderAddr int
+}
+
+// OccupancyServiceImpl 区域人数统计的业务实现
+// 订阅事件中心的允许通行事件，根据读卡器所属区域和方向维护每人当前所在区域
+type OccupancyServiceImpl struct {
+	AreaRepository            repository.AreaRepository
+	OccupancyRepository       repository.OccupancyRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	PeoplePhotoService        PeoplePhotoService
+	EventHub                  *EventHub
+	Validate                  *validator.Validate
+
+	mu           sync.Mutex
+	readers      map[readerKey][]*model.AreaReader // 读卡器所属区域，为 nil 时重新加载
+	areas        map[uint]*model.Area              // 区域
+	overCapacity map[uint]bool                     // 已报警的超员区域，人数恢复后清除
+}
+
+// NewOccupancyServiceImpl 创建并返回一个新的 OccupancyServiceImpl 实例
+func NewOccupancyServiceImpl(
+	areaRepository repository.AreaRepository,
+	occupancyRepository repository.OccupancyRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	peoplePhotoService PeoplePhotoService,
+	eventHub *EventHub,
+	validate *validator.Validate,
+) OccupancyService {
+	return &OccupancyServiceImpl{
+		AreaRepository:            areaRepository,
+		OccupancyRepository:       occupancyRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		PeoplePhotoService:        peoplePhotoService,
+		EventHub:                  eventHub,
+		Validate:                  validate,
+		overCapacity:              map[uint]bool{},
+	}
+}
+
+// CreateArea 创建区域
+func (s *OccupancyServiceImpl) CreateArea(req request.CreateAreaRequest) response.AreaResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	readers := toAreaReaders(req.Readers)
+	area, err := s.AreaRepository.Save(model.Area{
+		Name:     req.Name,
+		Capacity: req.Capacity,
+	}, readers)
+	utils.ErrorPanic(err)
+	s.invalidate()
+
+	return toAreaResponse(area, readers)
+}
+
+// UpdateArea 更新区域
+func (s *OccupancyServiceImpl) UpdateArea(req request.UpdateAreaRequest) {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	area, areaReaders, err := s.AreaRepository.FindById(req.ID)
+	utils.ErrorPanic(err)
+
+	if req.Name != nil {
+		area.Name = *req.Name
+	}
+	if req.Capacity != nil {
+		area.Capacity = *req.Capacity
+	}
+
+	var readers []model.AreaReader
+	if req.Readers != nil {
+		readers = toAreaReaders(*req.Readers)
+	} else {
+		for _, reader := range areaReaders {
+			readers = append(readers, *reader)
+		}
+	}
+
+	err = s.AreaRepository.Update(*area, readers)
+	utils.ErrorPanic(err)
+	s.invalidate()
+}
+
+// DeleteArea 删除区域，区域内人员记录一并清除
+func (s *OccupancyServiceImpl) DeleteArea(areaId uint) {
+	s.AreaRepository.Delete(areaId)
+	s.OccupancyRepository.DeleteByAreaId(areaId)
+	s.invalidate()
+}
+
+// FindAllAreas 查询所有区域及其读卡器
+func (s *OccupancyServiceImpl) FindAllAreas() []response.AreaResponse {
+	areas := s.AreaRepository.FindAll()
+	readers := map[uint][]model.AreaReader{}
+	for _, reader := range s.AreaRepository.FindAllReaders() {
+		readers[reader.AreaID] = append(readers[reader.AreaID], *reader)
+	}
+
+	areaResponses := make([]response.AreaResponse, 0, len(areas))
+	for _, area := range areas {
+		areaResponses = append(areaResponses, toAreaResponse(area, readers[area.ID]))
+	}
+	return areaResponses
+}
+
+// Track 事件中心订阅处理：根据允许通行事件更新人员所在区域，进入后超员时发布报警
+func (s *OccupancyServiceImpl) Track(msg *HubMessage) {
+	event := msg.Event
+	if event == nil || event.EventType != model.EventTypeGranted {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.load()
+	mappings := s.readers[readerKey{IBAddr: event.IBAddr, ReaderAddr: event.ReaderAddr}]
+	if len(mappings) == 0 {
+		return
+	}
+
+	peopleId := event.PeopleId
+	if peopleId == 0 && event.CardNo != "" {
+		credential, err := s.PeopleDirectoryRepository.FindCredentialByCardNo(event.CardNo)
+		if err == nil {
+			peopleId = credential.PeopleId
+		}
+	}
+	if peopleId == 0 {
+		return
+	}
+
+	var currentAreaId uint
+	current, err := s.OccupancyRepository.FindByPeopleId(peopleId)
+	if err == nil {
+		currentAreaId = current.AreaID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorPanic(err)
+	}
+
+	areaId, changed := AreaTransition(currentAreaId, mappings)
+	if !changed {
+		return
+	}
+
+	if areaId == 0 {
+		s.OccupancyRepository.DeleteByPeopleId(peopleId)
+		s.checkCapacity(currentAreaId)
+		return
+	}
+
+	err = s.OccupancyRepository.Enter(model.Occupancy{
+		PeopleId:   peopleId,
+		AreaID:     areaId,
+		PeopleCode: event.PeopleCode,
+		PeopleName: event.FullName,
+		Department: event.PeopleDepart,
+		CardNo:     event.CardNo,
+		ReaderName: event.ReaderName,
+		MsgId:      event.MsgId,
+		EnteredAt:  uint(msg.Time.Unix()),
+	})
+	utils.ErrorPanic(err)
+
+	if currentAreaId > 0 {
+		s.checkCapacity(currentAreaId)
+	}
+	s.checkCapacity(areaId)
+}
+
+// Summary 查询各区域当前人数
+func (s *OccupancyServiceImpl) Summary() []response.AreaOccupancyResponse {
+	areas := s.AreaRepository.FindAll()
+
+	summaries := make([]response.AreaOccupancyResponse, 0, len(areas))
+	for _, area := range areas {
+		count := s.OccupancyRepository.CountByArea(area.ID)
+		summaries = append(summaries, response.AreaOccupancyResponse{
+			AreaID:       area.ID,
+			AreaName:     area.Name,
+			Capacity:     area.Capacity,
+			Count:        count,
+			OverCapacity: area.Capacity > 0 && count > int64(area.Capacity),
+		})
+	}
+	return summaries
+}
+
+// Muster 查询区域内人员名单及照片，areaId 为 0 时查询所有区域
+func (s *OccupancyServiceImpl) Muster(areaId uint) []response.OccupantResponse {
+	occupancies := s.OccupancyRepository.FindAll(areaId)
+	areaNames := map[uint]string{}
+	for _, area := range s.AreaRepository.FindAll() {
+		areaNames[area.ID] = area.Name
+	}
+
+	occupants := make([]response.OccupantResponse, 0, len(occupancies))
+	for _, occupancy := range occupancies {
+		occupants = append(occupants, response.OccupantResponse{
+			PeopleId:   occupancy.PeopleId,
+			PeopleCode: occupancy.PeopleCode,
+			PeopleName: occupancy.PeopleName,
+			Department: occupancy.Department,
+			CardNo:     occupancy.CardNo,
+			AreaID:     occupancy.AreaID,
+			AreaName:   areaNames[occupancy.AreaID],
+			ReaderName: occupancy.ReaderName,
+			EnteredAt:  occupancy.EnteredAt,
+			PhotoUrl:   s.PeoplePhotoService.ThumbnailUrl(occupancy.PeopleId),
+		})
+	}
+	return occupants
+}
+
+// ExportMuster 导出区域内人员名单 CSV（UTF-8 BOM，便于 Excel 直接打开）
+func (s *OccupancyServiceImpl) ExportMuster(areaId uint) []byte {
+	occupants := s.Muster(areaId)
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"区域", "人员编号", "姓名", "部门", "卡号", "进入读卡器", "进入时间"})
+	for _, occupant := range occupants {
+		writer.Write([]string{
+			occupant.AreaName,
+			occupant.PeopleCode,
+			occupant.PeopleName,
+			occupant.Department,
+			occupant.CardNo,
+			occupant.ReaderName,
+			time.Unix(int64(occupant.EnteredAt), 0).Format(time.DateTime),
+		})
+	}
+	writer.Flush()
+	utils.ErrorPanic(writer.Error())
+
+	return buf.Bytes()
+}
+
+// Remove 人工将人员标记为已离开（如点名时已确认安全）
+func (s *OccupancyServiceImpl) Remove(peopleId uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.OccupancyRepository.FindByPeopleId(peopleId)
+	utils.ErrorPanic(err)
+
+	s.OccupancyRepository.DeleteByPeopleId(peopleId)
+	s.checkCapacity(current.AreaID)
+}
+
+// Reset 清空区域内人员，areaId 为 0 时清空所有区域
+func (s *OccupancyServiceImpl) Reset(areaId uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.OccupancyRepository.DeleteByAreaId(areaId)
+	if areaId == 0 {
+		s.overCapacity = map[uint]bool{}
+	} else {
+		delete(s.overCapacity, areaId)
+	}
+}
+
+// invalidate 区域配置变更后，下次处理事件时重新加载
+func (s *OccupancyServiceImpl) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readers = nil
+	s.areas = nil
+}
+
+// load 加载区域和读卡器映射，调用方需持有锁
+func (s *OccupancyServiceImpl) load() {
+	if s.readers != nil {
+		return
+	}
+
+	areas := map[uint]*model.Area{}
+	for _, area := range s.AreaRepository.FindAll() {
+		areas[area.ID] = area
+	}
+	readers := map[readerKey][]*model.AreaReader{}
+	for _, reader := range s.AreaRepository.FindAllReaders() {
+		key := readerKey{IBAddr: reader.IBAddr, ReaderAddr: reader.ReaderAddr}
+		readers[key] = append(readers[key], reader)
+	}
+
+	s.areas = areas
+	s.readers = readers
+}
+
+// checkCapacity 检查区域人数，首次超员时发布报警，恢复后允许再次报警，调用方需持有锁
+func (s *OccupancyServiceImpl) checkCapacity(areaId uint) {
+	s.load()
+	area, ok := s.areas[areaId]
+	if !ok || area.Capacity == 0 {
+		return
+	}
+
+	count := s.OccupancyRepository.CountByArea(areaId)
+	if count <= int64(area.Capacity) {
+		delete(s.overCapacity, areaId)
+		return
+	}
+	if s.overCapacity[areaId] {
+		return
+	}
+	s.overCapacity[areaId] = true
+
+	if s.EventHub != nil {
+		s.EventHub.Publish(NewHubMessage(occupancyAlarmTopic, response.OccupancyAlarmResponse{
+			AreaID:   area.ID,
+			AreaName: area.Name,
+			Capacity: area.Capacity,
+			Count:    count,
+		}))
+	}
+}
+
+// AreaTransition 根据刷卡读卡器的区域映射计算人员新的所在区域（0 表示不在任何区域）
+// 先处理离开当前区域，再处理进入，同一读卡器可同时作为一个区域的出口和另一个区域的入口
+func AreaTransition(currentAreaId uint, mappings []*model.AreaReader) (uint, bool) {
+	areaId := currentAreaId
+	for _, mapping := range mappings {
+		if mapping.Direction == model.AreaDirectionExit && mapping.AreaID == areaId {
+			areaId = 0
+		}
+	}
+	for _, mapping := range mappings {
+		if mapping.Direction == model.AreaDirectionEnter {
+			areaId = mapping.AreaID
+		}
+	}
+	return areaId, areaId != currentAreaId
+}
+
+// toAreaReaders 将请求中的读卡器转换为数据模型
+func toAreaReaders(readers []request.AreaReaderRequest) []model.AreaReader {
+	areaReaders := make([]model.AreaReader, 0, len(readers))
+	for _, reader := range readers {
+		areaReaders = append(areaReaders, model.AreaReader{
+			IBAddr:     reader.IBAddr,
+			ReaderAddr: reader.ReaderAddr,
+			Direction:  reader.Direction,
+		})
+	}
+	return areaReaders
+}
+
+// toAreaResponse 将区域转换为响应
+func toAreaResponse(area *model.Area, readers []model.AreaReader) response.AreaResponse {
+	readerResponses := make([]response.AreaReaderResponse, 0, len(readers))
+	for _, reader := range readers {
+		readerResponses = append(readerResponses, response.AreaReaderResponse{
+			IBAddr:     reader.IBAddr,
+			ReaderAddr: reader.ReaderAddr,
+			Direction:  reader.Direction,
+		})
+	}
+	return response.AreaResponse{
+		ID:       area.ID,
+		Name:     area.Name,
+		Capacity: area.Capacity,
+		Readers:  readerResponses,
+	}
+}
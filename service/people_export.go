@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/utils"
+)
+
+// 人员导出的工作表名称
+const peopleExportSheet = "人员"
+
+// Export 导出所有人员、卡号、门禁组和人员有效期，格式为 csv（默认）或 xlsx，列布局与导入一致
+// 每张卡一行，同一人员的多张卡重复人员列，导出的文件重新导入后每张卡的门禁组不变
+func (s *PeopleImportServiceImpl) Export(format string) []byte {
+	records := s.exportRecords()
+
+	if strings.EqualFold(format, "xlsx") {
+		workbook := excelize.NewFile()
+		defer workbook.Close()
+
+		err := workbook.SetSheetName(workbook.GetSheetName(0), peopleExportSheet)
+		utils.ErrorPanic(err)
+		for i, record := range records {
+			row := make([]interface{}, 0, len(record))
+			for _, value := range record {
+				row = append(row, value)
+			}
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			utils.ErrorPanic(err)
+			err = workbook.SetSheetRow(peopleExportSheet, cell, &row)
+			utils.ErrorPanic(err)
+		}
+
+		buf, err := workbook.WriteToBuffer()
+		utils.ErrorPanic(err)
+		return buf.Bytes()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	err := writer.WriteAll(records)
+	utils.ErrorPanic(err)
+	return buf.Bytes()
+}
+
+// exportRecords 生成导出文件的所有行，包括标题行
+func (s *PeopleImportServiceImpl) exportRecords() [][]string {
+	departments := s.PeopleImportRepository.FindDepartmentIndex()
+
+	// 门禁组名称唯一时导出名称，否则导出编号，导入时两者都可识别
+	nameCount := map[string]int{}
+	accessGroups := s.GroupDirectoryRepository.FindAllAccessGroups()
+	for _, accessGroup := range accessGroups {
+		nameCount[accessGroup.Name]++
+	}
+	accessGroupNames := map[uint]string{}
+	for _, accessGroup := range accessGroups {
+		name := accessGroup.Name
+		if _, err := strconv.ParseUint(name, 10, 32); name == "" || err == nil || nameCount[name] > 1 {
+			name = strconv.FormatUint(uint64(accessGroup.GroupId), 10)
+		}
+		accessGroupNames[accessGroup.GroupId] = name
+	}
+
+	// 停用的凭证导出停用期间保存的门禁组，重新导入时写入保存的门禁组
+	accessByCredential := map[uint][]uint{}
+	for _, access := range s.PeopleDirectoryRepository.FindAllCredentialAccesses() {
+		accessByCredential[access.UniqueId] = append(accessByCredential[access.UniqueId], access.AccessGroupId)
+	}
+	for _, state := range s.PeopleDirectoryRepository.FindAllCredentialStates() {
+		if state.Disabled != 1 || state.SuspendedAccess == "" {
+			continue
+		}
+		var groupIds []uint
+		err := json.Unmarshal([]byte(state.SuspendedAccess), &groupIds)
+		utils.ErrorPanic(err)
+		accessByCredential[state.UniqueId] = append(accessByCredential[state.UniqueId], groupIds...)
+	}
+	credentials := map[uint][]*model.Credential{}
+	for _, credential := range s.PeopleDirectoryRepository.FindAllCredentials() {
+		credentials[credential.PeopleId] = append(credentials[credential.PeopleId], credential)
+	}
+
+	validities := map[uint]*model.PeopleValidity{}
+	for _, validity := range s.CredentialValidityRepository.FindAllPeopleValidity() {
+		validities[validity.PeopleId] = validity
+	}
+
+	header := make([]string, 0, len(importFieldOrder))
+	for _, field := range importFieldOrder {
+		header = append(header, importFieldAliases[field][0])
+	}
+	records := [][]string{header}
+
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		var validFrom, validUntil string
+		if validity, ok := validities[people.ID]; ok {
+			validFrom = FormatValidityTime(validity.ValidFrom, false)
+			validUntil = FormatValidityTime(validity.ValidUntil, true)
+		}
+		// 不属于任何部门的人员导出清空标记，重新导入时清空之后设置的部门
+		department := importClearValue
+		if people.DepartmentID != 0 {
+			department = departments.Path(people.DepartmentID)
+		}
+		record := func(cardNo string, groups string) []string {
+			return []string{
+				people.PeopleCode,
+				people.FirstName,
+				people.LastName,
+				department,
+				cardNo,
+				groups,
+				validFrom,
+				validUntil,
+			}
+		}
+
+		// 没有门禁组的卡导出清空标记，重新导入时清空该卡之后添加的门禁组
+		exported := false
+		for _, credential := range credentials[people.ID] {
+			if credential.CardNo == "" {
+				continue
+			}
+			var groups []string
+			seenGroups := map[uint]bool{}
+			for _, groupId := range accessByCredential[credential.UniqueId] {
+				if seenGroups[groupId] {
+					continue
+				}
+				seenGroups[groupId] = true
+				name, ok := accessGroupNames[groupId]
+				if !ok {
+					name = strconv.FormatUint(uint64(groupId), 10)
+				}
+				groups = append(groups, name)
+			}
+			if len(groups) == 0 {
+				groups = []string{importClearValue}
+			}
+			records = append(records, record(credential.CardNo, strings.Join(groups, ";")))
+			exported = true
+		}
+		if !exported {
+			records = append(records, record("", ""))
+		}
+	}
+	return records
+}
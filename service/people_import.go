@@ -0,0 +1,481 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 人员导入支持的字段
+const (
+	importFieldPeopleCode  = "people_code"  // 人员编号，用于匹配已有人员
+	importFieldFirstName   = "first_name"   // 姓
+	importFieldLastName    = "last_name"    // 名
+	importFieldDepartment  = "department"   // 部门完整路径，上下级部门用 / 分隔，唯一的部门名称也可识别
+	importFieldCardNo      = "card_no"      // 卡号，多个用分号分隔
+	importFieldAccessGroup = "access_group" // 门禁组名称或编号，多个用分号分隔
+	importFieldValidFrom   = "valid_from"   // 人员生效时间
+	importFieldValidUntil  = "valid_until"  // 人员失效时间，只有日期时当天仍有效
+)
+
+// importClearValue 部门或门禁组单元格为该值时清空人员的部门或凭证的门禁组，空单元格表示不修改
+const importClearValue = "-"
+
+// 导出文件的列顺序，列标题取各字段的第一个默认标题，导出的文件可直接重新导入
+var importFieldOrder = []string{
+	importFieldPeopleCode,
+	importFieldFirstName,
+	importFieldLastName,
+	importFieldDepartment,
+	importFieldCardNo,
+	importFieldAccessGroup,
+	importFieldValidFrom,
+	importFieldValidUntil,
+}
+
+// 未指定列映射时按列标题识别字段
+var importFieldAliases = map[string][]string{
+	importFieldPeopleCode:  {"人员编号", "工号", "编号"},
+	importFieldFirstName:   {"姓"},
+	importFieldLastName:    {"名"},
+	importFieldDepartment:  {"部门"},
+	importFieldCardNo:      {"卡号"},
+	importFieldAccessGroup: {"门禁组", "权限组"},
+	importFieldValidFrom:   {"生效时间", "生效日期"},
+	importFieldValidUntil:  {"失效时间", "失效日期", "有效期至"},
+}
+
+// PeopleImportService 人员文件导入导出的业务接口
+type PeopleImportService interface {
+	Import(req request.ImportPeopleFileRequest, filename string, file io.Reader, userId uint) response.PeopleImportResponse
+	FindAllBatches(pg *utils.Pagination) []response.ImportBatchResponse
+	Rollback(batchId uint, userId uint)
+	Export(format string) []byte
+}
+
+// PeopleImportServiceImpl 人员文件导入的业务实现
+// 按人员编号新增或更新人员，自动创建部门，同一文件可包含卡号和门禁组，所有行在一个事务中写入
+// 每次导入记录为一个批次，可整体回滚
+type PeopleImportServiceImpl struct {
+	PeopleImportRepository       repository.PeopleImportRepository
+	ImportBatchRepository        repository.ImportBatchRepository
+	PeopleDirectoryRepository    repository.PeopleDirectoryRepository
+	GroupDirectoryRepository     repository.GroupDirectoryRepository
+	CredentialValidityRepository repository.CredentialValidityRepository
+	CredentialUsageRepository    repository.CredentialUsageRepository
+	PeoplePhotoService           PeoplePhotoService
+	DepartmentTreeService        DepartmentTreeService
+}
+
+// NewPeopleImportServiceImpl 创建并返回一个新的 PeopleImportServiceImpl 实例
+func NewPeopleImportServiceImpl(
+	peopleImportRepository repository.PeopleImportRepository,
+	importBatchRepository repository.ImportBatchRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	credentialValidityRepository repository.CredentialValidityRepository,
+	credentialUsageRepository repository.CredentialUsageRepository,
+	peoplePhotoService PeoplePhotoService,
+	departmentTreeService DepartmentTreeService,
+) PeopleImportService {
+	return &PeopleImportServiceImpl{
+		PeopleImportRepository:       peopleImportRepository,
+		ImportBatchRepository:        importBatchRepository,
+		PeopleDirectoryRepository:    peopleDirectoryRepository,
+		GroupDirectoryRepository:     groupDirectoryRepository,
+		CredentialValidityRepository: credentialValidityRepository,
+		CredentialUsageRepository:    credentialUsageRepository,
+		PeoplePhotoService:           peoplePhotoService,
+		DepartmentTreeService:        departmentTreeService,
+	}
+}
+
+// Import 导入人员文件，存在行级错误时不写入任何数据；试运行只校验并返回预计结果
+func (s *PeopleImportServiceImpl) Import(req request.ImportPeopleFileRequest, filename string, file io.Reader, userId uint) response.PeopleImportResponse {
+	records, err := ParsePeopleImportFile(filename, file, req.Sheet)
+	utils.ErrorPanic(err)
+	if len(records) == 0 {
+		panic("empty import file")
+	}
+
+	mapping := map[string]string{}
+	if req.Mapping != "" {
+		err = json.Unmarshal([]byte(req.Mapping), &mapping)
+		utils.ErrorPanic(err)
+	}
+	columns, err := MapImportColumns(records[0], mapping)
+	utils.ErrorPanic(err)
+
+	rows, result := s.validate(records, columns)
+	result.DryRun = req.DryRun
+	if req.DryRun || len(result.Errors) > 0 {
+		return result
+	}
+
+	batch, changes, err := s.PeopleImportRepository.Apply(rows, model.ImportBatch{
+		FileName: filepath.Base(filename),
+		UserId:   userId,
+	})
+	utils.ErrorPanic(err)
+	// 导入可能调整人员的部门或替换门禁组，立即同步继承的门禁组
+	s.DepartmentTreeService.Reconcile()
+
+	result.BatchId = batch.ID
+	result.Created = len(changes.CreatedPeopleIds)
+	result.Updated = len(changes.UpdatedPeopleIds)
+	result.DepartmentsCreated = len(changes.CreatedDepartmentIds)
+	result.CredentialsCreated = len(changes.CreatedCredentialIds)
+	return result
+}
+
+// FindAllBatches 分页查询导入批次
+func (s *PeopleImportServiceImpl) FindAllBatches(pg *utils.Pagination) []response.ImportBatchResponse {
+	batches := s.ImportBatchRepository.FindAll(pg)
+
+	batchResponses := make([]response.ImportBatchResponse, 0, len(batches))
+	for _, batch := range batches {
+		var changes model.ImportChanges
+		err := json.Unmarshal([]byte(batch.Changes), &changes)
+		utils.ErrorPanic(err)
+
+		batchResponses = append(batchResponses, response.ImportBatchResponse{
+			ID:                 batch.ID,
+			FileName:           batch.FileName,
+			UserId:             batch.UserId,
+			Created:            len(changes.CreatedPeopleIds),
+			Updated:            len(changes.UpdatedPeopleIds),
+			DepartmentsCreated: len(changes.CreatedDepartmentIds),
+			CredentialsCreated: len(changes.CreatedCredentialIds),
+			RolledBack:         batch.RolledBack == 1,
+			RolledBackBy:       batch.RolledBackBy,
+			RolledBackAt:       batch.RolledBackAt,
+			CreatedAt:          batch.CreatedAt,
+		})
+	}
+	return batchResponses
+}
+
+// Rollback 回滚导入批次，之后删除已删除人员的照片和已删除凭证的使用情况记录
+func (s *PeopleImportServiceImpl) Rollback(batchId uint, userId uint) {
+	err := s.ImportBatchRepository.Rollback(batchId, userId)
+	utils.ErrorPanic(err)
+	s.PeoplePhotoService.Purge()
+	trackCredentialUsage(s.CredentialUsageRepository, s.PeopleDirectoryRepository)
+	s.DepartmentTreeService.Reconcile()
+}
+
+// validate 校验所有数据行并生成待写入的行，同时统计预计结果
+func (s *PeopleImportServiceImpl) validate(records [][]string, columns map[string]int) ([]repository.PeopleImportRow, response.PeopleImportResponse) {
+	header := records[0]
+	result := response.PeopleImportResponse{Errors: []response.PeopleImportErrorResponse{}}
+	addError := func(row int, field string, message string) {
+		result.Errors = append(result.Errors, response.PeopleImportErrorResponse{
+			Row:     row,
+			Column:  header[columns[field]],
+			Message: message,
+		})
+	}
+	cell := func(record []string, field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	// 批量查询已有人员和凭证
+	var codes, cardNos []string
+	for _, record := range records[1:] {
+		if code := cell(record, importFieldPeopleCode); code != "" {
+			codes = append(codes, code)
+		}
+		cardNos = append(cardNos, splitImportList(cell(record, importFieldCardNo))...)
+	}
+	peoples := map[string]*model.People{}
+	for _, people := range s.PeopleImportRepository.FindPeopleByCodes(codes) {
+		peoples[people.PeopleCode] = people
+	}
+	credentials := map[string]*model.Credential{}
+	for _, credential := range s.PeopleImportRepository.FindCredentialsByCardNos(cardNos) {
+		credentials[credential.CardNo] = credential
+	}
+	lostCards := map[string]bool{}
+	for _, cardNo := range s.PeopleImportRepository.FindLostCardNos(cardNos) {
+		lostCards[cardNo] = true
+	}
+	departments := s.PeopleImportRepository.FindDepartmentIndex()
+	createdDepartments := map[string]bool{}
+	accessGroups := map[string]uint{}
+	if _, ok := columns[importFieldAccessGroup]; ok {
+		for _, accessGroup := range s.GroupDirectoryRepository.FindAllAccessGroups() {
+			accessGroups[accessGroup.Name] = accessGroup.GroupId
+			accessGroups[strconv.FormatUint(uint64(accessGroup.GroupId), 10)] = accessGroup.GroupId
+		}
+	}
+
+	var rows []repository.PeopleImportRow
+	seenCodes := map[string]int{}
+	firstRecords := map[string][]string{}
+	seenCards := map[string]int{}
+	for i, record := range records[1:] {
+		rowNumber := i + 2
+		if isBlankRecord(record) {
+			continue
+		}
+		result.Total++
+		errorCount := len(result.Errors)
+
+		row := repository.PeopleImportRow{Row: rowNumber}
+		code := cell(record, importFieldPeopleCode)
+		if code == "" {
+			addError(rowNumber, importFieldPeopleCode, "人员编号不能为空")
+		} else if first, ok := seenCodes[code]; ok {
+			// 同一人员的多张卡可以分行填写，之后的行只写入卡号和门禁组，其他列为空或与第一行相同
+			row.Continued = true
+			if cell(record, importFieldCardNo) == "" {
+				addError(rowNumber, importFieldPeopleCode, "人员编号与第 "+strconv.Itoa(first)+" 行重复，同一人员的后续行必须填写卡号")
+			}
+			for _, field := range []string{importFieldFirstName, importFieldLastName, importFieldDepartment, importFieldValidFrom, importFieldValidUntil} {
+				if value := cell(record, field); value != "" && value != cell(firstRecords[code], field) {
+					addError(rowNumber, field, "与第 "+strconv.Itoa(first)+" 行同一人员的数据不一致")
+				}
+			}
+		} else {
+			seenCodes[code] = rowNumber
+			firstRecords[code] = record
+		}
+
+		// 已有人员只更新文件中非空的字段
+		if existing, ok := peoples[code]; ok {
+			row.People = *existing
+		} else {
+			row.People = model.People{PeopleCode: code}
+		}
+		if firstName := cell(record, importFieldFirstName); firstName != "" {
+			row.People.FirstName = firstName
+		}
+		if lastName := cell(record, importFieldLastName); lastName != "" {
+			row.People.LastName = lastName
+		}
+		if !row.Continued && row.People.ID == 0 && row.People.FirstName == "" && row.People.LastName == "" {
+			if _, ok := columns[importFieldFirstName]; ok {
+				addError(rowNumber, importFieldFirstName, "新增人员的姓名不能为空")
+			} else {
+				addError(rowNumber, importFieldPeopleCode, "新增人员的姓名不能为空")
+			}
+		}
+
+		if department := cell(record, importFieldDepartment); department == importClearValue {
+			row.HasDepartment = true
+		} else if department != "" {
+			_, missing, err := departments.Find(department)
+			if errors.Is(err, repository.ErrDepartmentAmbiguous) {
+				addError(rowNumber, importFieldDepartment, "部门 "+department+" 不唯一，请填写完整路径，如 上级部门"+repository.DepartmentPathSeparator+department)
+			} else if err != nil {
+				addError(rowNumber, importFieldDepartment, "部门路径 "+department+" 格式不正确")
+			}
+			for _, name := range missing {
+				if len([]rune(name)) > 50 {
+					addError(rowNumber, importFieldDepartment, "部门名称不能超过 50 个字符")
+				}
+			}
+			row.HasDepartment = true
+			row.DepartmentName = department
+		}
+
+		for _, cardNo := range splitImportList(cell(record, importFieldCardNo)) {
+			if first, ok := seenCards[cardNo]; ok {
+				addError(rowNumber, importFieldCardNo, "卡号 "+cardNo+" 与第 "+strconv.Itoa(first)+" 行重复")
+				continue
+			}
+			seenCards[cardNo] = rowNumber
+			if lostCards[cardNo] {
+				addError(rowNumber, importFieldCardNo, "卡号 "+cardNo+" 已挂失")
+				continue
+			}
+			if credential, ok := credentials[cardNo]; ok && (row.People.ID == 0 || credential.PeopleId != row.People.ID) {
+				addError(rowNumber, importFieldCardNo, "卡号 "+cardNo+" 已分配给其他人员")
+				continue
+			}
+			row.CardNos = append(row.CardNos, cardNo)
+		}
+
+		if cell(record, importFieldAccessGroup) == importClearValue {
+			row.HasAccessGroups = true
+		} else if names := splitImportList(cell(record, importFieldAccessGroup)); len(names) > 0 {
+			row.HasAccessGroups = true
+			for _, name := range names {
+				groupId, ok := accessGroups[name]
+				if !ok {
+					addError(rowNumber, importFieldAccessGroup, "门禁组 "+name+" 不存在")
+					continue
+				}
+				row.AccessGroupIds = append(row.AccessGroupIds, groupId)
+			}
+		}
+
+		if value := cell(record, importFieldValidFrom); value != "" {
+			validFrom, err := ParseValidityTime(value, false)
+			if err != nil {
+				addError(rowNumber, importFieldValidFrom, "生效时间格式应为 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS")
+			} else {
+				row.ValidFrom = &validFrom
+			}
+		}
+		if value := cell(record, importFieldValidUntil); value != "" {
+			validUntil, err := ParseValidityTime(value, true)
+			if err != nil {
+				addError(rowNumber, importFieldValidUntil, "失效时间格式应为 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS")
+			} else {
+				row.ValidUntil = &validUntil
+			}
+		}
+		if row.ValidFrom != nil && row.ValidUntil != nil && *row.ValidUntil <= *row.ValidFrom {
+			addError(rowNumber, importFieldValidUntil, "失效时间必须晚于生效时间")
+		}
+
+		if len(result.Errors) > errorCount {
+			continue
+		}
+		if row.Continued {
+			row.HasDepartment = false
+			row.DepartmentName = ""
+			row.ValidFrom = nil
+			row.ValidUntil = nil
+		}
+
+		// 预计结果
+		if !row.Continued && row.People.ID == 0 {
+			result.Created++
+		} else if !row.Continued {
+			result.Updated++
+		}
+		if row.DepartmentName != "" {
+			// 按将要创建的每一级部门的完整路径计数，不同行的相同路径只创建一次
+			departmentId, missing, _ := departments.Find(row.DepartmentName)
+			path := departments.Path(departmentId)
+			for _, name := range missing {
+				if path != "" {
+					path += repository.DepartmentPathSeparator
+				}
+				path += name
+				if !createdDepartments[path] {
+					createdDepartments[path] = true
+					result.DepartmentsCreated++
+				}
+			}
+		}
+		for _, cardNo := range row.CardNos {
+			if _, ok := credentials[cardNo]; !ok {
+				result.CredentialsCreated++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, result
+}
+
+// ParsePeopleImportFile 根据文件扩展名解析 CSV 或 XLSX 文件，返回包括标题行在内的所有行
+func ParsePeopleImportFile(filename string, file io.Reader, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))))
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer workbook.Close()
+		if sheet == "" {
+			sheet = workbook.GetSheetName(0)
+		}
+		return workbook.GetRows(sheet, excelize.Options{RawCellValue: true})
+	default:
+		return nil, errors.New("unsupported import file type, expected .csv or .xlsx")
+	}
+}
+
+// MapImportColumns 根据列映射（列标题 -> 字段）和默认列标题确定各字段所在列，必须包含人员编号列
+// 已映射的字段不再按默认列标题识别，映射为空字符串的列被忽略
+func MapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	mapped := map[string]bool{}
+	for _, field := range mapping {
+		if _, ok := importFieldAliases[field]; !ok && field != "" {
+			return nil, errors.New("unknown import field: " + field)
+		}
+		mapped[field] = true
+	}
+
+	aliases := map[string]string{}
+	for field, names := range importFieldAliases {
+		if mapped[field] {
+			continue
+		}
+		aliases[field] = field
+		for _, name := range names {
+			aliases[name] = field
+		}
+	}
+	for title, field := range mapping {
+		aliases[strings.TrimSpace(title)] = field
+	}
+
+	columns := map[string]int{}
+	for index, title := range header {
+		field, ok := aliases[strings.TrimSpace(title)]
+		if !ok || field == "" {
+			continue
+		}
+		if _, exists := columns[field]; exists {
+			return nil, errors.New("duplicate column for import field: " + field)
+		}
+		columns[field] = index
+	}
+	if _, ok := columns[importFieldPeopleCode]; !ok {
+		return nil, errors.New("missing people code column")
+	}
+	return columns, nil
+}
+
+// splitImportList 拆分单元格中用分号、逗号或竖线分隔的多个值
+func splitImportList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == '；' || r == ',' || r == '，' || r == '|'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isBlankRecord 判断是否为空行
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
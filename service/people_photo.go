@@ -0,0 +1,326 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 人员照片存储，目录通过 /uploads 静态路由访问
+const (
+	peoplePhotoDir       = "./web/uploads/people" // 照片目录
+	peoplePhotoUrlPrefix = "/uploads/people/"     // 照片地址前缀
+	peoplePhotoMaxSize   = 480                    // 照片最长边（像素）
+	peopleThumbMaxSize   = 96                     // 缩略图最长边（像素）
+	peoplePhotoQuality   = 80                     // JPEG 质量
+	peoplePhotoMaxFile   = 5 << 20                // 单张上传照片的最大字节数
+	peoplePhotoMaxPixels = 40000000               // 上传照片的最大像素数，防止解码占用过多内存
+	peoplePhotoQuota     = 64 << 20               // 所有照片的存储配额（字节），适应设备的小容量闪存
+)
+
+// PeoplePhotoService 人员照片的业务接口
+type PeoplePhotoService interface {
+	Upload(peopleId uint, file io.Reader) response.PeoplePhotoResponse
+	UploadZip(file io.ReaderAt, size int64) response.PeoplePhotoUploadResponse
+	Delete(peopleId uint)
+	FindByPeopleIds(peopleIds []uint) []response.PeoplePhotoResponse
+	Usage() response.PeoplePhotoUsageResponse
+	ThumbnailUrl(peopleId uint) string
+	Purge()
+}
+
+// PeoplePhotoServiceImpl 人员照片的业务实现
+// 上传的照片统一缩放并转换为 JPEG，同时生成缩略图，总大小受存储配额限制
+type PeoplePhotoServiceImpl struct {
+	PeoplePhotoRepository     repository.PeoplePhotoRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+
+	mu      sync.Mutex
+	updated map[uint]uint // 有照片的人员及照片更新时间，首次使用时加载
+}
+
+// NewPeoplePhotoServiceImpl 创建并返回一个新的 PeoplePhotoServiceImpl 实例
+func NewPeoplePhotoServiceImpl(
+	peoplePhotoRepository repository.PeoplePhotoRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+) PeoplePhotoService {
+	return &PeoplePhotoServiceImpl{
+		PeoplePhotoRepository:     peoplePhotoRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+	}
+}
+
+// Upload 上传或替换人员照片
+func (s *PeoplePhotoServiceImpl) Upload(peopleId uint, file io.Reader) response.PeoplePhotoResponse {
+	if len(s.PeopleDirectoryRepository.FindPeopleByIds([]uint{peopleId})) == 0 {
+		panic("people " + strconv.FormatUint(uint64(peopleId), 10) + " not found")
+	}
+
+	photo, err := s.save(peopleId, file)
+	utils.ErrorPanic(err)
+	return toPeoplePhotoResponse(photo)
+}
+
+// UploadZip 批量上传 ZIP 压缩包中的照片，文件名（不含扩展名）为人员编号，每个文件单独返回结果
+func (s *PeoplePhotoServiceImpl) UploadZip(file io.ReaderAt, size int64) response.PeoplePhotoUploadResponse {
+	archive, err := zip.NewReader(file, size)
+	utils.ErrorPanic(err)
+
+	peoples := map[string]uint{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peoples[people.PeopleCode] = people.ID
+	}
+
+	result := response.PeoplePhotoUploadResponse{Items: []response.PeoplePhotoUploadItemResponse{}}
+	for _, entry := range archive.File {
+		name := path.Base(entry.Name)
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		item := response.PeoplePhotoUploadItemResponse{
+			FileName:   entry.Name,
+			PeopleCode: strings.TrimSuffix(name, path.Ext(name)),
+		}
+		item.PeopleId = peoples[item.PeopleCode]
+		err := func() error {
+			if item.PeopleId == 0 {
+				return errors.New("people code not found")
+			}
+			reader, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			_, err = s.save(item.PeopleId, reader)
+			return err
+		}()
+		if err != nil {
+			item.Message = err.Error()
+			result.Failed++
+		} else {
+			item.Success = true
+			result.Succeeded++
+		}
+		result.Total++
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+// Delete 删除人员照片
+func (s *PeoplePhotoServiceImpl) Delete(peopleId uint) {
+	_, err := s.PeoplePhotoRepository.FindByPeopleId(peopleId)
+	utils.ErrorPanic(err)
+
+	s.remove(peopleId)
+}
+
+// FindByPeopleIds 查询人员照片，没有照片的人员不返回，peopleIds 为空时返回所有照片
+func (s *PeoplePhotoServiceImpl) FindByPeopleIds(peopleIds []uint) []response.PeoplePhotoResponse {
+	var photos []*model.PeoplePhoto
+	if len(peopleIds) == 0 {
+		photos = s.PeoplePhotoRepository.FindAll()
+	} else {
+		photos = s.PeoplePhotoRepository.FindByPeopleIds(peopleIds)
+	}
+
+	photoResponses := make([]response.PeoplePhotoResponse, 0, len(photos))
+	for _, photo := range photos {
+		photoResponses = append(photoResponses, toPeoplePhotoResponse(photo))
+	}
+	return photoResponses
+}
+
+// Usage 返回照片存储空间使用情况
+func (s *PeoplePhotoServiceImpl) Usage() response.PeoplePhotoUsageResponse {
+	return response.PeoplePhotoUsageResponse{
+		Count: len(s.PeoplePhotoRepository.FindAll()),
+		Used:  s.PeoplePhotoRepository.TotalSize(),
+		Quota: peoplePhotoQuota,
+	}
+}
+
+// ThumbnailUrl 返回人员照片缩略图地址，没有照片时返回空字符串，用于事件推送
+func (s *PeoplePhotoServiceImpl) ThumbnailUrl(peopleId uint) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.updated == nil {
+		s.updated = map[uint]uint{}
+		for _, photo := range s.PeoplePhotoRepository.FindAll() {
+			s.updated[photo.PeopleId] = photo.UpdatedAt
+		}
+	}
+	updatedAt, ok := s.updated[peopleId]
+	if !ok {
+		return ""
+	}
+	return peoplePhotoUrl(peopleId, "_thumb", updatedAt)
+}
+
+// Purge 后台任务：删除人员已被删除的照片
+func (s *PeoplePhotoServiceImpl) Purge() {
+	for _, peopleId := range s.PeoplePhotoRepository.FindOrphanIds() {
+		s.remove(peopleId)
+	}
+}
+
+// save 缩放照片并生成缩略图，检查存储配额后写入文件并保存记录
+func (s *PeoplePhotoServiceImpl) save(peopleId uint, file io.Reader) (*model.PeoplePhoto, error) {
+	data, err := io.ReadAll(io.LimitReader(file, peoplePhotoMaxFile+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > peoplePhotoMaxFile {
+		return nil, fmt.Errorf("photo exceeds %d bytes", peoplePhotoMaxFile)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("unsupported image, expected JPEG, PNG or GIF")
+	}
+	if config.Width*config.Height > peoplePhotoMaxPixels {
+		return nil, errors.New("photo resolution is too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	photo := ResizePhoto(img, peoplePhotoMaxSize)
+	var photoData, thumbData bytes.Buffer
+	if err := jpeg.Encode(&photoData, photo, &jpeg.Options{Quality: peoplePhotoQuality}); err != nil {
+		return nil, err
+	}
+	if err := jpeg.Encode(&thumbData, ResizePhoto(photo, peopleThumbMaxSize), &jpeg.Options{Quality: peoplePhotoQuality}); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(photoData.Len() + thumbData.Len())
+	used := s.PeoplePhotoRepository.TotalSize()
+	if existing, err := s.PeoplePhotoRepository.FindByPeopleId(peopleId); err == nil {
+		used -= existing.Size
+	}
+	if used+size > peoplePhotoQuota {
+		return nil, fmt.Errorf("photo storage quota of %d bytes exceeded", peoplePhotoQuota)
+	}
+
+	if err := os.MkdirAll(peoplePhotoDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(peoplePhotoPath(peopleId, ""), photoData.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(peoplePhotoPath(peopleId, "_thumb"), thumbData.Bytes()); err != nil {
+		return nil, err
+	}
+
+	bounds := photo.Bounds()
+	record := model.PeoplePhoto{
+		PeopleId:  peopleId,
+		Width:     uint(bounds.Dx()),
+		Height:    uint(bounds.Dy()),
+		Size:      size,
+		UpdatedAt: uint(time.Now().Unix()),
+	}
+	if err := s.PeoplePhotoRepository.Save(record); err != nil {
+		return nil, err
+	}
+	if s.updated != nil {
+		s.updated[peopleId] = record.UpdatedAt
+	}
+	return &record, nil
+}
+
+// remove 删除照片文件和记录
+func (s *PeoplePhotoServiceImpl) remove(peopleId uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, suffix := range []string{"", "_thumb"} {
+		if err := os.Remove(peoplePhotoPath(peopleId, suffix)); err != nil && !os.IsNotExist(err) {
+			log.Printf("people photo: remove %d%s: %v", peopleId, suffix, err)
+		}
+	}
+	err := s.PeoplePhotoRepository.Delete(peopleId)
+	utils.ErrorPanic(err)
+	delete(s.updated, peopleId)
+}
+
+// ResizePhoto 按比例缩放图片使最长边不超过 maxSize，较小的图片保持原尺寸
+func ResizePhoto(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+		return dst
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.BiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// peoplePhotoPath 返回照片文件路径，suffix 为 "_thumb" 时为缩略图
+func peoplePhotoPath(peopleId uint, suffix string) string {
+	return filepath.Join(peoplePhotoDir, strconv.FormatUint(uint64(peopleId), 10)+suffix+".jpg")
+}
+
+// peoplePhotoUrl 返回照片地址，附带更新时间避免浏览器使用旧的缓存
+func peoplePhotoUrl(peopleId uint, suffix string, updatedAt uint) string {
+	return peoplePhotoUrlPrefix + strconv.FormatUint(uint64(peopleId), 10) + suffix + ".jpg?v=" + strconv.FormatUint(uint64(updatedAt), 10)
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免写入中断时留下不完整的文件
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// toPeoplePhotoResponse 将人员照片转换为响应结构
+func toPeoplePhotoResponse(photo *model.PeoplePhoto) response.PeoplePhotoResponse {
+	return response.PeoplePhotoResponse{
+		PeopleId:     photo.PeopleId,
+		PhotoUrl:     peoplePhotoUrl(photo.PeopleId, "", photo.UpdatedAt),
+		ThumbnailUrl: peoplePhotoUrl(photo.PeopleId, "_thumb", photo.UpdatedAt),
+		Width:        photo.Width,
+		Height:       photo.Height,
+		UpdatedAt:    photo.UpdatedAt,
+	}
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 全文检索的候选对象数，排序后按类型截取
+const searchCandidateLimit = 200
+
+// SearchService 人员、凭证和部门全文检索的业务接口
+type SearchService interface {
+	Search(req request.SearchRequest) response.SearchResponse
+	Sync()
+}
+
+// SearchServiceImpl 全文检索的业务实现
+// 索引使用 SQLite FTS4，姓名和编号前缀匹配；前缀匹配的结果不足时，再按包含关系模糊匹配（如卡号后几位），
+// 仍然没有结果时按索引词表纠正拼写错误的关键字
+type SearchServiceImpl struct {
+	SearchRepository          repository.SearchRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	PeoplePhotoService        PeoplePhotoService
+	Validate                  *validator.Validate
+
+	mu sync.Mutex // 保证同一时间只有一个增量更新索引
+}
+
+// NewSearchServiceImpl 创建并返回一个新的 SearchServiceImpl 实例
+func NewSearchServiceImpl(
+	searchRepository repository.SearchRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	peoplePhotoService PeoplePhotoService,
+	validate *validator.Validate,
+) SearchService {
+	return &SearchServiceImpl{
+		SearchRepository:          searchRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		PeoplePhotoService:        peoplePhotoService,
+		Validate:                  validate,
+	}
+}
+
+// searchCandidate 检索候选对象及其得分
+type searchCandidate struct {
+	ref   uint
+	name  string
+	score int
+}
+
+// Search 检索人员、凭证和部门，结果按对象类型分组并按得分排序
+func (s *SearchServiceImpl) Search(req request.SearchRequest) response.SearchResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	terms := SearchTerms(req.Q)
+	if len(terms) == 0 {
+		utils.ErrorPanic(errors.New("q must contain letters or digits"))
+	}
+	kinds, err := searchKinds(req.Types)
+	utils.ErrorPanic(err)
+	limit := req.Limit
+	if limit == 0 {
+		limit = 10
+	}
+
+	// 查询前更新索引，刚修改的数据立即可检索
+	s.Sync()
+
+	fuzzy := map[repository.SearchHit]bool{}
+	var hits []repository.SearchHit
+	for _, hit := range s.SearchRepository.Match(SearchMatchExpression(terms), searchCandidateLimit) {
+		if kinds[hit.Kind] {
+			hits = append(hits, hit)
+		}
+	}
+	if len(hits) < limit*len(kinds) {
+		matched := map[repository.SearchHit]bool{}
+		for _, hit := range hits {
+			matched[hit] = true
+		}
+		for _, hit := range s.SearchRepository.Like(repository.SearchIndexText(longestTerm(terms)), searchCandidateLimit) {
+			if kinds[hit.Kind] && !matched[hit] {
+				hits = append(hits, hit)
+				fuzzy[hit] = true
+			}
+		}
+	}
+
+	// 没有任何匹配时纠正拼写错误的关键字，按纠正后的关键字重新检索
+	suggestion := ""
+	if len(hits) == 0 {
+		if corrected, ok := SearchCorrectTerms(terms, s.SearchRepository.Terms()); ok {
+			terms = corrected
+			suggestion = strings.Join(corrected, " ")
+			for _, hit := range s.SearchRepository.Match(SearchMatchExpression(terms), searchCandidateLimit) {
+				if kinds[hit.Kind] {
+					hits = append(hits, hit)
+				}
+			}
+		}
+	}
+
+	refs := map[string][]uint{}
+	for _, hit := range hits {
+		refs[hit.Kind] = append(refs[hit.Kind], hit.Ref)
+	}
+	// score 计算候选对象的得分；全文检索命中的对象至少 1 分（如忽略重音符号匹配），模糊匹配的对象需匹配全部关键字
+	score := func(kind string, ref uint, code string, names ...string) (int, bool) {
+		value, ok := SearchScore(terms, code, names...)
+		if !ok && fuzzy[repository.SearchHit{Kind: kind, Ref: ref}] {
+			return 0, false
+		}
+		if value == 0 {
+			value = 1
+		}
+		return value, true
+	}
+
+	departments := map[uint]string{}
+	if len(refs[model.SearchKindPeople]) > 0 || len(refs[model.SearchKindDepartment]) > 0 {
+		for _, department := range s.PeopleDirectoryRepository.FindAllDepartments() {
+			departments[department.ID] = department.Name
+		}
+	}
+
+	searchResponse := response.SearchResponse{
+		Query:       req.Q,
+		Suggestion:  suggestion,
+		People:      []response.SearchPeopleResponse{},
+		Credentials: []response.SearchCredentialResponse{},
+		Departments: []response.SearchDepartmentResponse{},
+	}
+
+	// 人员：按姓名和人员编号评分
+	peoples := map[uint]*model.People{}
+	var candidates []searchCandidate
+	for _, people := range s.PeopleDirectoryRepository.FindPeopleByIds(refs[model.SearchKindPeople]) {
+		value, ok := score(model.SearchKindPeople, people.ID, people.PeopleCode,
+			people.FirstName, people.LastName, people.FirstName+people.LastName, people.LastName+people.FirstName)
+		if ok {
+			peoples[people.ID] = people
+			candidates = append(candidates, searchCandidate{ref: people.ID, name: peopleFullName(people), score: value})
+		}
+	}
+	for _, candidate := range rankSearchCandidates(candidates, limit) {
+		people := peoples[candidate.ref]
+		searchResponse.People = append(searchResponse.People, response.SearchPeopleResponse{
+			PeopleId:   people.ID,
+			PeopleCode: people.PeopleCode,
+			FirstName:  people.FirstName,
+			LastName:   people.LastName,
+			Department: departments[people.DepartmentID],
+			PhotoUrl:   s.PeoplePhotoService.ThumbnailUrl(people.ID),
+			Score:      candidate.score,
+		})
+	}
+
+	// 凭证：按卡号评分
+	credentials := map[uint]*model.Credential{}
+	candidates = nil
+	for _, credential := range s.PeopleDirectoryRepository.FindCredentialsByIds(refs[model.SearchKindCredential]) {
+		if value, ok := score(model.SearchKindCredential, credential.UniqueId, credential.CardNo); ok {
+			credentials[credential.UniqueId] = credential
+			candidates = append(candidates, searchCandidate{ref: credential.UniqueId, name: credential.CardNo, score: value})
+		}
+	}
+	candidates = rankSearchCandidates(candidates, limit)
+	var ownerIds []uint
+	for _, candidate := range candidates {
+		ownerIds = append(ownerIds, credentials[candidate.ref].PeopleId)
+	}
+	owners := map[uint]*model.People{}
+	for _, people := range s.PeopleDirectoryRepository.FindPeopleByIds(ownerIds) {
+		owners[people.ID] = people
+	}
+	for _, candidate := range candidates {
+		credential := credentials[candidate.ref]
+		credentialResponse := response.SearchCredentialResponse{
+			UniqueId: credential.UniqueId,
+			CardNo:   credential.CardNo,
+			PeopleId: credential.PeopleId,
+			Score:    candidate.score,
+		}
+		if people, ok := owners[credential.PeopleId]; ok {
+			credentialResponse.PeopleCode = people.PeopleCode
+			credentialResponse.PeopleName = peopleFullName(people)
+		}
+		searchResponse.Credentials = append(searchResponse.Credentials, credentialResponse)
+	}
+
+	// 部门：按部门名称评分
+	candidates = nil
+	for _, ref := range refs[model.SearchKindDepartment] {
+		name, exists := departments[ref]
+		if !exists {
+			continue
+		}
+		if value, ok := score(model.SearchKindDepartment, ref, "", name); ok {
+			candidates = append(candidates, searchCandidate{ref: ref, name: name, score: value})
+		}
+	}
+	candidates = rankSearchCandidates(candidates, limit)
+	if len(candidates) > 0 {
+		peopleCounts := map[uint]int{}
+		for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+			peopleCounts[people.DepartmentID]++
+		}
+		for _, candidate := range candidates {
+			searchResponse.Departments = append(searchResponse.Departments, response.SearchDepartmentResponse{
+				DepartmentId: candidate.ref,
+				Name:         candidate.name,
+				PeopleCount:  peopleCounts[candidate.ref],
+				Score:        candidate.score,
+			})
+		}
+	}
+	return searchResponse
+}
+
+// Sync 后台任务：增量更新全文检索索引
+func (s *SearchServiceImpl) Sync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.SearchRepository.Sync(); err != nil {
+		log.Printf("search: sync index: %v", err)
+	}
+}
+
+// searchKinds 解析检索的对象类型，为空表示全部
+func searchKinds(types string) (map[string]bool, error) {
+	all := []string{model.SearchKindPeople, model.SearchKindCredential, model.SearchKindDepartment}
+	kinds := map[string]bool{}
+	for _, kind := range splitList(types) {
+		valid := false
+		for _, candidate := range all {
+			valid = valid || kind == candidate
+		}
+		if !valid {
+			return nil, errors.New("unknown search type " + kind)
+		}
+		kinds[kind] = true
+	}
+	if len(kinds) == 0 {
+		for _, kind := range all {
+			kinds[kind] = true
+		}
+	}
+	return kinds, nil
+}
+
+// rankSearchCandidates 按得分从高到低排序，得分相同时名称较短（更接近关键字）的在前，返回前 limit 个
+func rankSearchCandidates(candidates []searchCandidate, limit int) []searchCandidate {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if len(candidates[i].name) != len(candidates[j].name) {
+			return len(candidates[i].name) < len(candidates[j].name)
+		}
+		return candidates[i].ref < candidates[j].ref
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// longestTerm 返回最长的关键字，用于模糊匹配
+func longestTerm(terms []string) string {
+	longest := ""
+	for _, term := range terms {
+		if len([]rune(term)) > len([]rune(longest)) {
+			longest = term
+		}
+	}
+	return longest
+}
+
+// searchWords 按字母和数字以外的字符拆分为小写的词
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchTerms 将检索关键字拆分为小写的词，忽略标点和空白
+func SearchTerms(q string) []string {
+	return searchWords(q)
+}
+
+// SearchMatchExpression 生成 FTS4 查询表达式：每个关键字前缀匹配，全部关键字都需匹配
+// 中日韩文字在索引中逐字分词，包含这些文字的关键字按短语匹配
+func SearchMatchExpression(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		tokens := strings.Fields(repository.SearchIndexText(term))
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) == 1 {
+			parts = append(parts, tokens[0]+"*")
+			continue
+		}
+		parts = append(parts, `"`+strings.Join(tokens, " ")+`*"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// SearchScore 计算关键字与编号、名称的匹配得分（0-100），ok 表示每个关键字都匹配了编号或名称
+// 编号完全匹配 100，编号中的词完全匹配 90，编号前缀 80，名称完全匹配 70，名称中的词完全匹配 65，名称前缀 60，编号包含 40，名称包含 30
+func SearchScore(terms []string, code string, names ...string) (score int, ok bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	code = strings.ToLower(code)
+	ok = true
+	total := 0
+	for _, term := range terms {
+		best := 0
+		if code != "" {
+			switch {
+			case code == term:
+				best = 100
+			case strings.HasPrefix(code, term):
+				best = 80
+			case strings.Contains(code, term):
+				best = 40
+			}
+			for _, word := range searchWords(code) {
+				if word == term && best < 90 {
+					best = 90
+				} else if strings.HasPrefix(word, term) && best < 70 {
+					best = 70
+				}
+			}
+		}
+		for _, name := range names {
+			name = strings.ToLower(name)
+			value := 0
+			switch {
+			case name == "":
+			case name == term:
+				value = 70
+			case strings.HasPrefix(name, term):
+				value = 60
+			case strings.Contains(name, term):
+				value = 30
+			}
+			for _, word := range searchWords(name) {
+				if word == term && value < 65 {
+					value = 65
+				} else if strings.HasPrefix(word, term) && value < 60 {
+					value = 60
+				}
+			}
+			if value > best {
+				best = value
+			}
+		}
+		if best == 0 {
+			ok = false
+		}
+		total += best
+	}
+	return total / len(terms), ok
+}
+
+// SearchCorrectTerms 将索引中没有的关键字纠正为编辑距离最小的索引词，4 个字符以上允许 1 处差异，8 个字符以上允许 2 处
+// 返回纠正后的关键字，ok 表示至少纠正了一个关键字
+func SearchCorrectTerms(terms []string, vocabulary []string) (corrected []string, ok bool) {
+	corrected = make([]string, 0, len(terms))
+	for _, term := range terms {
+		runes := []rune(term)
+		maxDistance := 0
+		switch {
+		case len(runes) >= 8:
+			maxDistance = 2
+		case len(runes) >= 4:
+			maxDistance = 1
+		}
+		// 中日韩文字逐字索引，不纠正
+		for _, r := range runes {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+				maxDistance = 0
+			}
+		}
+
+		best, bestDistance := term, maxDistance+1
+		for _, word := range vocabulary {
+			if strings.HasPrefix(word, term) {
+				best, bestDistance = term, 0
+				break
+			}
+			if distance := editDistance(runes, []rune(word)); distance < bestDistance {
+				best, bestDistance = word, distance
+			}
+		}
+		if bestDistance > maxDistance {
+			best = term
+		}
+		ok = ok || best != term
+		corrected = append(corrected, best)
+	}
+	return corrected, ok
+}
+
+// editDistance 计算两个字符串的编辑距离（插入、删除、替换各计 1）
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+const (
+	statisticsCursorName = "statistics" // 统计汇总游标名称
+	statisticsBatchSize  = 500          // 每批汇总的事件数
+	statisticsMaxBatches = 20           // 每次任务最多汇总的批数，避免首次汇总历史事件时长时间占用数据库
+	statisticsTopLimit   = 10           // 排行榜默认条数
+	statisticsMaxLimit   = 100          // 排行榜最大条数
+)
+
+// StatisticsService 统计分析的业务接口
+type StatisticsService interface {
+	Aggregate()
+	Rebuild()
+	Summary(startTime string, endTime string) response.StatisticsSummaryResponse
+	CountByDoor(startTime string, endTime string) []response.DoorStatisticsResponse
+	BusiestDoors(startTime string, endTime string, limit int) []response.DoorStatisticsResponse
+	CountByReader(startTime string, endTime string) []response.ReaderStatisticsResponse
+	CountByDepartment(startTime string, endTime string) []response.DepartmentStatisticsResponse
+	Heatmap(startTime string, endTime string) response.HeatmapResponse
+	TopDeniedCards(startTime string, endTime string, limit int) []response.DeniedCardResponse
+}
+
+// StatisticsServiceImpl 统计分析的业务实现
+// 后台任务按事件消息 ID 增量汇总到小时统计表和拒绝卡号日统计表，查询只读取汇总表
+type StatisticsServiceImpl struct {
+	StatisticsRepository  repository.StatisticsRepository
+	EventCursorRepository repository.EventCursorRepository
+}
+
+// NewStatisticsServiceImpl 创建并返回一个新的 StatisticsServiceImpl 实例
+func NewStatisticsServiceImpl(
+	statisticsRepository repository.StatisticsRepository,
+	eventCursorRepository repository.EventCursorRepository,
+) StatisticsService {
+	return &StatisticsServiceImpl{
+		StatisticsRepository:  statisticsRepository,
+		EventCursorRepository: eventCursorRepository,
+	}
+}
+
+// Aggregate 后台任务：汇总游标之后的新事件，首次运行时或消息 ID 重新编号后从第一条事件开始汇总
+func (s *StatisticsServiceImpl) Aggregate() {
+	lastMsgId, _ := loadEventCursor(s.EventCursorRepository, statisticsCursorName)
+
+	for i := 0; i < statisticsMaxBatches; i++ {
+		events := s.EventCursorRepository.FindEventsAfter(lastMsgId, statisticsBatchSize)
+		if len(events) == 0 {
+			return
+		}
+
+		hours, cards := AggregateEvents(events)
+		lastMsgId = events[len(events)-1].MsgId
+		err := s.StatisticsRepository.Apply(hours, cards, statisticsCursorName, lastMsgId)
+		utils.ErrorPanic(err)
+
+		if len(events) < statisticsBatchSize {
+			return
+		}
+	}
+}
+
+// Rebuild 清空统计数据，后台任务将从第一条事件开始重新汇总
+func (s *StatisticsServiceImpl) Rebuild() {
+	err := s.StatisticsRepository.DeleteAll(statisticsCursorName)
+	utils.ErrorPanic(err)
+}
+
+// Summary 查询时间段内的事件数汇总
+func (s *StatisticsServiceImpl) Summary(startTime string, endTime string) response.StatisticsSummaryResponse {
+	counts := s.StatisticsRepository.CountBy(parseStatisticsFilter(startTime, endTime), nil, "", 0)
+	if len(counts) == 0 {
+		return response.StatisticsSummaryResponse{}
+	}
+	return toStatisticsSummary(counts[0])
+}
+
+// CountByDoor 按门汇总事件数
+func (s *StatisticsServiceImpl) CountByDoor(startTime string, endTime string) []response.DoorStatisticsResponse {
+	return s.countByDoor(startTime, endTime, "ib_addr, output_addr", 0)
+}
+
+// BusiestDoors 查询通行次数最多的门
+func (s *StatisticsServiceImpl) BusiestDoors(startTime string, endTime string, limit int) []response.DoorStatisticsResponse {
+	return s.countByDoor(startTime, endTime, "granted DESC, total DESC", statisticsLimit(limit))
+}
+
+// countByDoor 按门汇总事件数
+func (s *StatisticsServiceImpl) countByDoor(startTime string, endTime string, order string, limit int) []response.DoorStatisticsResponse {
+	counts := s.StatisticsRepository.CountBy(
+		parseStatisticsFilter(startTime, endTime),
+		[]string{"ib_addr", "output_addr"},
+		order,
+		limit,
+	)
+
+	doorResponses := make([]response.DoorStatisticsResponse, 0, len(counts))
+	for _, count := range counts {
+		doorResponses = append(doorResponses, response.DoorStatisticsResponse{
+			IBAddr:                    count.IBAddr,
+			OutputAddr:                count.OutputAddr,
+			IBName:                    count.IBName,
+			StatisticsSummaryResponse: toStatisticsSummary(count),
+		})
+	}
+	return doorResponses
+}
+
+// CountByReader 按读卡器汇总事件数
+func (s *StatisticsServiceImpl) CountByReader(startTime string, endTime string) []response.ReaderStatisticsResponse {
+	counts := s.StatisticsRepository.CountBy(
+		parseStatisticsFilter(startTime, endTime),
+		[]string{"ib_addr", "reader_addr"},
+		"ib_addr, reader_addr",
+		0,
+	)
+
+	readerResponses := make([]response.ReaderStatisticsResponse, 0, len(counts))
+	for _, count := range counts {
+		readerResponses = append(readerResponses, response.ReaderStatisticsResponse{
+			IBAddr:                    count.IBAddr,
+			ReaderAddr:                count.ReaderAddr,
+			IBName:                    count.IBName,
+			ReaderName:                count.ReaderName,
+			StatisticsSummaryResponse: toStatisticsSummary(count),
+		})
+	}
+	return readerResponses
+}
+
+// CountByDepartment 按部门汇总事件数
+func (s *StatisticsServiceImpl) CountByDepartment(startTime string, endTime string) []response.DepartmentStatisticsResponse {
+	counts := s.StatisticsRepository.CountBy(
+		parseStatisticsFilter(startTime, endTime),
+		[]string{"department"},
+		"total DESC",
+		0,
+	)
+
+	departmentResponses := make([]response.DepartmentStatisticsResponse, 0, len(counts))
+	for _, count := range counts {
+		departmentResponses = append(departmentResponses, response.DepartmentStatisticsResponse{
+			Department:                count.Department,
+			StatisticsSummaryResponse: toStatisticsSummary(count),
+		})
+	}
+	return departmentResponses
+}
+
+// Heatmap 查询按小时、星期以及星期 × 小时汇总的事件热力图
+func (s *StatisticsServiceImpl) Heatmap(startTime string, endTime string) response.HeatmapResponse {
+	counts := s.StatisticsRepository.CountBy(
+		parseStatisticsFilter(startTime, endTime),
+		[]string{"weekday", "hour_of_day"},
+		"weekday, hour_of_day",
+		0,
+	)
+
+	heatmap := response.HeatmapResponse{
+		Hourly:  make([]response.StatisticsSummaryResponse, 24),
+		Weekday: make([]response.StatisticsSummaryResponse, 7),
+		Cells:   make([]response.HeatmapCellResponse, 0, len(counts)),
+	}
+	for _, count := range counts {
+		summary := toStatisticsSummary(count)
+		heatmap.Cells = append(heatmap.Cells, response.HeatmapCellResponse{
+			Weekday:                   count.Weekday,
+			Hour:                      count.HourOfDay,
+			StatisticsSummaryResponse: summary,
+		})
+		if count.HourOfDay < 24 {
+			addStatisticsSummary(&heatmap.Hourly[count.HourOfDay], summary)
+		}
+		if count.Weekday < 7 {
+			addStatisticsSummary(&heatmap.Weekday[count.Weekday], summary)
+		}
+	}
+	return heatmap
+}
+
+// TopDeniedCards 查询被拒绝次数最多的卡
+func (s *StatisticsServiceImpl) TopDeniedCards(startTime string, endTime string, limit int) []response.DeniedCardResponse {
+	filter := parseStatisticsFilter(startTime, endTime)
+	// 卡号按天统计，时间范围扩展到整天
+	if filter.Start > 0 {
+		filter.Start = statisticsDay(time.Unix(int64(filter.Start), 0))
+	}
+	if filter.End > 0 {
+		end := time.Unix(int64(filter.End), 0)
+		if day := statisticsDay(end); int64(day) != end.Unix() {
+			filter.End = statisticsDay(end.AddDate(0, 0, 1))
+		}
+	}
+
+	counts := s.StatisticsRepository.TopDeniedCards(filter, statisticsLimit(limit))
+
+	cardResponses := make([]response.DeniedCardResponse, 0, len(counts))
+	for _, count := range counts {
+		cardResponses = append(cardResponses, response.DeniedCardResponse{
+			CardNo:     count.CardNo,
+			PeopleName: count.PeopleName,
+			Count:      count.Count,
+			LastTime:   count.LastTime,
+		})
+	}
+	return cardResponses
+}
+
+// AggregateEvents 将一批事件消息汇总为小时统计和拒绝卡号日统计
+func AggregateEvents(events []*model.EventMessageData) ([]*model.EventStatHour, []*model.EventStatCard) {
+	type hourKey struct {
+		hour       uint
+		ibAddr     int
+		readerAddr int
+		outputAddr int
+		department string
+		eventType  uint
+	}
+	type cardKey struct {
+		day    uint
+		cardNo string
+	}
+
+	hourStats := map[hourKey]*model.EventStatHour{}
+	cardStats := map[cardKey]*model.EventStatCard{}
+	var hours []*model.EventStatHour
+	var cards []*model.EventStatCard
+
+	for _, event := range events {
+		accessTime := ParseAccessTime(event.AccessTime)
+		hour := time.Date(accessTime.Year(), accessTime.Month(), accessTime.Day(), accessTime.Hour(), 0, 0, 0, accessTime.Location())
+
+		key := hourKey{
+			hour:       uint(hour.Unix()),
+			ibAddr:     event.IBAddr,
+			readerAddr: event.ReaderAddr,
+			outputAddr: event.OutputAddr,
+			department: event.PeopleDepart,
+			eventType:  event.EventType,
+		}
+		stat, ok := hourStats[key]
+		if !ok {
+			stat = &model.EventStatHour{
+				Hour:       key.hour,
+				Weekday:    uint(hour.Weekday()),
+				HourOfDay:  uint(hour.Hour()),
+				IBAddr:     key.ibAddr,
+				ReaderAddr: key.readerAddr,
+				OutputAddr: key.outputAddr,
+				Department: key.department,
+				EventType:  key.eventType,
+			}
+			hourStats[key] = stat
+			hours = append(hours, stat)
+		}
+		stat.Count++
+		stat.IBName = event.IBName
+		stat.ReaderName = event.ReaderName
+
+		if event.EventType != model.EventTypeDenied || event.CardNo == "" {
+			continue
+		}
+		ckey := cardKey{day: statisticsDay(accessTime), cardNo: event.CardNo}
+		card, ok := cardStats[ckey]
+		if !ok {
+			card = &model.EventStatCard{Day: ckey.day, CardNo: ckey.cardNo}
+			cardStats[ckey] = card
+			cards = append(cards, card)
+		}
+		card.Count++
+		card.PeopleName = strings.TrimSpace(event.FullName)
+		if lastTime := uint(accessTime.Unix()); lastTime > card.LastTime {
+			card.LastTime = lastTime
+		}
+	}
+	return hours, cards
+}
+
+// parseStatisticsFilter 解析查询时间范围，支持 "2006-01-02 15:04:05" 和 "2006-01-02" 格式
+// 只有日期的结束时间包含当天
+func parseStatisticsFilter(startTime string, endTime string) repository.StatisticsFilter {
+	filter := repository.StatisticsFilter{}
+	if startTime != "" {
+		start, _ := parseStatisticsTime(startTime)
+		filter.Start = uint(start.Unix())
+	}
+	if endTime != "" {
+		end, dateOnly := parseStatisticsTime(endTime)
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		filter.End = uint(end.Unix())
+	}
+	return filter
+}
+
+// parseStatisticsTime 解析本地时间字符串，返回时间以及是否只有日期
+func parseStatisticsTime(s string) (time.Time, bool) {
+	if t, err := time.ParseInLocation(time.DateTime, s, time.Local); err == nil {
+		return t, false
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	utils.ErrorPanic(err)
+	return t, true
+}
+
+// statisticsDay 返回本地时间当天零点的 UNIX 时间戳
+func statisticsDay(t time.Time) uint {
+	return uint(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Unix())
+}
+
+// statisticsLimit 返回排行榜条数，未指定时使用默认值
+func statisticsLimit(limit int) int {
+	if limit <= 0 {
+		return statisticsTopLimit
+	}
+	if limit > statisticsMaxLimit {
+		return statisticsMaxLimit
+	}
+	return limit
+}
+
+// toStatisticsSummary 将汇总结果转换为响应结构
+func toStatisticsSummary(count *repository.EventStatCount) response.StatisticsSummaryResponse {
+	return response.StatisticsSummaryResponse{
+		Granted: count.Granted,
+		Denied:  count.Denied,
+		Alarm:   count.Alarm,
+		Total:   count.Total,
+	}
+}
+
+// addStatisticsSummary 累加事件数
+func addStatisticsSummary(sum *response.StatisticsSummaryResponse, summary response.StatisticsSummaryResponse) {
+	sum.Granted += summary.Granted
+	sum.Denied += summary.Denied
+	sum.Alarm += summary.Alarm
+	sum.Total += summary.Total
+}
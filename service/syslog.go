@@ -0,0 +1,560 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+const (
+	syslogDeliverBatchSize = 200              // 每次发送的最大条数
+	syslogQueueMax         = 10000            // 本地队列最大条数，超出后丢弃最早的消息
+	syslogDialTimeout      = 5 * time.Second  // 连接超时
+	syslogWriteTimeout     = 10 * time.Second // 写超时
+)
+
+// Syslog 严重级别（RFC 5424）
+const (
+	syslogSeverityCritical = 2
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+	syslogSeverityInfo     = 6
+)
+
+// SyslogService Syslog 转发的业务接口
+type SyslogService interface {
+	FindConfig() response.SyslogConfigResponse
+	UpdateConfig(req request.UpdateSyslogConfigRequest) response.SyslogConfigResponse
+	Status() response.SyslogStatusResponse
+	Test()
+	Enqueue(msg *HubMessage)
+	Deliver()
+}
+
+// SyslogServiceImpl Syslog 转发的业务实现
+// 事件中心的消息按配置格式化后写入本地队列，后台任务按顺序发送，日志服务器不可达时保留在队列中等待补发
+type SyslogServiceImpl struct {
+	SyslogConfigRepository repository.SyslogConfigRepository
+	SyslogQueueRepository  repository.SyslogQueueRepository
+	Validate               *validator.Validate
+
+	mu        sync.Mutex // 保护 config、resetConn、connected、lastError、lastSent
+	config    *model.SyslogConfig
+	resetConn bool
+	connected bool
+	lastError string
+	lastSent  uint
+
+	sendMu sync.Mutex // 保护 conn，发送期间持有，避免阻塞消息入队
+	conn   net.Conn
+}
+
+// NewSyslogServiceImpl 创建并返回一个新的 SyslogServiceImpl 实例
+func NewSyslogServiceImpl(
+	syslogConfigRepository repository.SyslogConfigRepository,
+	syslogQueueRepository repository.SyslogQueueRepository,
+	validate *validator.Validate,
+) SyslogService {
+	return &SyslogServiceImpl{
+		SyslogConfigRepository: syslogConfigRepository,
+		SyslogQueueRepository:  syslogQueueRepository,
+		Validate:               validate,
+	}
+}
+
+// FindConfig 查询 Syslog 转发配置
+func (s *SyslogServiceImpl) FindConfig() response.SyslogConfigResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return toSyslogConfigResponse(s.loadConfig())
+}
+
+// UpdateConfig 更新 Syslog 转发配置，连接参数变化后下次发送时重新连接
+func (s *SyslogServiceImpl) UpdateConfig(req request.UpdateSyslogConfigRequest) response.SyslogConfigResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := *s.loadConfig()
+	if req.Enable != nil {
+		config.Enable = boolToUint(*req.Enable)
+	}
+	if req.Protocol != nil {
+		config.Protocol = *req.Protocol
+	}
+	if req.Host != nil {
+		config.Host = *req.Host
+	}
+	if req.Port != nil {
+		config.Port = *req.Port
+	}
+	if req.Format != nil {
+		config.Format = *req.Format
+	}
+	if req.Facility != nil {
+		config.Facility = *req.Facility
+	}
+	if req.AppName != nil {
+		config.AppName = *req.AppName
+	}
+	if req.Hostname != nil {
+		config.Hostname = *req.Hostname
+	}
+	if req.CACert != nil {
+		config.CACert = *req.CACert
+	}
+	if req.InsecureSkipVerify != nil {
+		config.InsecureSkipVerify = boolToUint(*req.InsecureSkipVerify)
+	}
+	if req.ForwardAccess != nil {
+		config.ForwardAccess = boolToUint(*req.ForwardAccess)
+	}
+	if req.ForwardAlarm != nil {
+		config.ForwardAlarm = boolToUint(*req.ForwardAlarm)
+	}
+	if req.ForwardAudit != nil {
+		config.ForwardAudit = boolToUint(*req.ForwardAudit)
+	}
+	if req.ForwardSystem != nil {
+		config.ForwardSystem = boolToUint(*req.ForwardSystem)
+	}
+
+	if config.Enable == 1 && config.Host == "" {
+		panic("host is required when syslog is enabled")
+	}
+	if _, err := syslogTLSConfig(&config); err != nil {
+		panic(err)
+	}
+
+	newConfig, err := s.SyslogConfigRepository.Save(config)
+	utils.ErrorPanic(err)
+
+	s.config = newConfig
+	s.lastError = ""
+	s.resetConn = true
+
+	return toSyslogConfigResponse(newConfig)
+}
+
+// Status 查询转发状态
+func (s *SyslogServiceImpl) Status() response.SyslogStatusResponse {
+	queued := s.SyslogQueueRepository.Count()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return response.SyslogStatusResponse{
+		Enable:    s.loadConfig().Enable == 1,
+		Connected: s.connected,
+		Queued:    queued,
+		LastError: s.lastError,
+		LastSent:  s.lastSent,
+	}
+}
+
+// Test 立即发送一条测试消息，发送失败时返回错误
+func (s *SyslogServiceImpl) Test() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	config := s.prepareSend()
+	if config.Host == "" {
+		panic("syslog host is not configured")
+	}
+
+	message := FormatSyslogMessage(config, syslogHostname(config), NewHubMessage("system.test", "syslog test message"))
+	err := s.send(config, message)
+	s.recordResult(err, false)
+	utils.ErrorPanic(err)
+}
+
+// Enqueue 事件中心订阅函数，按类别开关过滤后格式化消息并写入本地队列
+func (s *SyslogServiceImpl) Enqueue(msg *HubMessage) {
+	s.mu.Lock()
+	config := s.loadConfig()
+	s.mu.Unlock()
+
+	if config.Enable == 0 || !syslogForwarded(config, msg.Category) {
+		return
+	}
+
+	err := s.SyslogQueueRepository.Save(model.SyslogQueue{
+		Message:   FormatSyslogMessage(config, syslogHostname(config), msg),
+		CreatedAt: uint(time.Now().Unix()),
+	})
+	utils.ErrorPanic(err)
+}
+
+// Deliver 后台任务：按入队顺序发送队列中的消息，发送失败时保留剩余消息等待下次重试
+func (s *SyslogServiceImpl) Deliver() {
+	s.SyslogQueueRepository.Trim(syslogQueueMax)
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	config := s.prepareSend()
+	if config.Enable == 0 || config.Host == "" {
+		s.closeConn()
+		s.recordResult(nil, false)
+		return
+	}
+
+	for {
+		queues := s.SyslogQueueRepository.FindFirst(syslogDeliverBatchSize)
+		if len(queues) == 0 {
+			return
+		}
+
+		var sentId uint
+		var err error
+		for _, queue := range queues {
+			if err = s.send(config, queue.Message); err != nil {
+				log.Printf("syslog: send failed: %v", err)
+				break
+			}
+			sentId = queue.ID
+		}
+
+		if sentId > 0 {
+			s.SyslogQueueRepository.DeleteUpTo(sentId)
+		}
+		s.recordResult(err, sentId > 0)
+
+		if err != nil || len(queues) < syslogDeliverBatchSize {
+			return
+		}
+	}
+}
+
+// loadConfig 返回缓存的配置，首次调用时从数据库读取，调用方需持有 s.mu
+func (s *SyslogServiceImpl) loadConfig() *model.SyslogConfig {
+	if s.config == nil {
+		config, err := s.SyslogConfigRepository.Find()
+		utils.ErrorPanic(err)
+		s.config = config
+	}
+	return s.config
+}
+
+// prepareSend 返回当前配置，配置更新后关闭旧连接，调用方需持有 s.sendMu
+func (s *SyslogServiceImpl) prepareSend() *model.SyslogConfig {
+	s.mu.Lock()
+	config := s.loadConfig()
+	resetConn := s.resetConn
+	s.resetConn = false
+	s.mu.Unlock()
+
+	if resetConn {
+		s.closeConn()
+	}
+	return config
+}
+
+// recordResult 记录发送结果，失败时关闭连接，调用方需持有 s.sendMu
+func (s *SyslogServiceImpl) recordResult(err error, sent bool) {
+	if err != nil {
+		s.closeConn()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connected = s.conn != nil
+	if sent {
+		s.lastSent = uint(time.Now().Unix())
+	}
+	if err != nil {
+		s.lastError = err.Error()
+	} else {
+		s.lastError = ""
+	}
+}
+
+// send 发送一条消息，未连接时先建立连接，调用方需持有 s.sendMu
+// TCP/TLS 使用 RFC 6587 八位组计数分帧，UDP 每条消息一个数据报
+func (s *SyslogServiceImpl) send(config *model.SyslogConfig, message string) error {
+	if s.conn == nil {
+		conn, err := dialSyslog(config)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	frame := message
+	if config.Protocol != model.SyslogProtocolUDP {
+		frame = strconv.Itoa(len(message)) + " " + message
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := s.conn.Write([]byte(frame))
+	return err
+}
+
+// closeConn 关闭当前连接，调用方需持有 s.sendMu
+func (s *SyslogServiceImpl) closeConn() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// dialSyslog 按配置的协议连接日志服务器
+func dialSyslog(config *model.SyslogConfig) (net.Conn, error) {
+	address := net.JoinHostPort(config.Host, strconv.FormatUint(uint64(config.Port), 10))
+
+	switch config.Protocol {
+	case model.SyslogProtocolUDP, model.SyslogProtocolTCP:
+		return net.DialTimeout(config.Protocol, address, syslogDialTimeout)
+	case model.SyslogProtocolTLS:
+		tlsConfig, err := syslogTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: syslogDialTimeout}, "tcp", address, tlsConfig)
+	default:
+		return nil, errors.New("unsupported syslog protocol: " + config.Protocol)
+	}
+}
+
+// syslogTLSConfig 生成 TLS 配置，非 TLS 协议返回 nil
+func syslogTLSConfig(config *model.SyslogConfig) (*tls.Config, error) {
+	if config.Protocol != model.SyslogProtocolTLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         config.Host,
+		InsecureSkipVerify: config.InsecureSkipVerify == 1,
+	}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("invalid CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// syslogForwarded 判断消息类别是否需要转发
+func syslogForwarded(config *model.SyslogConfig, category string) bool {
+	switch category {
+	case model.EventCategoryAccess:
+		return config.ForwardAccess == 1
+	case model.EventCategoryAlarm:
+		return config.ForwardAlarm == 1
+	case model.EventCategoryAudit:
+		return config.ForwardAudit == 1
+	default:
+		return config.ForwardSystem == 1
+	}
+}
+
+// syslogHostname 返回消息头中的主机名
+func syslogHostname(config *model.SyslogConfig) string {
+	if config.Hostname != "" {
+		return config.Hostname
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// FormatSyslogMessage 按 RFC 5424 格式化消息：
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+// MSGID 为消息主题，MSG 为 JSON（rfc5424）或 CEF（cef）格式
+func FormatSyslogMessage(config *model.SyslogConfig, hostname string, msg *HubMessage) string {
+	pri := config.Facility*8 + syslogSeverity(msg)
+
+	var body string
+	if config.Format == model.SyslogFormatCEF {
+		body = FormatCEF(msg)
+	} else {
+		payload, err := json.Marshal(msg)
+		utils.ErrorPanic(err)
+		body = string(payload)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		pri,
+		msg.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(hostname, 255),
+		syslogHeaderField(config.AppName, 48),
+		syslogHeaderField(msg.Topic, 32),
+		body,
+	)
+}
+
+// syslogHeaderField 将头部字段转换为 RFC 5424 要求的可打印字符，为空时使用 "-"
+func syslogHeaderField(s string, n int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, s)
+	if field == "" {
+		return "-"
+	}
+	return truncate(field, n)
+}
+
+// syslogSeverity 返回消息的 syslog 严重级别
+func syslogSeverity(msg *HubMessage) uint {
+	switch {
+	case msg.Category == model.EventCategoryAlarm:
+		return syslogSeverityCritical
+	case msg.Topic == "access.denied":
+		return syslogSeverityWarning
+	case msg.Category == model.EventCategoryAudit:
+		if auditLog, ok := msg.Data.(response.AuditLogResponse); ok && !auditLog.Success {
+			return syslogSeverityWarning
+		}
+		return syslogSeverityNotice
+	default:
+		return syslogSeverityInfo
+	}
+}
+
+// cefSeverity 返回消息的 CEF 严重级别（0-10）
+func cefSeverity(msg *HubMessage) int {
+	switch syslogSeverity(msg) {
+	case syslogSeverityCritical:
+		return 8
+	case syslogSeverityWarning:
+		return 5
+	case syslogSeverityNotice:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// cefExtension CEF 扩展字段
+type cefExtension struct {
+	key   string
+	value string
+}
+
+// FormatCEF 将消息格式化为 ArcSight CEF：
+// CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension
+func FormatCEF(msg *HubMessage) string {
+	name := msg.Topic
+	extensions := []cefExtension{
+		{"rt", strconv.FormatInt(msg.Time.UnixMilli(), 10)},
+		{"cat", msg.Category},
+	}
+
+	switch data := msg.Data.(type) {
+	case response.AuditLogResponse:
+		name = data.Method + " " + data.Path
+		outcome := "success"
+		if !data.Success {
+			outcome = "failure"
+		}
+		extensions = append(extensions,
+			cefExtension{"suid", strconv.FormatUint(uint64(data.UserId), 10)},
+			cefExtension{"requestMethod", data.Method},
+			cefExtension{"request", data.Target},
+			cefExtension{"src", data.ClientIP},
+			cefExtension{"outcome", outcome},
+			cefExtension{"msg", data.Message},
+		)
+	case nil:
+	case string:
+		extensions = append(extensions, cefExtension{"msg", data})
+	default:
+		payload, err := json.Marshal(data)
+		utils.ErrorPanic(err)
+		extensions = append(extensions, cefExtension{"msg", string(payload)})
+	}
+
+	if event := msg.Event; event != nil {
+		content := strconv.FormatUint(uint64(event.Content), 10)
+		name = msg.Topic + " " + content
+		extensions = append(extensions,
+			cefExtension{"externalId", strconv.FormatUint(uint64(event.MsgId), 10)},
+			cefExtension{"suid", event.PeopleCode},
+			cefExtension{"suser", event.FullName},
+			cefExtension{"cn1Label", "eventCode"},
+			cefExtension{"cn1", content},
+			cefExtension{"cs1Label", "cardNo"},
+			cefExtension{"cs1", event.CardNo},
+			cefExtension{"cs2Label", "reader"},
+			cefExtension{"cs2", event.ReaderName},
+			cefExtension{"cs3Label", "department"},
+			cefExtension{"cs3", event.PeopleDepart},
+			cefExtension{"cs4Label", "interfaceBoard"},
+			cefExtension{"cs4", event.IBName},
+		)
+	}
+
+	var ext []string
+	for _, extension := range extensions {
+		if extension.value != "" {
+			ext = append(ext, extension.key+"="+cefEscapeExtension(extension.value))
+		}
+	}
+
+	return fmt.Sprintf("CEF:0|Hoyang|Ownsa|1.0|%s|%s|%d|%s",
+		cefEscapeHeader(msg.Topic),
+		cefEscapeHeader(name),
+		cefSeverity(msg),
+		strings.Join(ext, " "),
+	)
+}
+
+// cefEscapeHeader 转义 CEF 头部字段中的 \ 和 |
+func cefEscapeHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+// cefEscapeExtension 转义 CEF 扩展字段值中的 \、= 和换行
+func cefEscapeExtension(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// toSyslogConfigResponse 将 Syslog 转发配置转换为响应结构
+func toSyslogConfigResponse(config *model.SyslogConfig) response.SyslogConfigResponse {
+	return response.SyslogConfigResponse{
+		Enable:             config.Enable == 1,
+		Protocol:           config.Protocol,
+		Host:               config.Host,
+		Port:               config.Port,
+		Format:             config.Format,
+		Facility:           config.Facility,
+		AppName:            config.AppName,
+		Hostname:           config.Hostname,
+		CACert:             config.CACert,
+		InsecureSkipVerify: config.InsecureSkipVerify == 1,
+		ForwardAccess:      config.ForwardAccess == 1,
+		ForwardAlarm:       config.ForwardAlarm == 1,
+		ForwardAudit:       config.ForwardAudit == 1,
+		ForwardSystem:      config.ForwardSystem == 1,
+	}
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/pbkdf2"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 指静脉模板的数量限制和导出文件参数，设备的模板容量由 .env 的 VeinTemplateCapacity 设置
+const (
+	veinTemplateMaxPerPeople = 3 // 每人最多模板数
+	veinExportVersion        = 1 // 导出文件版本
+	veinExportIterations     = 100000
+	veinExportMaxFile        = 64 << 20 // 导入文件的最大字节数
+)
+
+// VeinTemplateService 指静脉模板的业务接口
+type VeinTemplateService interface {
+	FindByPeopleId(peopleId uint) []response.VeinTemplateResponse
+	Enroll(req request.EnrollVeinTemplateRequest) response.VeinTemplateResponse
+	Delete(peopleId uint, fingerIndex uint)
+	DeleteByPeopleId(peopleId uint)
+	Usage() response.VeinTemplateUsageResponse
+	Export(req request.ExportVeinTemplateRequest) []byte
+	Import(file io.Reader, passphrase string) response.VeinTemplateImportResponse
+}
+
+// veinTemplateExport 指静脉模板导出文件，模板使用口令派生的密钥重新加密，导入时按人员编号匹配
+type veinTemplateExport struct {
+	Version    int                      `json:"version"`
+	ExportedAt string                   `json:"exported_at"`
+	Salt       string                   `json:"salt"` // 口令派生密钥的盐，十六进制
+	Templates  []veinTemplateExportItem `json:"templates"`
+}
+
+// veinTemplateExportItem 导出文件中的模板
+type veinTemplateExportItem struct {
+	PeopleCode  string `json:"people_code"`
+	FingerIndex uint   `json:"finger_index"`
+	Template    string `json:"template"` // 加密后的模板，Base64 编码
+	Checksum    string `json:"checksum"` // 模板原文的 SHA-256
+}
+
+// VeinTemplateServiceImpl 指静脉模板的业务实现
+// 模板使用本机密钥加密保存在 red_vein_template 表中；在控制器之间迁移时使用口令加密的导出文件
+type VeinTemplateServiceImpl struct {
+	VeinTemplateRepository    repository.VeinTemplateRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	Key                       []byte // 模板加密密钥（32 字节）
+	Capacity                  int64  // 设备的模板容量
+	Validate                  *validator.Validate
+}
+
+// NewVeinTemplateServiceImpl 创建并返回一个新的 VeinTemplateServiceImpl 实例
+func NewVeinTemplateServiceImpl(
+	veinTemplateRepository repository.VeinTemplateRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	key []byte,
+	capacity int64,
+	validate *validator.Validate,
+) VeinTemplateService {
+	return &VeinTemplateServiceImpl{
+		VeinTemplateRepository:    veinTemplateRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		Key:                       key,
+		Capacity:                  capacity,
+		Validate:                  validate,
+	}
+}
+
+// LoadVeinTemplateKey 读取 .env 的 VeinTemplateKey 作为模板加密密钥
+// 不使用 SecretKey，避免更换登录令牌密钥后已保存的模板无法解密
+func LoadVeinTemplateKey() ([]byte, error) {
+	confEnv, err := godotenv.Read() // .env in project root path.
+	if err != nil {
+		return nil, err
+	}
+	secret := confEnv["VeinTemplateKey"]
+	if secret == "" {
+		return nil, errors.New("VeinTemplateKey is required")
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// FindByPeopleId 查询人员的模板
+func (s *VeinTemplateServiceImpl) FindByPeopleId(peopleId uint) []response.VeinTemplateResponse {
+	templates := []response.VeinTemplateResponse{}
+	for _, template := range s.VeinTemplateRepository.FindByPeopleId(peopleId) {
+		templates = append(templates, toVeinTemplateResponse(template))
+	}
+	return templates
+}
+
+// Enroll 登记或替换人员指定手指的模板
+func (s *VeinTemplateServiceImpl) Enroll(req request.EnrollVeinTemplateRequest) response.VeinTemplateResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	if len(s.PeopleDirectoryRepository.FindPeopleByIds([]uint{req.PeopleId})) == 0 {
+		panic("people " + strconv.FormatUint(uint64(req.PeopleId), 10) + " not found")
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Template)
+	utils.ErrorPanic(err)
+
+	template, err := s.save(req.PeopleId, req.FingerIndex, data)
+	utils.ErrorPanic(err)
+	return toVeinTemplateResponse(template)
+}
+
+// Delete 删除人员指定手指的模板
+func (s *VeinTemplateServiceImpl) Delete(peopleId uint, fingerIndex uint) {
+	err := s.VeinTemplateRepository.Delete(peopleId, fingerIndex)
+	utils.ErrorPanic(err)
+}
+
+// DeleteByPeopleId 删除人员的所有模板
+func (s *VeinTemplateServiceImpl) DeleteByPeopleId(peopleId uint) {
+	err := s.VeinTemplateRepository.DeleteByPeopleId(peopleId)
+	utils.ErrorPanic(err)
+}
+
+// Usage 查询模板数量和容量
+func (s *VeinTemplateServiceImpl) Usage() response.VeinTemplateUsageResponse {
+	count := s.VeinTemplateRepository.Count()
+	return response.VeinTemplateUsageResponse{
+		Count:        count,
+		PeopleCount:  s.VeinTemplateRepository.CountPeople(),
+		Capacity:     s.Capacity,
+		Free:         max(s.Capacity-count, 0),
+		MaxPerPeople: veinTemplateMaxPerPeople,
+	}
+}
+
+// Export 导出所有模板，模板解密后使用口令派生的密钥重新加密
+func (s *VeinTemplateServiceImpl) Export(req request.ExportVeinTemplateRequest) []byte {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	salt := make([]byte, 16)
+	_, err = rand.Read(salt)
+	utils.ErrorPanic(err)
+	exportKey := veinExportKey(req.Passphrase, salt)
+
+	peopleCodes := map[uint]string{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peopleCodes[people.ID] = people.PeopleCode
+	}
+
+	export := veinTemplateExport{
+		Version:    veinExportVersion,
+		ExportedAt: time.Now().Format(time.DateTime),
+		Salt:       hex.EncodeToString(salt),
+		Templates:  []veinTemplateExportItem{},
+	}
+	for _, template := range s.VeinTemplateRepository.FindAll() {
+		peopleCode, ok := peopleCodes[template.PeopleId]
+		if !ok || peopleCode == "" {
+			continue
+		}
+		data, err := DecryptVeinTemplate(s.Key, template.Template)
+		utils.ErrorPanic(err)
+		encrypted, err := EncryptVeinTemplate(exportKey, data)
+		utils.ErrorPanic(err)
+		export.Templates = append(export.Templates, veinTemplateExportItem{
+			PeopleCode:  peopleCode,
+			FingerIndex: template.FingerIndex,
+			Template:    base64.StdEncoding.EncodeToString(encrypted),
+			Checksum:    template.Checksum,
+		})
+	}
+
+	data, err := json.Marshal(export)
+	utils.ErrorPanic(err)
+	return data
+}
+
+// Import 导入其他控制器导出的模板，按人员编号匹配人员，每个模板单独返回结果
+func (s *VeinTemplateServiceImpl) Import(file io.Reader, passphrase string) response.VeinTemplateImportResponse {
+	var export veinTemplateExport
+	err := json.NewDecoder(io.LimitReader(file, veinExportMaxFile)).Decode(&export)
+	utils.ErrorPanic(err)
+	if export.Version != veinExportVersion {
+		panic("unsupported vein template export version " + strconv.Itoa(export.Version))
+	}
+	salt, err := hex.DecodeString(export.Salt)
+	utils.ErrorPanic(err)
+	exportKey := veinExportKey(passphrase, salt)
+
+	peoples := map[string]uint{}
+	for _, people := range s.PeopleDirectoryRepository.FindAllPeople() {
+		peoples[people.PeopleCode] = people.ID
+	}
+
+	result := response.VeinTemplateImportResponse{Items: []response.VeinTemplateImportItemResponse{}}
+	for _, exported := range export.Templates {
+		item := response.VeinTemplateImportItemResponse{
+			PeopleCode:  exported.PeopleCode,
+			FingerIndex: exported.FingerIndex,
+			PeopleId:    peoples[exported.PeopleCode],
+		}
+		err := func() error {
+			if item.PeopleId == 0 {
+				return errors.New("people code not found")
+			}
+			if item.FingerIndex > 9 {
+				return errors.New("invalid finger index")
+			}
+			encrypted, err := base64.StdEncoding.DecodeString(exported.Template)
+			if err != nil {
+				return err
+			}
+			data, err := DecryptVeinTemplate(exportKey, encrypted)
+			if err != nil {
+				return errors.New("wrong passphrase or corrupted template")
+			}
+			if veinTemplateChecksum(data) != exported.Checksum {
+				return errors.New("template checksum mismatch")
+			}
+			_, err = s.save(item.PeopleId, item.FingerIndex, data)
+			return err
+		}()
+		if err != nil {
+			item.Message = err.Error()
+			result.Failed++
+		} else {
+			item.Success = true
+			result.Succeeded++
+		}
+		result.Total++
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+// save 加密并保存模板，每人模板数和设备容量在保存的事务中检查
+func (s *VeinTemplateServiceImpl) save(peopleId uint, fingerIndex uint, data []byte) (*model.VeinTemplate, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty vein template")
+	}
+
+	encrypted, err := EncryptVeinTemplate(s.Key, data)
+	if err != nil {
+		return nil, err
+	}
+	template := model.VeinTemplate{
+		PeopleId:    peopleId,
+		FingerIndex: fingerIndex,
+		Template:    encrypted,
+		Size:        uint(len(data)),
+		Checksum:    veinTemplateChecksum(data),
+		UpdatedAt:   uint(time.Now().Unix()),
+	}
+	err = s.VeinTemplateRepository.Save(template, veinTemplateMaxPerPeople, s.Capacity)
+	if errors.Is(err, repository.ErrVeinTemplatePeopleLimit) {
+		return nil, errors.New("vein template limit per people reached (" + strconv.Itoa(veinTemplateMaxPerPeople) + ")")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// EncryptVeinTemplate 使用 AES-256-GCM 加密模板，返回随机数与密文的拼接
+func EncryptVeinTemplate(key []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// DecryptVeinTemplate 解密 EncryptVeinTemplate 加密的模板
+func DecryptVeinTemplate(key []byte, encrypted []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, errors.New("encrypted vein template too short")
+	}
+	nonce, ciphertext := encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// veinExportKey 由口令派生导出文件的加密密钥
+func veinExportKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, veinExportIterations, 32, sha256.New)
+}
+
+// veinTemplateChecksum 计算模板原文的 SHA-256
+func veinTemplateChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// toVeinTemplateResponse 将指静脉模板转换为响应
+func toVeinTemplateResponse(template *model.VeinTemplate) response.VeinTemplateResponse {
+	return response.VeinTemplateResponse{
+		PeopleId:    template.PeopleId,
+		FingerIndex: template.FingerIndex,
+		Size:        template.Size,
+		Checksum:    template.Checksum,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
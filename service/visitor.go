@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// 访客签到、签离和过期时推送的主题
+const (
+	visitorCheckedInTopic  = "system.visitor_checked_in"
+	visitorCheckedOutTopic = "system.visitor_checked_out"
+	visitorExpiredTopic    = "system.visitor_expired"
+)
+
+// VisitorService 访客管理的业务接口
+type VisitorService interface {
+	Create(req request.CreateVisitorRequest) response.VisitorResponse
+	CheckIn(req request.CheckInVisitorRequest) response.VisitorResponse
+	CheckOut(id uint) response.VisitorResponse
+	Cancel(id uint) response.VisitorResponse
+	FindById(id uint) response.VisitorResponse
+	FindAll(req request.FindVisitorRequest, pg *utils.Pagination) []response.VisitorResponse
+	Expire() int
+}
+
+// VisitorServiceImpl 访客管理的业务实现
+// 访客使用临时人员和凭证通行，凭证有效期为预约时间段，签离、取消或过期时删除
+type VisitorServiceImpl struct {
+	VisitorRepository         repository.VisitorRepository
+	PeopleDirectoryRepository repository.PeopleDirectoryRepository
+	GroupDirectoryRepository  repository.GroupDirectoryRepository
+	EventHub                  *EventHub
+	Validate                  *validator.Validate
+}
+
+// NewVisitorServiceImpl 创建并返回一个新的 VisitorServiceImpl 实例
+func NewVisitorServiceImpl(
+	visitorRepository repository.VisitorRepository,
+	peopleDirectoryRepository repository.PeopleDirectoryRepository,
+	groupDirectoryRepository repository.GroupDirectoryRepository,
+	eventHub *EventHub,
+	validate *validator.Validate,
+) VisitorService {
+	return &VisitorServiceImpl{
+		VisitorRepository:         visitorRepository,
+		PeopleDirectoryRepository: peopleDirectoryRepository,
+		GroupDirectoryRepository:  groupDirectoryRepository,
+		EventHub:                  eventHub,
+		Validate:                  validate,
+	}
+}
+
+// Create 预约访客，创建临时人员和凭证
+func (s *VisitorServiceImpl) Create(req request.CreateVisitorRequest) response.VisitorResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	expectedFrom, err := ParseValidityTime(req.ExpectedFrom, false)
+	utils.ErrorPanic(err)
+	expectedUntil, err := ParseValidityTime(req.ExpectedUntil, true)
+	utils.ErrorPanic(err)
+	if expectedUntil <= uint(time.Now().Unix()) {
+		panic("expected_until must be in the future")
+	}
+	if expectedFrom > 0 && expectedUntil <= expectedFrom {
+		panic("expected_until must be later than expected_from")
+	}
+
+	if len(s.PeopleDirectoryRepository.FindPeopleByIds([]uint{req.HostPeopleId})) == 0 {
+		panic("host people " + strconv.FormatUint(uint64(req.HostPeopleId), 10) + " not found")
+	}
+	groupIds := map[uint]bool{}
+	for _, accessGroup := range s.GroupDirectoryRepository.FindAllAccessGroups() {
+		groupIds[accessGroup.GroupId] = true
+	}
+	for _, groupId := range req.AccessGroupIds {
+		if !groupIds[groupId] {
+			panic("access group " + strconv.FormatUint(uint64(groupId), 10) + " not found")
+		}
+	}
+
+	cardNo := req.CardNo
+	if cardNo == "" {
+		cardNo = s.generatePin()
+	}
+
+	visitor := model.Visitor{
+		Name:           req.Name,
+		Company:        req.Company,
+		Phone:          req.Phone,
+		HostPeopleId:   req.HostPeopleId,
+		Purpose:        req.Purpose,
+		ExpectedFrom:   expectedFrom,
+		ExpectedUntil:  expectedUntil,
+		CardNo:         cardNo,
+		AccessGroupIds: joinUintList(req.AccessGroupIds),
+	}
+	err = s.VisitorRepository.Register(&visitor, req.AccessGroupIds)
+	utils.ErrorPanic(err)
+	return s.toVisitorResponse(&visitor)
+}
+
+// CheckIn 访客签到，临时凭证立即生效
+func (s *VisitorServiceImpl) CheckIn(req request.CheckInVisitorRequest) response.VisitorResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	visitor, err := s.VisitorRepository.CheckIn(req.ID, req.CardNo)
+	utils.ErrorPanic(err)
+
+	visitorResponse := s.toVisitorResponse(visitor)
+	s.EventHub.Publish(NewHubMessage(visitorCheckedInTopic, visitorResponse))
+	return visitorResponse
+}
+
+// CheckOut 访客签离，删除临时人员和凭证
+func (s *VisitorServiceImpl) CheckOut(id uint) response.VisitorResponse {
+	visitor, err := s.VisitorRepository.Revoke(id, model.VisitorStatusCheckedOut)
+	utils.ErrorPanic(err)
+
+	visitorResponse := s.toVisitorResponse(visitor)
+	s.EventHub.Publish(NewHubMessage(visitorCheckedOutTopic, visitorResponse))
+	return visitorResponse
+}
+
+// Cancel 取消尚未签到的访客预约
+func (s *VisitorServiceImpl) Cancel(id uint) response.VisitorResponse {
+	visitor, err := s.VisitorRepository.Revoke(id, model.VisitorStatusCancelled)
+	utils.ErrorPanic(err)
+	return s.toVisitorResponse(visitor)
+}
+
+// FindById 根据 ID 查询访客
+func (s *VisitorServiceImpl) FindById(id uint) response.VisitorResponse {
+	visitor, err := s.VisitorRepository.FindById(id)
+	utils.ErrorPanic(err)
+	return s.toVisitorResponse(visitor)
+}
+
+// FindAll 分页查询访客日志
+func (s *VisitorServiceImpl) FindAll(req request.FindVisitorRequest, pg *utils.Pagination) []response.VisitorResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	filter := repository.VisitorFilter{Status: req.Status, Keyword: req.Keyword}
+	filter.Start, err = ParseValidityTime(req.StartTime, false)
+	utils.ErrorPanic(err)
+	filter.End, err = ParseValidityTime(req.EndTime, true)
+	utils.ErrorPanic(err)
+
+	visitors := s.VisitorRepository.FindAll(filter, pg)
+	visitorResponses := make([]response.VisitorResponse, 0, len(visitors))
+	for _, visitor := range visitors {
+		visitorResponses = append(visitorResponses, s.toVisitorResponse(visitor))
+	}
+	return visitorResponses
+}
+
+// Expire 后台任务：注销超过预约结束时间的访客，返回注销的访客数
+func (s *VisitorServiceImpl) Expire() int {
+	expired := 0
+	for _, id := range s.VisitorRepository.FindExpiredIds(uint(time.Now().Unix())) {
+		visitor, err := s.VisitorRepository.Revoke(id, model.VisitorStatusExpired)
+		if err != nil {
+			log.Printf("visitor: expire %d: %v", id, err)
+			continue
+		}
+		expired++
+		s.EventHub.Publish(NewHubMessage(visitorExpiredTopic, s.toVisitorResponse(visitor)))
+	}
+	return expired
+}
+
+// generatePin 生成未被使用的 6 位数字密码
+func (s *VisitorServiceImpl) generatePin() string {
+	for i := 0; i < 20; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(900000))
+		utils.ErrorPanic(err)
+		pin := strconv.FormatInt(n.Int64()+100000, 10)
+		if !s.VisitorRepository.CardNoExists(pin) {
+			return pin
+		}
+	}
+	panic("failed to generate visitor pin")
+}
+
+// toVisitorResponse 将访客转换为响应结构
+func (s *VisitorServiceImpl) toVisitorResponse(visitor *model.Visitor) response.VisitorResponse {
+	visitorResponse := response.VisitorResponse{
+		ID:             visitor.ID,
+		Name:           visitor.Name,
+		Company:        visitor.Company,
+		Phone:          visitor.Phone,
+		HostPeopleId:   visitor.HostPeopleId,
+		Purpose:        visitor.Purpose,
+		ExpectedFrom:   visitor.ExpectedFrom,
+		ExpectedUntil:  visitor.ExpectedUntil,
+		CardNo:         visitor.CardNo,
+		AccessGroupIds: splitUintList(visitor.AccessGroupIds),
+		PeopleId:       visitor.PeopleId,
+		UniqueId:       visitor.UniqueId,
+		Status:         visitor.Status,
+		CheckedInAt:    visitor.CheckedInAt,
+		CheckedOutAt:   visitor.CheckedOutAt,
+		CreatedAt:      visitor.CreatedAt,
+	}
+	if hosts := s.PeopleDirectoryRepository.FindPeopleByIds([]uint{visitor.HostPeopleId}); len(hosts) > 0 {
+		visitorResponse.HostName = peopleFullName(hosts[0])
+	}
+	return visitorResponse
+}
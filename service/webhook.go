@@ -0,0 +1,448 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+const (
+	webhookDefaultMaxRetries = 8                   // 默认最大重试次数
+	webhookDefaultTimeout    = 10                  // 默认请求超时（秒）
+	webhookTestTimeout       = 5                   // 测试请求超时上限（秒），须小于 HTTP 服务的写超时
+	webhookBackoffBase       = 10 * time.Second    // 重试退避基数
+	webhookBackoffMax        = time.Hour           // 重试退避上限
+	webhookDeliverBatchSize  = 20                  // 每次投递的最大条数
+	webhookDeliveryRetention = 30 * 24 * time.Hour // 已结束投递记录的保留时间
+)
+
+// WebhookService Webhook 订阅与投递的业务接口
+type WebhookService interface {
+	Create(req request.CreateWebhookRequest) response.WebhookResponse
+	Update(req request.UpdateWebhookRequest)
+	Delete(webhookId uint)
+	FindById(webhookId uint) response.WebhookResponse
+	FindAll() []response.WebhookResponse
+	FindDeliveries(webhookId uint, pg *utils.Pagination) []response.WebhookDeliveryResponse
+	Retry(deliveryId uint)
+	Test(webhookId uint) response.WebhookDeliveryResponse
+	Enqueue(msg *HubMessage)
+	Deliver()
+	Purge()
+}
+
+// WebhookServiceImpl Webhook 订阅与投递的业务实现
+type WebhookServiceImpl struct {
+	WebhookRepository         repository.WebhookRepository
+	WebhookDeliveryRepository repository.WebhookDeliveryRepository
+	Validate                  *validator.Validate
+}
+
+// NewWebhookServiceImpl 创建并返回一个新的 WebhookServiceImpl 实例
+func NewWebhookServiceImpl(
+	webhookRepository repository.WebhookRepository,
+	webhookDeliveryRepository repository.WebhookDeliveryRepository,
+	validate *validator.Validate,
+) WebhookService {
+	return &WebhookServiceImpl{
+		WebhookRepository:         webhookRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		Validate:                  validate,
+	}
+}
+
+// Create 创建 Webhook 订阅
+func (s *WebhookServiceImpl) Create(req request.CreateWebhookRequest) response.WebhookResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	webhook := model.Webhook{
+		Name:       req.Name,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		Categories: strings.Join(req.Categories, ","),
+		EventTypes: joinUintList(req.EventTypes),
+		Enable:     boolToUint(*req.Enable),
+		MaxRetries: webhookDefaultMaxRetries,
+		Timeout:    webhookDefaultTimeout,
+	}
+	if req.MaxRetries != nil {
+		webhook.MaxRetries = *req.MaxRetries
+	}
+	if req.Timeout != nil {
+		webhook.Timeout = *req.Timeout
+	}
+
+	newWebhook, err := s.WebhookRepository.Save(webhook)
+	utils.ErrorPanic(err)
+
+	return toWebhookResponse(newWebhook)
+}
+
+// Update 更新 Webhook 订阅，仅更新请求中提供的字段
+func (s *WebhookServiceImpl) Update(req request.UpdateWebhookRequest) {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	webhook, err := s.WebhookRepository.FindById(req.ID)
+	utils.ErrorPanic(err)
+
+	if req.Name != nil {
+		webhook.Name = *req.Name
+	}
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.Categories != nil {
+		webhook.Categories = strings.Join(*req.Categories, ",")
+	}
+	if req.EventTypes != nil {
+		webhook.EventTypes = joinUintList(*req.EventTypes)
+	}
+	if req.Enable != nil {
+		webhook.Enable = boolToUint(*req.Enable)
+	}
+	if req.MaxRetries != nil {
+		webhook.MaxRetries = *req.MaxRetries
+	}
+	if req.Timeout != nil {
+		webhook.Timeout = *req.Timeout
+	}
+
+	err = s.WebhookRepository.Update(*webhook)
+	utils.ErrorPanic(err)
+}
+
+// Delete 删除 Webhook 订阅及其投递记录
+func (s *WebhookServiceImpl) Delete(webhookId uint) {
+	s.WebhookRepository.Delete(webhookId)
+	s.WebhookDeliveryRepository.DeleteByWebhookId(webhookId)
+}
+
+// FindById 根据 ID 查询 Webhook 订阅
+func (s *WebhookServiceImpl) FindById(webhookId uint) response.WebhookResponse {
+	webhook, err := s.WebhookRepository.FindById(webhookId)
+	utils.ErrorPanic(err)
+
+	return toWebhookResponse(webhook)
+}
+
+// FindAll 查询所有 Webhook 订阅
+func (s *WebhookServiceImpl) FindAll() []response.WebhookResponse {
+	webhooks := s.WebhookRepository.FindAll()
+
+	webhookResponses := make([]response.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookResponses = append(webhookResponses, toWebhookResponse(webhook))
+	}
+	return webhookResponses
+}
+
+// FindDeliveries 分页查询指定 Webhook 的投递记录
+func (s *WebhookServiceImpl) FindDeliveries(webhookId uint, pg *utils.Pagination) []response.WebhookDeliveryResponse {
+	deliveries := s.WebhookDeliveryRepository.FindByWebhookId(webhookId, pg)
+
+	deliveryResponses := make([]response.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryResponses = append(deliveryResponses, toWebhookDeliveryResponse(delivery))
+	}
+	return deliveryResponses
+}
+
+// Retry 将投递记录重新置为等待投递，立即参与下一轮投递
+func (s *WebhookServiceImpl) Retry(deliveryId uint) {
+	delivery, err := s.WebhookDeliveryRepository.FindById(deliveryId)
+	utils.ErrorPanic(err)
+
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttempt = uint(time.Now().Unix())
+
+	err = s.WebhookDeliveryRepository.Update(*delivery)
+	utils.ErrorPanic(err)
+}
+
+// Test 向指定 Webhook 同步发送一条测试消息，并返回投递结果；请求超时不超过 5 秒
+func (s *WebhookServiceImpl) Test(webhookId uint) response.WebhookDeliveryResponse {
+	webhook, err := s.WebhookRepository.FindById(webhookId)
+	utils.ErrorPanic(err)
+
+	msg := NewHubMessage("webhook.test", map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"name":       webhook.Name,
+	})
+	payload, err := json.Marshal(msg)
+	utils.ErrorPanic(err)
+
+	delivery, err := s.WebhookDeliveryRepository.Save(model.WebhookDelivery{
+		WebhookID:   webhook.ID,
+		Topic:       msg.Topic,
+		Payload:     string(payload),
+		Status:      model.WebhookDeliveryPending,
+		NextAttempt: uint(time.Now().Unix()),
+	})
+	utils.ErrorPanic(err)
+
+	// 测试请求在 HTTP 请求内同步发送，超时不能超过服务的写超时
+	testWebhook := *webhook
+	testWebhook.Timeout = min(testWebhook.Timeout, webhookTestTimeout)
+	s.attempt(&testWebhook, delivery)
+	// 测试消息不进入重试队列
+	if delivery.Status == model.WebhookDeliveryPending {
+		delivery.Status = model.WebhookDeliveryFailed
+	}
+	err = s.WebhookDeliveryRepository.Update(*delivery)
+	utils.ErrorPanic(err)
+
+	return toWebhookDeliveryResponse(delivery)
+}
+
+// Enqueue 事件中心订阅函数，为每个匹配的 Webhook 写入一条待投递记录
+func (s *WebhookServiceImpl) Enqueue(msg *HubMessage) {
+	webhooks := s.WebhookRepository.FindEnabled()
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(msg)
+	utils.ErrorPanic(err)
+
+	now := uint(time.Now().Unix())
+	for _, webhook := range webhooks {
+		if !webhookMatches(webhook, msg) {
+			continue
+		}
+
+		_, err := s.WebhookDeliveryRepository.Save(model.WebhookDelivery{
+			WebhookID:   webhook.ID,
+			Topic:       msg.Topic,
+			MsgId:       msg.MsgId,
+			Payload:     string(payload),
+			Status:      model.WebhookDeliveryPending,
+			NextAttempt: now,
+		})
+		utils.ErrorPanic(err)
+	}
+}
+
+// Deliver 投递所有到期的记录，失败时按指数退避安排下一次重试
+func (s *WebhookServiceImpl) Deliver() {
+	deliveries := s.WebhookDeliveryRepository.FindDue(uint(time.Now().Unix()), webhookDeliverBatchSize)
+
+	webhooks := map[uint]*model.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, _ = s.WebhookRepository.FindById(delivery.WebhookID)
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		if webhook == nil || webhook.Enable == 0 {
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.LastError = "webhook deleted or disabled"
+		} else {
+			s.attempt(webhook, delivery)
+		}
+
+		err := s.WebhookDeliveryRepository.Update(*delivery)
+		utils.ErrorPanic(err)
+	}
+}
+
+// Purge 清理超过保留时间的已结束投递记录
+func (s *WebhookServiceImpl) Purge() {
+	s.WebhookDeliveryRepository.DeleteBefore(time.Now().Add(-webhookDeliveryRetention))
+}
+
+// attempt 执行一次投递并更新投递记录的状态
+func (s *WebhookServiceImpl) attempt(webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := postWebhook(webhook, delivery)
+	delivery.StatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = model.WebhookDeliverySuccess
+		delivery.LastError = ""
+		delivery.DeliveredAt = uint(time.Now().Unix())
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), 255)
+	if delivery.Attempts > webhook.MaxRetries {
+		delivery.Status = model.WebhookDeliveryFailed
+		log.Printf("webhook %d: delivery %d failed after %d attempts: %v", webhook.ID, delivery.ID, delivery.Attempts, err)
+		return
+	}
+	delivery.NextAttempt = uint(time.Now().Add(WebhookBackoff(delivery.Attempts)).Unix())
+}
+
+// postWebhook 发送一次 HTTP POST 请求，2xx 响应视为成功
+func postWebhook(webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ownsa-webhook")
+	req.Header.Set("X-Ownsa-Event", delivery.Topic)
+	req.Header.Set("X-Ownsa-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Ownsa-Timestamp", strconv.FormatInt(timestamp, 10))
+	if webhook.Secret != "" {
+		req.Header.Set("X-Ownsa-Signature", "sha256="+SignWebhookPayload(webhook.Secret, timestamp, body))
+	}
+
+	client := &http.Client{Timeout: time.Duration(webhook.Timeout) * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload 计算 Webhook 签名：HMAC-SHA256(secret, "<timestamp>.<body>") 的十六进制字符串
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff 返回第 attempts 次失败后的重试间隔，按指数增长并有上限
+func WebhookBackoff(attempts uint) time.Duration {
+	if attempts == 0 {
+		return 0
+	}
+	backoff := webhookBackoffBase
+	for i := uint(1); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookBackoffMax {
+			return webhookBackoffMax
+		}
+	}
+	return backoff
+}
+
+// webhookMatches 判断消息是否匹配 Webhook 的类别和事件类型过滤条件
+func webhookMatches(webhook *model.Webhook, msg *HubMessage) bool {
+	if categories := splitList(webhook.Categories); len(categories) > 0 && !slices.Contains(categories, msg.Category) {
+		return false
+	}
+	if eventTypes := splitUintList(webhook.EventTypes); len(eventTypes) > 0 {
+		if msg.Event == nil || !slices.Contains(eventTypes, msg.Event.EventType) {
+			return false
+		}
+	}
+	return true
+}
+
+// toWebhookResponse 将 Webhook 模型转换为响应结构
+func toWebhookResponse(webhook *model.Webhook) response.WebhookResponse {
+	return response.WebhookResponse{
+		ID:         webhook.ID,
+		Name:       webhook.Name,
+		URL:        webhook.URL,
+		HasSecret:  webhook.Secret != "",
+		Categories: splitList(webhook.Categories),
+		EventTypes: splitUintList(webhook.EventTypes),
+		Enable:     webhook.Enable == 1,
+		MaxRetries: webhook.MaxRetries,
+		Timeout:    webhook.Timeout,
+	}
+}
+
+// toWebhookDeliveryResponse 将投递记录模型转换为响应结构
+func toWebhookDeliveryResponse(delivery *model.WebhookDelivery) response.WebhookDeliveryResponse {
+	return response.WebhookDeliveryResponse{
+		ID:          delivery.ID,
+		WebhookID:   delivery.WebhookID,
+		Topic:       delivery.Topic,
+		MsgId:       delivery.MsgId,
+		Payload:     delivery.Payload,
+		Status:      delivery.Status,
+		Attempts:    delivery.Attempts,
+		NextAttempt: delivery.NextAttempt,
+		StatusCode:  delivery.StatusCode,
+		LastError:   delivery.LastError,
+		DeliveredAt: delivery.DeliveredAt,
+		CreatedAt:   uint(delivery.CreatedAt.Unix()),
+	}
+}
+
+// splitList 拆分逗号分隔的字符串，忽略空项
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitUintList 拆分逗号分隔的数字字符串，忽略无效项
+func splitUintList(s string) []uint {
+	items := []uint{}
+	for _, item := range splitList(s) {
+		if n, err := strconv.ParseUint(item, 10, 32); err == nil {
+			items = append(items, uint(n))
+		}
+	}
+	return items
+}
+
+// joinUintList 将数字列表拼接为逗号分隔的字符串
+func joinUintList(items []uint) string {
+	s := make([]string, 0, len(items))
+	for _, item := range items {
+		s = append(s, strconv.FormatUint(uint64(item), 10))
+	}
+	return strings.Join(s, ",")
+}
+
+// boolToUint 将布尔值转换为 0/1
+func boolToUint(b bool) uint {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
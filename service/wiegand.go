@@ -0,0 +1,382 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+
+	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
+	"hoyang/ownsa/model"
+	"hoyang/ownsa/repository"
+	"hoyang/ownsa/utils"
+)
+
+// WiegandService 韦根规则管理与卡号解码的业务接口
+type WiegandService interface {
+	Create(req request.WiegandRuleRequest) response.WiegandRuleResponse
+	Update(req request.WiegandRuleRequest) response.WiegandRuleResponse
+	Delete(ruleId uint)
+	FindById(ruleId uint) response.WiegandRuleResponse
+	FindAll() []response.WiegandRuleResponse
+	FindPresets() []response.WiegandRuleResponse
+	Decode(req request.DecodeWiegandRequest) response.WiegandDecodeResultResponse
+	DecodeEvents(msgIds []uint) []response.EventWiegandResponse
+	DecodeEvent(event *model.EventMessageData) *response.WiegandDecodeResponse
+}
+
+// 预置韦根规则，不写入控制器；控制器中没有相同位数的规则时用于解码，也可作为新建规则的模板
+var presetWiegandRules = []model.ValidWiegandRule{
+	{
+		Name: "H10301 26-bit", BitLength: 26,
+		FacilityStart: 1, FacilityLength: 8, CardStart: 9, CardLength: 16,
+		EvenParityBit: 0, EvenParityStart: 1, EvenParityLength: 12,
+		OddParityBit: 25, OddParityStart: 13, OddParityLength: 12,
+	},
+	{
+		Name: "34-bit", BitLength: 34,
+		FacilityStart: 1, FacilityLength: 16, CardStart: 17, CardLength: 16,
+		EvenParityBit: 0, EvenParityStart: 1, EvenParityLength: 16,
+		OddParityBit: 33, OddParityStart: 17, OddParityLength: 16,
+	},
+	{
+		Name: "H10304 37-bit", BitLength: 37,
+		FacilityStart: 1, FacilityLength: 16, CardStart: 17, CardLength: 19,
+		EvenParityBit: 0, EvenParityStart: 1, EvenParityLength: 18,
+		OddParityBit: 36, OddParityStart: 18, OddParityLength: 18,
+	},
+	{
+		Name: "H10302 37-bit", BitLength: 37,
+		CardStart: 1, CardLength: 35,
+		EvenParityBit: 0, EvenParityStart: 1, EvenParityLength: 18,
+		OddParityBit: 36, OddParityStart: 18, OddParityLength: 18,
+	},
+}
+
+// WiegandServiceImpl 韦根规则管理与卡号解码的业务实现
+// 规则保存在控制器的 ValidWiegandRule 表中，修改后需同步配置到控制器
+// 事件中的卡号按控制器上报的原始韦根数据（十进制，失败时按十六进制）解析，再按位数相同的规则拆分设备码和卡号
+type WiegandServiceImpl struct {
+	WiegandRuleRepository repository.WiegandRuleRepository
+	EventCursorRepository repository.EventCursorRepository
+	Validate              *validator.Validate
+
+	mu    sync.Mutex
+	rules []*model.ValidWiegandRule // 控制器中的所有规则，为 nil 时重新加载
+}
+
+// NewWiegandServiceImpl 创建并返回一个新的 WiegandServiceImpl 实例
+func NewWiegandServiceImpl(
+	wiegandRuleRepository repository.WiegandRuleRepository,
+	eventCursorRepository repository.EventCursorRepository,
+	validate *validator.Validate,
+) WiegandService {
+	return &WiegandServiceImpl{
+		WiegandRuleRepository: wiegandRuleRepository,
+		EventCursorRepository: eventCursorRepository,
+		Validate:              validate,
+	}
+}
+
+// Create 创建韦根规则
+func (s *WiegandServiceImpl) Create(req request.WiegandRuleRequest) response.WiegandRuleResponse {
+	rule := s.toRule(req)
+	saved, err := s.WiegandRuleRepository.Save(rule)
+	utils.ErrorPanic(err)
+	s.invalidate()
+	return toWiegandRuleResponse(saved)
+}
+
+// Update 修改韦根规则
+func (s *WiegandServiceImpl) Update(req request.WiegandRuleRequest) response.WiegandRuleResponse {
+	_, err := s.WiegandRuleRepository.FindById(req.ID)
+	utils.ErrorPanic(err)
+
+	rule := s.toRule(req)
+	rule.ID = req.ID
+	err = s.WiegandRuleRepository.Update(rule)
+	utils.ErrorPanic(err)
+	s.invalidate()
+	return toWiegandRuleResponse(&rule)
+}
+
+// Delete 删除韦根规则
+func (s *WiegandServiceImpl) Delete(ruleId uint) {
+	err := s.WiegandRuleRepository.Delete(ruleId)
+	utils.ErrorPanic(err)
+	s.invalidate()
+}
+
+// FindById 根据 ID 查询韦根规则
+func (s *WiegandServiceImpl) FindById(ruleId uint) response.WiegandRuleResponse {
+	rule, err := s.WiegandRuleRepository.FindById(ruleId)
+	utils.ErrorPanic(err)
+	return toWiegandRuleResponse(rule)
+}
+
+// FindAll 查询控制器中的所有韦根规则
+func (s *WiegandServiceImpl) FindAll() []response.WiegandRuleResponse {
+	rules := []response.WiegandRuleResponse{}
+	for _, rule := range s.WiegandRuleRepository.FindAll() {
+		rules = append(rules, toWiegandRuleResponse(rule))
+	}
+	return rules
+}
+
+// FindPresets 查询预置韦根规则
+func (s *WiegandServiceImpl) FindPresets() []response.WiegandRuleResponse {
+	rules := []response.WiegandRuleResponse{}
+	for i := range presetWiegandRules {
+		rules = append(rules, toWiegandRuleResponse(&presetWiegandRules[i]))
+	}
+	return rules
+}
+
+// Decode 解码原始韦根数据，返回各候选规则的解码结果，校验正确的规则在前
+func (s *WiegandServiceImpl) Decode(req request.DecodeWiegandRequest) response.WiegandDecodeResultResponse {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	var bits string
+	if req.Bits != "" {
+		bits, err = ParseWiegandBits(req.Bits)
+	} else {
+		bits, err = WiegandBitsFromValue(req.Value, req.BitLength)
+	}
+	utils.ErrorPanic(err)
+
+	var rules []*model.ValidWiegandRule
+	if req.RuleId > 0 {
+		rule, err := s.WiegandRuleRepository.FindById(req.RuleId)
+		utils.ErrorPanic(err)
+		if rule.BitLength != uint(len(bits)) {
+			panic(fmt.Sprintf("wiegand rule %s expects %d bits, got %d", rule.Name, rule.BitLength, len(bits)))
+		}
+		rules = []*model.ValidWiegandRule{rule}
+	} else {
+		rules = s.candidates(uint(len(bits)))
+	}
+
+	results := []response.WiegandDecodeResponse{}
+	for _, rule := range rules {
+		results = append(results, DecodeWiegand(bits, rule))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ParityValid && !results[j].ParityValid
+	})
+	return response.WiegandDecodeResultResponse{Bits: bits, Results: results}
+}
+
+// DecodeEvents 解码事件中的韦根数据
+func (s *WiegandServiceImpl) DecodeEvents(msgIds []uint) []response.EventWiegandResponse {
+	events := []response.EventWiegandResponse{}
+	for _, event := range s.EventCursorRepository.FindEventsByMsgIds(msgIds) {
+		events = append(events, response.EventWiegandResponse{
+			MsgId:   event.MsgId,
+			Wiegand: event.Wiegand,
+			CardNo:  event.CardNo,
+			Decoded: s.DecodeEvent(event),
+		})
+	}
+	return events
+}
+
+// DecodeEvent 按位数相同的韦根规则解码事件卡号，优先返回校验正确的结果，无法解码时返回 nil
+func (s *WiegandServiceImpl) DecodeEvent(event *model.EventMessageData) *response.WiegandDecodeResponse {
+	if event.Wiegand == 0 || event.CardNo == "" {
+		return nil
+	}
+	bits, err := WiegandBitsFromValue(event.CardNo, event.Wiegand)
+	if err != nil {
+		bits, err = WiegandBitsFromValue("0x"+event.CardNo, event.Wiegand)
+	}
+	if err != nil {
+		return nil
+	}
+
+	var decoded *response.WiegandDecodeResponse
+	for _, rule := range s.candidates(event.Wiegand) {
+		result := DecodeWiegand(bits, rule)
+		if result.ParityValid {
+			return &result
+		}
+		if decoded == nil {
+			decoded = &result
+		}
+	}
+	return decoded
+}
+
+// candidates 返回控制器中指定位数的规则；控制器中没有该位数的规则时返回该位数的预置规则
+func (s *WiegandServiceImpl) candidates(bitLength uint) []*model.ValidWiegandRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rules == nil {
+		s.rules = append([]*model.ValidWiegandRule{}, s.WiegandRuleRepository.FindAll()...)
+	}
+
+	var rules []*model.ValidWiegandRule
+	for _, rule := range s.rules {
+		if rule.BitLength == bitLength {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) > 0 {
+		return rules
+	}
+	for i := range presetWiegandRules {
+		if presetWiegandRules[i].BitLength == bitLength {
+			rules = append(rules, &presetWiegandRules[i])
+		}
+	}
+	return rules
+}
+
+// invalidate 韦根规则变更后，下次解码时重新加载
+func (s *WiegandServiceImpl) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules = nil
+}
+
+// toRule 校验请求并转换为韦根规则，位范围需在总位数之内，校验范围不能包含其校验位
+func (s *WiegandServiceImpl) toRule(req request.WiegandRuleRequest) model.ValidWiegandRule {
+	err := s.Validate.Struct(req)
+	utils.ErrorPanic(err)
+
+	inRange := func(start uint, length uint) bool {
+		return start+length <= req.BitLength
+	}
+	if req.FacilityLength > 0 && !inRange(req.FacilityStart, req.FacilityLength) {
+		panic("facility code bits exceed bit length")
+	}
+	if !inRange(req.CardStart, req.CardLength) {
+		panic("card number bits exceed bit length")
+	}
+	checkParity := func(bit uint, start uint, length uint) {
+		if length == 0 {
+			return
+		}
+		if bit >= req.BitLength || !inRange(start, length) {
+			panic("parity bits exceed bit length")
+		}
+		if start <= bit && bit < start+length {
+			panic("parity range must not contain its parity bit")
+		}
+	}
+	checkParity(req.EvenParityBit, req.EvenParityStart, req.EvenParityLength)
+	checkParity(req.OddParityBit, req.OddParityStart, req.OddParityLength)
+
+	return model.ValidWiegandRule{
+		Name:             req.Name,
+		BitLength:        req.BitLength,
+		FacilityStart:    req.FacilityStart,
+		FacilityLength:   req.FacilityLength,
+		CardStart:        req.CardStart,
+		CardLength:       req.CardLength,
+		EvenParityBit:    req.EvenParityBit,
+		EvenParityStart:  req.EvenParityStart,
+		EvenParityLength: req.EvenParityLength,
+		OddParityBit:     req.OddParityBit,
+		OddParityStart:   req.OddParityStart,
+		OddParityLength:  req.OddParityLength,
+	}
+}
+
+// ParseWiegandBits 解析位串，忽略空格、下划线和短横线
+func ParseWiegandBits(s string) (string, error) {
+	var bits strings.Builder
+	for _, c := range s {
+		switch c {
+		case '0', '1':
+			bits.WriteRune(c)
+		case ' ', '_', '-':
+		default:
+			return "", fmt.Errorf("invalid wiegand bit %q", c)
+		}
+	}
+	if bits.Len() == 0 || bits.Len() > 128 {
+		return "", errors.New("wiegand bit length must be between 1 and 128")
+	}
+	return bits.String(), nil
+}
+
+// WiegandBitsFromValue 将原始韦根数值（十进制或 0x 开头的十六进制）转换为指定位数的位串
+func WiegandBitsFromValue(value string, bitLength uint) (string, error) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(value), 0)
+	if !ok || n.Sign() < 0 {
+		return "", fmt.Errorf("invalid wiegand value %q", value)
+	}
+	if bitLength == 0 || uint(n.BitLen()) > bitLength {
+		return "", fmt.Errorf("wiegand value %q exceeds %d bits", value, bitLength)
+	}
+	bits := n.Text(2)
+	return strings.Repeat("0", int(bitLength)-len(bits)) + bits, nil
+}
+
+// DecodeWiegand 按规则从位串中取出设备码和卡号并检查校验位，位串长度需与规则总位数一致
+func DecodeWiegand(bits string, rule *model.ValidWiegandRule) response.WiegandDecodeResponse {
+	field := func(start uint, length uint) uint64 {
+		var value uint64
+		for i := start; i < start+length && i < uint(len(bits)); i++ {
+			value = value<<1 | uint64(bits[i]-'0')
+		}
+		return value
+	}
+
+	result := response.WiegandDecodeResponse{
+		RuleId:     rule.ID,
+		RuleName:   rule.Name,
+		BitLength:  rule.BitLength,
+		CardNumber: field(rule.CardStart, rule.CardLength),
+	}
+	if rule.FacilityLength > 0 {
+		result.FacilityCode = field(rule.FacilityStart, rule.FacilityLength)
+	}
+
+	// odd 为 true 时校验位与校验范围内 1 的个数之和应为奇数，否则为偶数
+	checkParity := func(bit uint, start uint, length uint, odd bool) {
+		if length == 0 {
+			return
+		}
+		if bit >= uint(len(bits)) {
+			result.ParityErrors = append(result.ParityErrors, bit)
+			return
+		}
+		ones := strings.Count(bits[min(start, uint(len(bits))):min(start+length, uint(len(bits)))], "1")
+		ones += int(bits[bit] - '0')
+		if odd != (ones%2 == 1) {
+			result.ParityErrors = append(result.ParityErrors, bit)
+		}
+	}
+	checkParity(rule.EvenParityBit, rule.EvenParityStart, rule.EvenParityLength, false)
+	checkParity(rule.OddParityBit, rule.OddParityStart, rule.OddParityLength, true)
+	result.ParityValid = uint(len(bits)) == rule.BitLength && len(result.ParityErrors) == 0
+	return result
+}
+
+// toWiegandRuleResponse 将韦根规则转换为响应
+func toWiegandRuleResponse(rule *model.ValidWiegandRule) response.WiegandRuleResponse {
+	return response.WiegandRuleResponse{
+		ID:               rule.ID,
+		Name:             rule.Name,
+		BitLength:        rule.BitLength,
+		FacilityStart:    rule.FacilityStart,
+		FacilityLength:   rule.FacilityLength,
+		CardStart:        rule.CardStart,
+		CardLength:       rule.CardLength,
+		EvenParityBit:    rule.EvenParityBit,
+		EvenParityStart:  rule.EvenParityStart,
+		EvenParityLength: rule.EvenParityLength,
+		OddParityBit:     rule.OddParityBit,
+		OddParityStart:   rule.OddParityStart,
+		OddParityLength:  rule.OddParityLength,
+		Preset:           rule.ID == 0,
+	}
+}
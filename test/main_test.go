@@ -1,14 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -16,8 +20,10 @@ import (
 	"github.com/stretchr/testify/mock"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
 	"hoyang/ownsa/data/request"
+	"hoyang/ownsa/data/response"
 	"hoyang/ownsa/database"
 	"hoyang/ownsa/middleware"
 	"hoyang/ownsa/model"
@@ -251,7 +257,7 @@ func TestPeopleDepartControl(t *testing.T) {
 	engine.Use(gin.LoggerWithWriter(logger.Writer()))
 	engine.Use(gin.CustomRecovery(middleware.ErrorHandler))
 	router.SetupRouter(engine)
-	router.CreateWebController()
+	router.CreateWebController(&confEnv)
 	router.RegisterPeopleRoutes(&confEnv, engine, router.WebController.PeopleController)
 	// 模拟请求 /api/people GET 方法来测试 FindAll
 	w := httptest.NewRecorder()
@@ -361,3 +367,830 @@ func getTestDB() (*gorm.DB, error) {
 // 	db.Exec("DELETE FROM event_message_data WHERE accesstime LIKE '2024-12-23%'")
 // 	t.Logf("Inserted and validated 100 rows of test data in event_message_data")
 // }
+
+// Webhook 签名：HMAC-SHA256("<timestamp>.<body>")
+func TestSignWebhookPayload(t *testing.T) {
+	signature := service.SignWebhookPayload("secret", 1700000000, []byte(`{"topic":"webhook.test"}`))
+	assert.Equal(t, "93f0f00ebd2eca95b0854c1481b63eb5a2bd8a30e832dd03527fa41b2350ba23", signature)
+}
+
+// Webhook 重试间隔按指数增长，并且不超过 1 小时
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, time.Duration(0), service.WebhookBackoff(0))
+	assert.Equal(t, 10*time.Second, service.WebhookBackoff(1))
+	assert.Equal(t, 20*time.Second, service.WebhookBackoff(2))
+	assert.Equal(t, 80*time.Second, service.WebhookBackoff(4))
+	assert.Equal(t, time.Hour, service.WebhookBackoff(20))
+}
+
+// newMemoryDatabase 创建迁移了所有表的内存数据库，四个数据库共用同一个连接
+func newMemoryDatabase(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	// 部门表由后端创建，不在 Migrate 中
+	assert.NoError(t, db.AutoMigrate(&model.Department{}))
+	database.DB = &database.DbInstance{DbConfig: db, DbCredential: db, DbOtherGroup: db, DbEventMessage: db}
+	database.Migrate()
+	return db
+}
+
+// 事件中心：消息 ID 重新编号后游标回到起点，新事件继续分发
+func TestEventHubCursorReset(t *testing.T) {
+	db := newMemoryDatabase(t)
+	cursors := repository.NewEventCursorRepositoryImpl(db)
+	assert.NoError(t, cursors.Save("event_hub", 100))
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 1, EventType: model.EventTypeGranted, AccessTime: "2026-10-01 09:00:00"}).Error)
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 2, EventType: model.EventTypeGranted, AccessTime: "2026-10-01 09:00:01"}).Error)
+
+	var msgIds []uint
+	hub := service.NewEventHub(cursors)
+	hub.Subscribe("test", func(msg *service.HubMessage) {
+		msgIds = append(msgIds, msg.MsgId)
+	})
+	hub.Poll()
+	assert.Equal(t, []uint{1, 2}, msgIds)
+
+	cursor, err := cursors.FindByName("event_hub")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), cursor.LastMsgId)
+}
+
+// Syslog 消息按 RFC 5424 格式化，CEF 字段中的特殊字符需要转义
+func TestFormatSyslogMessage(t *testing.T) {
+	config := &model.SyslogConfig{Format: model.SyslogFormatCEF, Facility: 16, AppName: "ownsa"}
+	msg := service.NewHubMessage("alarm.test", "door=1|forced")
+	msg.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	message := service.FormatSyslogMessage(config, "controller 1", msg)
+	assert.Equal(t, `<130>1 2024-01-02T03:04:05.000000Z controller_1 ownsa - alarm.test - `+
+		`CEF:0|Hoyang|Ownsa|1.0|alarm.test|alarm.test|8|rt=1704164645000 cat=alarm msg=door\=1|forced`, message)
+}
+
+// 操作审计：处理函数以 panic 返回错误时记录为失败，响应仍由 ErrorHandler 生成
+func TestAuditMiddlewarePanic(t *testing.T) {
+	var auditLogs []model.AuditLog
+	server := gin.New()
+	server.Use(gin.CustomRecovery(middleware.ErrorHandler))
+	server.Use(middleware.AuditMiddleware(func(auditLog model.AuditLog) {
+		auditLogs = append(auditLogs, auditLog)
+	}))
+	server.POST("/fail", func(ctx *gin.Context) {
+		utils.ErrorPanic(errors.New("people not found"))
+	})
+	server.POST("/ok", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, response.Response{Code: 200, Success: true})
+	})
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fail", nil))
+	var result response.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Message, "people not found")
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ok", nil))
+
+	assert.Len(t, auditLogs, 2)
+	assert.Equal(t, "/fail", auditLogs[0].Path)
+	assert.Equal(t, uint(0), auditLogs[0].Success)
+	assert.Equal(t, "people not found", auditLogs[0].Message)
+	assert.Equal(t, uint(1), auditLogs[1].Success)
+}
+
+// 同一小时、同一读卡器的事件合并计数，拒绝事件同时按卡号汇总
+func TestAggregateEvents(t *testing.T) {
+	events := []*model.EventMessageData{
+		{MsgId: 1, AccessTime: "2024-01-01 08:05:00", IBAddr: 1, ReaderAddr: 1, EventType: model.EventTypeGranted, PeopleDepart: "研发部"},
+		{MsgId: 2, AccessTime: "2024-01-01 08:30:00", IBAddr: 1, ReaderAddr: 1, EventType: model.EventTypeGranted, PeopleDepart: "研发部"},
+		{MsgId: 3, AccessTime: "2024-01-01 09:10:00", IBAddr: 1, ReaderAddr: 1, EventType: model.EventTypeDenied, CardNo: "1001"},
+		{MsgId: 4, AccessTime: "2024-01-01 09:20:00", IBAddr: 1, ReaderAddr: 2, EventType: model.EventTypeDenied, CardNo: "1001"},
+	}
+
+	hours, cards := service.AggregateEvents(events)
+	assert.Len(t, hours, 3)
+	assert.Equal(t, uint(2), hours[0].Count)
+	assert.Equal(t, uint(8), hours[0].HourOfDay)
+	assert.Equal(t, uint(time.Monday), hours[0].Weekday)
+	assert.Len(t, cards, 1)
+	assert.Equal(t, uint(2), cards[0].Count)
+}
+
+// 统计汇总：消息 ID 重新编号后游标回到起点，新事件继续汇总
+func TestStatisticsCursorReset(t *testing.T) {
+	db := newMemoryDatabase(t)
+	cursors := repository.NewEventCursorRepositoryImpl(db)
+	assert.NoError(t, cursors.Save("statistics", 100))
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 1, EventType: model.EventTypeGranted, AccessTime: "2026-10-01 09:00:00", IBAddr: 1, ReaderAddr: 1}).Error)
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 2, EventType: model.EventTypeDenied, AccessTime: "2026-10-01 09:00:01", IBAddr: 1, ReaderAddr: 1, CardNo: "1001"}).Error)
+
+	statisticsService := service.NewStatisticsServiceImpl(repository.NewStatisticsRepositoryImpl(db), cursors)
+	statisticsService.Aggregate()
+	summary := statisticsService.Summary("", "")
+	assert.Equal(t, uint(1), summary.Granted)
+	assert.Equal(t, uint(1), summary.Denied)
+
+	cursor, err := cursors.FindByName("statistics")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), cursor.LastMsgId)
+}
+
+// 考勤结果：超过宽限时间刷卡为迟到，班次结束后未打下班卡为早退，无刷卡为缺勤
+func TestEvaluateAttendance(t *testing.T) {
+	shift := &model.Shift{StartTime: "08:30", EndTime: "17:30", LateGrace: 5, WorkDays: "1,2,3,4,5"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local) // 周一
+	at := func(hour, minute int) uint {
+		return uint(time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local).Unix())
+	}
+	after := day.AddDate(0, 0, 1)
+
+	record := service.EvaluateAttendance(shift, day, at(8, 34), at(17, 35), false, after)
+	assert.Equal(t, uint(0), record.Late)
+	assert.Equal(t, uint(0), record.EarlyLeave)
+
+	record = service.EvaluateAttendance(shift, day, at(8, 50), 0, false, after)
+	assert.Equal(t, uint(1), record.Late)
+	assert.Equal(t, uint(20), record.LateMinutes)
+	assert.Equal(t, uint(1), record.EarlyLeave)
+
+	record = service.EvaluateAttendance(shift, day, 0, 0, false, after)
+	assert.Equal(t, uint(1), record.Absent)
+
+	record = service.EvaluateAttendance(shift, day, 0, 0, true, after)
+	assert.Equal(t, uint(0), record.Absent)
+
+	// 班次未结束时不判定缺勤
+	record = service.EvaluateAttendance(shift, day, 0, 0, false, day.Add(10*time.Hour))
+	assert.Equal(t, uint(0), record.Absent)
+
+	// 跨天班次
+	start, end := service.ShiftPeriod(&model.Shift{StartTime: "22:00", EndTime: "06:00"}, day)
+	assert.Equal(t, 8*time.Hour, end.Sub(start))
+}
+
+// 区域进出：先离开当前区域再进入，同一读卡器可作为相邻区域的出口和入口
+func TestAreaTransition(t *testing.T) {
+	enterA := &model.AreaReader{AreaID: 1, Direction: model.AreaDirectionEnter}
+	exitA := &model.AreaReader{AreaID: 1, Direction: model.AreaDirectionExit}
+	enterB := &model.AreaReader{AreaID: 2, Direction: model.AreaDirectionEnter}
+
+	areaId, changed := service.AreaTransition(0, []*model.AreaReader{enterA})
+	assert.Equal(t, uint(1), areaId)
+	assert.True(t, changed)
+
+	areaId, changed = service.AreaTransition(1, []*model.AreaReader{enterA})
+	assert.False(t, changed)
+
+	areaId, changed = service.AreaTransition(1, []*model.AreaReader{exitA})
+	assert.Equal(t, uint(0), areaId)
+	assert.True(t, changed)
+
+	// 不在该区域时刷出口读卡器不变
+	areaId, changed = service.AreaTransition(2, []*model.AreaReader{exitA})
+	assert.Equal(t, uint(2), areaId)
+	assert.False(t, changed)
+
+	areaId, changed = service.AreaTransition(1, []*model.AreaReader{exitA, enterB})
+	assert.Equal(t, uint(2), areaId)
+	assert.True(t, changed)
+}
+
+// 人员导入文件：CSV 去除 BOM，按默认列标题或列映射识别字段
+func TestParsePeopleImportFile(t *testing.T) {
+	records, err := service.ParsePeopleImportFile("people.CSV", strings.NewReader("\xEF\xBB\xBF工号,姓,名,备注\nE1,赵,云\n"), "")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "工号", records[0][0])
+
+	columns, err := service.MapImportColumns(records[0], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"people_code": 0, "first_name": 1, "last_name": 2}, columns)
+
+	// 映射后的字段不再按默认标题识别
+	columns, err = service.MapImportColumns([]string{"编号", "员工号"}, map[string]string{"员工号": "people_code"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"people_code": 1}, columns)
+
+	_, err = service.MapImportColumns([]string{"姓名"}, nil)
+	assert.Error(t, err)
+	_, err = service.MapImportColumns([]string{"工号"}, map[string]string{"x": "unknown"})
+	assert.Error(t, err)
+	_, err = service.ParsePeopleImportFile("people.xls", strings.NewReader(""), "")
+	assert.Error(t, err)
+}
+
+// 导入回滚：导入后门禁组被修改时拒绝回滚；回滚删除新增人员及其凭证和门禁组
+func TestImportBatchRollback(t *testing.T) {
+	db := newMemoryDatabase(t)
+	imports := repository.NewPeopleImportRepositoryImpl(db)
+	batches := repository.NewImportBatchRepositoryImpl(db)
+	bulk := repository.NewBulkRepositoryImpl(db)
+
+	rows := []repository.PeopleImportRow{{
+		Row:             2,
+		People:          model.People{PeopleCode: "P001", FirstName: "张"},
+		CardNos:         []string{"1001"},
+		HasAccessGroups: true,
+		AccessGroupIds:  []uint{1},
+	}}
+	batch, changes, err := imports.Apply(rows, model.ImportBatch{FileName: "people.csv"})
+	assert.NoError(t, err)
+	uniqueId := changes.CreatedCredentialIds[0]
+
+	assign := repository.BulkOperation{Action: repository.BulkActionAssignAccessGroup, AccessGroupIds: []uint{2}}
+	_, err = bulk.ApplyCredentials([]uint{uniqueId}, assign, true)
+	assert.NoError(t, err)
+	assert.Error(t, batches.Rollback(batch.ID, 1))
+
+	remove := repository.BulkOperation{Action: repository.BulkActionRemoveAccessGroup, AccessGroupIds: []uint{2}}
+	_, err = bulk.ApplyCredentials([]uint{uniqueId}, remove, true)
+	assert.NoError(t, err)
+	assert.NoError(t, batches.Rollback(batch.ID, 1))
+
+	for _, table := range []interface{}{&model.People{}, &model.Credential{}, &model.CredentialAccess{}} {
+		var count int64
+		assert.NoError(t, db.Model(table).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+	}
+}
+
+// newPeopleImportService 创建使用内存数据库的人员导入导出服务
+func newPeopleImportService(db *gorm.DB) service.PeopleImportService {
+	return service.NewPeopleImportServiceImpl(
+		repository.NewPeopleImportRepositoryImpl(db),
+		repository.NewImportBatchRepositoryImpl(db),
+		repository.NewPeopleDirectoryRepositoryImpl(db),
+		repository.NewGroupDirectoryRepositoryImpl(db),
+		repository.NewCredentialValidityRepositoryImpl(db),
+		repository.NewCredentialUsageRepositoryImpl(db),
+		nil,
+		service.NewDepartmentTreeServiceImpl(
+			repository.NewDepartmentTreeRepositoryImpl(db),
+			repository.NewPeopleDirectoryRepositoryImpl(db),
+			repository.NewGroupDirectoryRepositoryImpl(db),
+			validator.New(),
+		),
+	)
+}
+
+// 人员导出：每张卡一行，停用的卡导出停用期间保存的门禁组，重新导入后每张卡的门禁组不变
+func TestPeopleExportRoundTrip(t *testing.T) {
+	db := newMemoryDatabase(t)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 1, Name: "大门"}).Error)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 2, Name: "机房"}).Error)
+	people := model.People{PeopleCode: "P001", FirstName: "张"}
+	assert.NoError(t, db.Create(&people).Error)
+	first := model.Credential{PeopleId: people.ID, CardNo: "1001"}
+	assert.NoError(t, db.Create(&first).Error)
+	second := model.Credential{PeopleId: people.ID, CardNo: "1002"}
+	assert.NoError(t, db.Create(&second).Error)
+	assert.NoError(t, db.Create(&model.CredentialAccess{UniqueId: first.UniqueId, AccessGroupId: 1}).Error)
+	assert.NoError(t, db.Create(&model.CredentialAccess{UniqueId: second.UniqueId, AccessGroupId: 2}).Error)
+
+	bulk := repository.NewBulkRepositoryImpl(db)
+	_, err := bulk.ApplyCredentials([]uint{second.UniqueId}, repository.BulkOperation{Action: repository.BulkActionDisable}, true)
+	assert.NoError(t, err)
+
+	peopleImportService := newPeopleImportService(db)
+	exported := peopleImportService.Export("csv")
+	records, err := service.ParsePeopleImportFile("people.csv", bytes.NewReader(exported), "")
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"1001", "大门"}, records[1][4:6])
+	assert.Equal(t, []string{"1002", "机房"}, records[2][4:6])
+
+	_, err = bulk.ApplyCredentials([]uint{first.UniqueId}, repository.BulkOperation{Action: repository.BulkActionAssignAccessGroup, AccessGroupIds: []uint{2}}, true)
+	assert.NoError(t, err)
+	result := peopleImportService.Import(request.ImportPeopleFileRequest{}, "people.csv", bytes.NewReader(exported), 1)
+	assert.Empty(t, result.Errors)
+
+	var groupIds []uint
+	assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", first.UniqueId).Pluck("access_group_id", &groupIds).Error)
+	assert.Equal(t, []uint{1}, groupIds)
+}
+
+// 导入停用的凭证：门禁组写入停用期间保存的门禁组，回滚恢复保存的门禁组，凭证始终没有门禁组
+func TestImportDisabledCredential(t *testing.T) {
+	db := newMemoryDatabase(t)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 1, Name: "大门"}).Error)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 2, Name: "机房"}).Error)
+	people := model.People{PeopleCode: "P001", FirstName: "张"}
+	assert.NoError(t, db.Create(&people).Error)
+	credential := model.Credential{PeopleId: people.ID, CardNo: "1001"}
+	assert.NoError(t, db.Create(&credential).Error)
+	assert.NoError(t, db.Create(&model.CredentialAccess{UniqueId: credential.UniqueId, AccessGroupId: 1}).Error)
+	_, err := repository.NewBulkRepositoryImpl(db).ApplyCredentials([]uint{credential.UniqueId}, repository.BulkOperation{Action: repository.BulkActionDisable}, true)
+	assert.NoError(t, err)
+
+	suspended := func() []uint {
+		var accessCount int64
+		assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", credential.UniqueId).Count(&accessCount).Error)
+		assert.Equal(t, int64(0), accessCount)
+		var state model.CredentialState
+		assert.NoError(t, db.First(&state, credential.UniqueId).Error)
+		assert.Equal(t, uint(1), state.Disabled)
+		var groupIds []uint
+		assert.NoError(t, json.Unmarshal([]byte(state.SuspendedAccess), &groupIds))
+		return groupIds
+	}
+
+	file := "人员编号,卡号,门禁组\nP001,1001,机房\n"
+	result := newPeopleImportService(db).Import(request.ImportPeopleFileRequest{}, "people.csv", strings.NewReader(file), 1)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, []uint{2}, suspended())
+
+	assert.NoError(t, repository.NewImportBatchRepositoryImpl(db).Rollback(result.BatchId, 1))
+	assert.Equal(t, []uint{1}, suspended())
+}
+
+// 凭证有效期：取凭证与人员有效期的交集，失效时间只有日期时当天仍有效
+func TestCredentialValidity(t *testing.T) {
+	validFrom, validUntil := repository.EffectiveValidity(100, 0, 200, 500)
+	assert.Equal(t, uint(200), validFrom)
+	assert.Equal(t, uint(500), validUntil)
+	_, validUntil = repository.EffectiveValidity(0, 400, 0, 500)
+	assert.Equal(t, uint(400), validUntil)
+
+	assert.Equal(t, repository.ValidityStatusPending, repository.ValidityStatus(200, 500, 199))
+	assert.Equal(t, repository.ValidityStatusActive, repository.ValidityStatus(200, 500, 200))
+	assert.Equal(t, repository.ValidityStatusExpired, repository.ValidityStatus(200, 500, 500))
+	assert.Equal(t, repository.ValidityStatusActive, repository.ValidityStatus(0, 0, 500))
+
+	// 人工停用的凭证不受有效期影响
+	assert.True(t, repository.ValidityChangeNeeded(&model.CredentialState{}, repository.ValidityStatusExpired))
+	assert.True(t, repository.ValidityChangeNeeded(&model.CredentialState{Disabled: 1, DisabledReason: model.CredentialDisabledExpired}, repository.ValidityStatusActive))
+	assert.False(t, repository.ValidityChangeNeeded(&model.CredentialState{Disabled: 1, DisabledReason: model.CredentialDisabledManual}, repository.ValidityStatusActive))
+
+	until, err := service.ParseValidityTime("2025-03-01", true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(time.Date(2025, 3, 2, 0, 0, 0, 0, time.Local).Unix()), until)
+	assert.Equal(t, "2025-03-01", service.FormatValidityTime(until, true))
+	from, err := service.ParseValidityTime("2025-03-01 08:30:00", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-03-01 08:30:00", service.FormatValidityTime(from, false))
+	_, err = service.ParseValidityTime("2025/03/01", false)
+	assert.Error(t, err)
+}
+
+// 访客：预约时临时凭证未生效，签到后门禁组生效，签离后删除人员和凭证并保留访客记录
+func TestVisitorLifecycle(t *testing.T) {
+	db := newMemoryDatabase(t)
+	visitors := repository.NewVisitorRepositoryImpl(db)
+	now := uint(time.Now().Unix())
+
+	visitor := model.Visitor{Name: "李四", CardNo: "9001", ExpectedFrom: now + 3600, ExpectedUntil: now + 7200}
+	assert.NoError(t, visitors.Register(&visitor, []uint{1}))
+	assert.Error(t, visitors.Register(&model.Visitor{Name: "王五", CardNo: "9001", ExpectedFrom: now, ExpectedUntil: now + 3600}, []uint{1}))
+
+	accessCount := func() int64 {
+		var count int64
+		assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", visitor.UniqueId).Count(&count).Error)
+		return count
+	}
+	assert.Equal(t, int64(0), accessCount())
+
+	_, err := visitors.CheckIn(visitor.ID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), accessCount())
+
+	revoked, err := visitors.Revoke(visitor.ID, model.VisitorStatusCheckedOut)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(model.VisitorStatusCheckedOut), revoked.Status)
+	assert.Equal(t, int64(0), accessCount())
+	for _, table := range []interface{}{&model.People{}, &model.Credential{}, &model.CredentialState{}} {
+		var count int64
+		assert.NoError(t, db.Model(table).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+	}
+	assert.False(t, visitors.CardNoExists("9001"))
+}
+
+// 人员照片缩放：按比例缩小到最长边，较小的图片保持原尺寸
+func TestResizePhoto(t *testing.T) {
+	resized := service.ResizePhoto(image.NewRGBA(image.Rect(0, 0, 1000, 600)), 480)
+	assert.Equal(t, image.Rect(0, 0, 480, 288), resized.Bounds())
+
+	resized = service.ResizePhoto(image.NewRGBA(image.Rect(0, 0, 300, 2000)), 96)
+	assert.Equal(t, image.Rect(0, 0, 14, 96), resized.Bounds())
+
+	resized = service.ResizePhoto(image.NewRGBA(image.Rect(10, 10, 60, 40)), 96)
+	assert.Equal(t, image.Rect(0, 0, 50, 30), resized.Bounds())
+}
+
+// 全文检索：中日韩文字逐字索引，关键字前缀匹配，按编号和名称的匹配程度评分，拼写错误按索引词纠正
+func TestSearchQuery(t *testing.T) {
+	assert.Equal(t, "三 丰 张 AB12", repository.SearchIndexText("三丰 张AB12"))
+	assert.Equal(t, []string{"emp", "001", "张三"}, service.SearchTerms("EMP-001, 张三"))
+	assert.Equal(t, `emp* 001* "张 三*"`, service.SearchMatchExpression([]string{"emp", "001", "张三"}))
+
+	score, ok := service.SearchScore([]string{"12345678"}, "12345678")
+	assert.True(t, ok)
+	assert.Equal(t, 100, score)
+	score, ok = service.SearchScore([]string{"smi"}, "EMP-002", "José", "Smith")
+	assert.True(t, ok)
+	assert.Equal(t, 60, score)
+	score, ok = service.SearchScore([]string{"3456"}, "12345678")
+	assert.True(t, ok)
+	assert.Equal(t, 40, score)
+	_, ok = service.SearchScore([]string{"smi", "brown"}, "EMP-002", "José", "Smith")
+	assert.False(t, ok)
+
+	corrected, ok := service.SearchCorrectTerms([]string{"smyth", "jo"}, []string{"jose", "smith", "brown"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"smith", "jo"}, corrected)
+	_, ok = service.SearchCorrectTerms([]string{"smi", "zzzzz"}, []string{"smith"})
+	assert.False(t, ok)
+}
+
+// 部门门禁组继承：部门继承所有上级部门的门禁组，重复的门禁组只保留一次
+func TestInheritedAccessGroupIds(t *testing.T) {
+	parents := map[uint]uint{2: 1, 3: 2, 4: 1}
+	departmentGroups := map[uint][]uint{1: {5}, 2: {6, 5}, 4: {7}}
+
+	assert.Equal(t, []uint{6, 5}, repository.InheritedAccessGroupIds(3, parents, departmentGroups))
+	assert.Equal(t, []uint{7, 5}, repository.InheritedAccessGroupIds(4, parents, departmentGroups))
+	assert.Equal(t, []uint{}, repository.InheritedAccessGroupIds(0, parents, departmentGroups))
+}
+
+// 部门路径：重名部门须填写完整路径，导入继承部门的门禁组，单独移除的继承门禁组同步时不再添加，回滚删除自动创建的部门
+func TestImportDepartmentPath(t *testing.T) {
+	db := newMemoryDatabase(t)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 1, Name: "大门"}).Error)
+	departments := repository.NewDepartmentTreeRepositoryImpl(db)
+	for _, name := range []string{"总部", "分部"} {
+		parent := model.Department{Name: name}
+		assert.NoError(t, db.Create(&parent).Error)
+		_, err := departments.CreateChild(parent.ID, "研发部")
+		assert.NoError(t, err)
+	}
+	peopleImportService := newPeopleImportService(db)
+
+	result := peopleImportService.Import(request.ImportPeopleFileRequest{}, "people.csv", strings.NewReader("人员编号,姓,部门,卡号\nP001,张,研发部,1001\n"), 1)
+	assert.NotEmpty(t, result.Errors)
+
+	result = peopleImportService.Import(request.ImportPeopleFileRequest{}, "people.csv", strings.NewReader("人员编号,姓,部门,卡号\nP001,张,总部/研发部,1001\n"), 1)
+	assert.Empty(t, result.Errors)
+	var people model.People
+	assert.NoError(t, db.Where("people_code = ?", "P001").First(&people).Error)
+	var credential model.Credential
+	assert.NoError(t, db.Where("card_no = ?", "1001").First(&credential).Error)
+
+	departmentTreeService := service.NewDepartmentTreeServiceImpl(
+		departments,
+		repository.NewPeopleDirectoryRepositoryImpl(db),
+		repository.NewGroupDirectoryRepositoryImpl(db),
+		validator.New(),
+	)
+	assert.NoError(t, departments.SetAccess(people.DepartmentID, []uint{1}))
+	departmentTreeService.Reconcile()
+	groupIds := func() []uint {
+		var groupIds []uint
+		assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", credential.UniqueId).Pluck("access_group_id", &groupIds).Error)
+		return groupIds
+	}
+	assert.Equal(t, []uint{1}, groupIds())
+
+	remove := repository.BulkOperation{Action: repository.BulkActionRemoveAccessGroup, AccessGroupIds: []uint{1}}
+	_, err := repository.NewBulkRepositoryImpl(db).ApplyCredentials([]uint{credential.UniqueId}, remove, true)
+	assert.NoError(t, err)
+	departmentTreeService.Reconcile()
+	assert.Empty(t, groupIds())
+
+	result = peopleImportService.Import(request.ImportPeopleFileRequest{}, "people.csv", strings.NewReader("人员编号,姓,部门\nP002,李,总部/测试部/一组\n"), 1)
+	assert.Empty(t, result.Errors)
+	var count int64
+	assert.NoError(t, db.Model(&model.Department{}).Count(&count).Error)
+	assert.Equal(t, int64(6), count)
+	assert.NoError(t, repository.NewImportBatchRepositoryImpl(db).Rollback(result.BatchId, 1))
+	assert.NoError(t, db.Model(&model.Department{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count)
+}
+
+// 韦根解码：按规则取出设备码和卡号，校验位错误时列出出错的校验位
+func TestDecodeWiegand(t *testing.T) {
+	rule := &model.ValidWiegandRule{
+		Name: "H10301 26-bit", BitLength: 26,
+		FacilityStart: 1, FacilityLength: 8, CardStart: 9, CardLength: 16,
+		EvenParityBit: 0, EvenParityStart: 1, EvenParityLength: 12,
+		OddParityBit: 25, OddParityStart: 13, OddParityLength: 12,
+	}
+
+	bits, err := service.WiegandBitsFromValue("33685506", 26)
+	assert.NoError(t, err)
+	assert.Equal(t, "10000000100000000000000010", bits)
+
+	result := service.DecodeWiegand(bits, rule)
+	assert.Equal(t, uint64(1), result.FacilityCode)
+	assert.Equal(t, uint64(1), result.CardNumber)
+	assert.True(t, result.ParityValid)
+
+	bits, err = service.ParseWiegandBits("0 00000001 0000000000000001 0")
+	assert.NoError(t, err)
+	result = service.DecodeWiegand(bits, rule)
+	assert.False(t, result.ParityValid)
+	assert.Equal(t, []uint{0}, result.ParityErrors)
+
+	_, err = service.WiegandBitsFromValue("0x4000000", 26)
+	assert.Error(t, err)
+}
+
+// 指静脉模板加密：密文带随机数，每次加密结果不同，密钥错误或密文被篡改时解密失败
+func TestVeinTemplateEncryption(t *testing.T) {
+	key := make([]byte, 32)
+	data := []byte("vein template")
+
+	encrypted, err := service.EncryptVeinTemplate(key, data)
+	assert.NoError(t, err)
+	again, err := service.EncryptVeinTemplate(key, data)
+	assert.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	decrypted, err := service.DecryptVeinTemplate(key, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	key[0] = 1
+	_, err = service.DecryptVeinTemplate(key, encrypted)
+	assert.Error(t, err)
+	_, err = service.DecryptVeinTemplate(make([]byte, 32), encrypted[:len(encrypted)-1])
+	assert.Error(t, err)
+}
+
+// 指静脉模板数量限制：新增模板受每人模板数和设备容量限制，替换已有模板不受限制
+func TestVeinTemplateLimit(t *testing.T) {
+	db := newMemoryDatabase(t)
+	templates := repository.NewVeinTemplateRepositoryImpl(db)
+
+	for finger := uint(0); finger < 2; finger++ {
+		assert.NoError(t, templates.Save(model.VeinTemplate{PeopleId: 1, FingerIndex: finger, Template: []byte{1}}, 2, 3))
+	}
+	assert.ErrorIs(t, templates.Save(model.VeinTemplate{PeopleId: 1, FingerIndex: 2, Template: []byte{1}}, 2, 3), repository.ErrVeinTemplatePeopleLimit)
+	assert.NoError(t, templates.Save(model.VeinTemplate{PeopleId: 1, FingerIndex: 1, Template: []byte{2}}, 2, 3))
+
+	assert.NoError(t, templates.Save(model.VeinTemplate{PeopleId: 2, FingerIndex: 0, Template: []byte{1}}, 2, 3))
+	assert.ErrorIs(t, templates.Save(model.VeinTemplate{PeopleId: 3, FingerIndex: 0, Template: []byte{1}}, 2, 3), repository.ErrVeinTemplateCapacity)
+	assert.Equal(t, int64(3), templates.Count())
+	assert.Equal(t, []byte{2}, templates.FindByPeopleId(1)[1].Template)
+}
+
+// 挂失与补发：黑名单中的卡号不能再分配给凭证，补发的新卡恢复原凭证单独分配的门禁组和人工停用状态
+func TestLostCardReplace(t *testing.T) {
+	db := newMemoryDatabase(t)
+	lostCards := repository.NewLostCardRepositoryImpl(db)
+	people := model.People{PeopleCode: "P001", FirstName: "张"}
+	assert.NoError(t, db.Create(&people).Error)
+	credential := model.Credential{PeopleId: people.ID, CardNo: "1001"}
+	assert.NoError(t, db.Create(&credential).Error)
+	assert.NoError(t, db.Create(&model.CredentialAccess{UniqueId: credential.UniqueId, AccessGroupId: 1}).Error)
+	assert.NoError(t, db.Create(&model.CredentialAccess{UniqueId: credential.UniqueId, AccessGroupId: 2}).Error)
+	assert.NoError(t, db.Create(&model.DepartmentAccessGrant{UniqueId: credential.UniqueId, AccessGroupId: 2}).Error)
+	_, err := repository.NewBulkRepositoryImpl(db).ApplyCredentials([]uint{credential.UniqueId}, repository.BulkOperation{Action: repository.BulkActionDisable}, true)
+	assert.NoError(t, err)
+
+	lostCard, err := lostCards.Report(credential.UniqueId, model.LostCardReasonStolen, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[1]", lostCard.AccessGroups)
+	assert.Error(t, db.Create(&model.Credential{PeopleId: people.ID, CardNo: "1001"}).Error)
+	other := model.Credential{PeopleId: people.ID, CardNo: "1003"}
+	assert.NoError(t, db.Create(&other).Error)
+	assert.Error(t, db.Model(&other).Update("card_no", "1001").Error)
+
+	replaced, err := lostCards.Replace(lostCard.ID, "1002")
+	assert.NoError(t, err)
+	var accessCount int64
+	assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", replaced.UniqueId).Count(&accessCount).Error)
+	assert.Equal(t, int64(0), accessCount)
+	var state model.CredentialState
+	assert.NoError(t, db.First(&state, replaced.UniqueId).Error)
+	assert.Equal(t, uint(1), state.Disabled)
+	assert.Equal(t, model.CredentialDisabledManual, state.DisabledReason)
+	assert.Equal(t, `[1]`, state.SuspendedAccess)
+
+	_, err = lostCards.Replace(lostCard.ID, "1004")
+	assert.Error(t, err)
+}
+
+// 刷卡登记：会话读卡器上刷的卡登记为人员的凭证，已使用或已挂失的卡号登记失败，未注册卡的事件可直接登记
+func TestEnrollment(t *testing.T) {
+	db := newMemoryDatabase(t)
+	assert.NoError(t, db.Create(&model.AccessGroup{GroupId: 1, Name: "大门"}).Error)
+	people := model.People{PeopleCode: "P001", FirstName: "张"}
+	assert.NoError(t, db.Create(&people).Error)
+	assert.NoError(t, db.Create(&model.LostCard{CardNo: "2003", PeopleId: people.ID, Reason: model.LostCardReasonLost}).Error)
+
+	enrollmentService := service.NewEnrollmentServiceImpl(
+		repository.NewEnrollmentRepositoryImpl(db),
+		repository.NewEventCursorRepositoryImpl(db),
+		repository.NewGroupDirectoryRepositoryImpl(db),
+		service.NewDepartmentTreeServiceImpl(
+			repository.NewDepartmentTreeRepositoryImpl(db),
+			repository.NewPeopleDirectoryRepositoryImpl(db),
+			repository.NewGroupDirectoryRepositoryImpl(db),
+			validator.New(),
+		),
+		nil,
+		validator.New(),
+	)
+	swipe := func(cardNo string, readerAddr int) {
+		enrollmentService.Capture(&service.HubMessage{
+			Time:  time.Now(),
+			Event: &model.EventMessageData{CardNo: cardNo, EventType: model.EventTypeDenied, ReaderAddr: readerAddr},
+		})
+	}
+
+	session := enrollmentService.Start(request.StartEnrollmentRequest{PeopleId: people.ID, AccessGroupIds: []uint{1}})
+	assert.Panics(t, func() { enrollmentService.Start(request.StartEnrollmentRequest{PeopleId: people.ID}) })
+	swipe("2001", 1)
+	assert.Equal(t, service.EnrollmentWaiting, enrollmentService.FindById(session.ID).Status)
+	swipe("2001", 0)
+	enrolled := enrollmentService.FindById(session.ID)
+	assert.Equal(t, service.EnrollmentCompleted, enrolled.Status)
+	var groupIds []uint
+	assert.NoError(t, db.Model(&model.CredentialAccess{}).Where("unique_id = ?", enrolled.UniqueId).Pluck("access_group_id", &groupIds).Error)
+	assert.Equal(t, []uint{1}, groupIds)
+
+	for _, cardNo := range []string{"2001", "2003"} {
+		session = enrollmentService.Start(request.StartEnrollmentRequest{PeopleId: people.ID})
+		swipe(cardNo, 0)
+		assert.Equal(t, service.EnrollmentFailed, enrollmentService.FindById(session.ID).Status)
+	}
+
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 10, CardNo: "2002", EventType: model.EventTypeDenied}).Error)
+	credential := enrollmentService.CreateFromEvent(request.CreateCredentialFromEventRequest{MsgId: 10, PeopleId: people.ID})
+	assert.Equal(t, "2002", credential.CardNo)
+	assert.Panics(t, func() {
+		enrollmentService.CreateFromEvent(request.CreateCredentialFromEventRequest{MsgId: 10, PeopleId: people.ID})
+	})
+}
+
+// 数据质量报告：重复卡号、没有凭证的人员、没有门禁组的凭证（不含已停用的凭证）和不存在的引用
+func TestBuildDataQualityReport(t *testing.T) {
+	report := service.BuildDataQualityReport(service.DataQualitySource{
+		Peoples: []*model.People{
+			{ID: 1, PeopleCode: "P1", DepartmentID: 1},
+			{ID: 2, PeopleCode: "P2", DepartmentID: 9},
+			{ID: 3, PeopleCode: "P3"},
+		},
+		Departments: []*model.Department{{ID: 1}},
+		Credentials: []*model.Credential{
+			{UniqueId: 10, PeopleId: 1, CardNo: "100"},
+			{UniqueId: 11, PeopleId: 2, CardNo: "100"},
+			{UniqueId: 12, PeopleId: 2, CardNo: "200"},
+			{UniqueId: 13, PeopleId: 8, CardNo: "300"},
+		},
+		CredentialAccesses: []*model.CredentialAccess{
+			{UniqueId: 10, AccessGroupId: 1},
+			{UniqueId: 11, AccessGroupId: 5},
+			{UniqueId: 13, AccessGroupId: 1},
+		},
+		DepartmentAccesses: []*model.DepartmentAccess{{DepartmentId: 1, AccessGroupId: 6}},
+		AccessGroups:       []*model.AccessGroup{{GroupId: 1}},
+		States:             []*model.CredentialState{{UniqueId: 12, Disabled: 1}},
+	})
+
+	assert.Len(t, report.DuplicateCards, 1)
+	assert.Equal(t, "100", report.DuplicateCards[0].CardNo)
+	assert.Len(t, report.DuplicateCards[0].Credentials, 2)
+	assert.Equal(t, "P2", report.DuplicateCards[0].Credentials[1].PeopleCode)
+
+	assert.Len(t, report.PeopleWithoutCredential, 1)
+	assert.Equal(t, uint(3), report.PeopleWithoutCredential[0].PeopleId)
+	assert.Empty(t, report.CredentialsWithoutAccess)
+
+	var missing []string
+	for _, reference := range report.MissingReferences {
+		missing = append(missing, fmt.Sprintf("%s %d %s %d", reference.Kind, reference.RefId, reference.MissingKind, reference.MissingId))
+	}
+	assert.ElementsMatch(t, []string{
+		"people 2 department 9",
+		"credential 13 people 8",
+		"credential_access 11 access_group 5",
+		"department_access 1 access_group 6",
+	}, missing)
+}
+
+// 数据完整性触发器：违反约束的写入返回 database 包的错误，已删除的凭证和人员不再占用卡号和部门
+func TestIntegrityTriggers(t *testing.T) {
+	db := newMemoryDatabase(t)
+	department := model.Department{Name: "研发部"}
+	assert.NoError(t, db.Create(&department).Error)
+	people := model.People{PeopleCode: "E001", FirstName: "三", LastName: "张", DepartmentID: department.ID}
+	assert.NoError(t, db.Create(&people).Error)
+
+	err := db.Create(&model.People{PeopleCode: "E002", FirstName: "四", LastName: "李", DepartmentID: department.ID + 100}).Error
+	assert.ErrorIs(t, err, database.ErrDepartmentNotFound)
+	err = db.Model(&people).Update("department_id", department.ID+100).Error
+	assert.ErrorIs(t, err, database.ErrDepartmentNotFound)
+
+	// 部门有软删除列时删除是 UPDATE，同样被拒绝
+	err = db.Delete(&model.Department{}, department.ID).Error
+	assert.ErrorIs(t, err, database.ErrDepartmentInUse)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Delete(&model.Department{}, department.ID).Error
+	})
+	assert.ErrorIs(t, err, database.ErrDepartmentInUse)
+
+	credential := model.Credential{PeopleId: people.ID, CardNo: "3001"}
+	assert.NoError(t, db.Create(&credential).Error)
+	err = db.Create(&model.Credential{PeopleId: people.ID, CardNo: "3001"}).Error
+	assert.ErrorIs(t, err, database.ErrCardInUse)
+	assert.NoError(t, db.Create(&model.LostCard{CardNo: "3002", PeopleId: people.ID, Reason: model.LostCardReasonLost}).Error)
+	err = db.Model(&credential).Update("card_no", "3002").Error
+	assert.ErrorIs(t, err, database.ErrCardLost)
+
+	// 删除凭证后卡号可以重新分配
+	assert.NoError(t, db.Delete(&model.Credential{}, credential.UniqueId).Error)
+	assert.NoError(t, db.Create(&model.Credential{PeopleId: people.ID, CardNo: "3001"}).Error)
+
+	// 删除人员后部门可以删除
+	assert.NoError(t, db.Delete(&model.People{}, people.ID).Error)
+	assert.NoError(t, db.Delete(&model.Department{}, department.ID).Error)
+}
+
+// 凭证使用情况：只汇总有凭证的允许通行事件，没有凭证 ID 的事件按卡号查找凭证；
+// 最近一次通行的门取时间最晚的事件；未使用天数从最近通行或开始跟踪时计算
+func TestAggregateCredentialUsage(t *testing.T) {
+	usages := service.AggregateCredentialUsage([]*model.EventMessageData{
+		{MsgId: 1, EventType: model.EventTypeGranted, UniqueId: 1, AccessTime: "2026-10-02 09:00:00", IBAddr: 1, ReaderAddr: 2},
+		{MsgId: 2, EventType: model.EventTypeGranted, UniqueId: 1, AccessTime: "2026-10-01 09:00:00", IBAddr: 1, ReaderAddr: 3},
+		{MsgId: 3, EventType: model.EventTypeDenied, UniqueId: 2, AccessTime: "2026-10-03 09:00:00"},
+		{MsgId: 4, EventType: model.EventTypeGranted, AccessTime: "2026-10-03 09:00:00"},
+		{MsgId: 5, EventType: model.EventTypeGranted, CardNo: "1001", AccessTime: "2026-10-01 08:00:00", IBAddr: 1, ReaderAddr: 4},
+		{MsgId: 6, EventType: model.EventTypeGranted, CardNo: "9999", AccessTime: "2026-10-03 09:00:00"},
+	}, map[string]uint{"1001": 1})
+
+	assert.Len(t, usages, 1)
+	usage := usages[0]
+	assert.Equal(t, uint(1), usage.UniqueId)
+	assert.Equal(t, uint(3), usage.UseCount)
+	assert.Equal(t, 2, usage.ReaderAddr)
+	assert.Equal(t, uint(service.ParseAccessTime("2026-10-02 09:00:00").Unix()), usage.LastUsedAt)
+	assert.Equal(t, uint(service.ParseAccessTime("2026-10-01 08:00:00").Unix()), usage.TrackedSince)
+
+	now := service.ParseAccessTime("2026-10-12 08:00:00")
+	assert.Equal(t, uint(9), service.CredentialIdleDays(usage, now))
+	assert.Equal(t, uint(3), service.CredentialIdleDays(&model.CredentialUsage{TrackedSince: uint(now.AddDate(0, 0, -3).Unix())}, now))
+	assert.Equal(t, uint(0), service.CredentialIdleDays(&model.CredentialUsage{}, now))
+}
+
+// 凭证使用情况汇总：事件中没有凭证 ID 时按卡号计入凭证，游标记录已汇总的最后一条事件
+func TestCredentialUsageAggregateByCardNo(t *testing.T) {
+	db := newMemoryDatabase(t)
+	credential := model.Credential{PeopleId: 1, CardNo: "4001"}
+	assert.NoError(t, db.Create(&credential).Error)
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 1, EventType: model.EventTypeGranted, CardNo: "4001", AccessTime: "2026-10-01 09:00:00", IBAddr: 1, ReaderAddr: 2}).Error)
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 2, EventType: model.EventTypeGranted, UniqueId: credential.UniqueId, AccessTime: "2026-10-02 09:00:00", IBAddr: 1, ReaderAddr: 3}).Error)
+
+	cursors := repository.NewEventCursorRepositoryImpl(db)
+	usageService := service.NewCredentialUsageServiceImpl(
+		repository.NewCredentialUsageRepositoryImpl(db),
+		cursors,
+		repository.NewPeopleDirectoryRepositoryImpl(db),
+		repository.NewCredentialValidityRepositoryImpl(db),
+		repository.NewBulkRepositoryImpl(db),
+		validator.New(),
+	)
+	usageService.Aggregate()
+
+	usages := usageService.FindAll()
+	assert.Len(t, usages, 1)
+	assert.Equal(t, credential.UniqueId, usages[0].UniqueId)
+	assert.Equal(t, uint(2), usages[0].UseCount)
+	assert.Equal(t, 3, usages[0].ReaderAddr)
+
+	cursor, err := cursors.FindByName("credential_usage")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), cursor.LastMsgId)
+}
+
+// 凭证使用情况汇总：消息 ID 重新编号后游标回到起点，新事件继续汇总
+func TestCredentialUsageCursorReset(t *testing.T) {
+	db := newMemoryDatabase(t)
+	credential := model.Credential{PeopleId: 1, CardNo: "4001"}
+	assert.NoError(t, db.Create(&credential).Error)
+	cursors := repository.NewEventCursorRepositoryImpl(db)
+	assert.NoError(t, cursors.Save("credential_usage", 100))
+	assert.NoError(t, db.Create(&model.EventMessageData{MsgId: 1, EventType: model.EventTypeGranted, UniqueId: credential.UniqueId, AccessTime: "2026-10-01 09:00:00", IBAddr: 1, ReaderAddr: 2}).Error)
+
+	usageService := service.NewCredentialUsageServiceImpl(
+		repository.NewCredentialUsageRepositoryImpl(db),
+		cursors,
+		repository.NewPeopleDirectoryRepositoryImpl(db),
+		repository.NewCredentialValidityRepositoryImpl(db),
+		repository.NewBulkRepositoryImpl(db),
+		validator.New(),
+	)
+	usageService.Aggregate()
+
+	usages := usageService.FindAll()
+	assert.Len(t, usages, 1)
+	assert.Equal(t, uint(1), usages[0].UseCount)
+
+	cursor, err := cursors.FindByName("credential_usage")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), cursor.LastMsgId)
+}